@@ -15,9 +15,47 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
+        "/services": {
+            "get": {
+                "description": "Возвращает записи каталога по алфавиту.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Список сервисов каталога",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Категория",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Service"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Создает новую подписку с указанными параметрами.",
+                "description": "Создает запись каталога. Название и псевдонимы сравниваются без учета регистра и не должны совпадать с другими записями.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,17 +63,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "services"
                 ],
-                "summary": "Создать новую подписку",
+                "summary": "Добавить сервис в каталог",
                 "parameters": [
                     {
-                        "description": "Данные подписки",
-                        "name": "subscription",
+                        "description": "Данные сервиса",
+                        "name": "service",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CreateSubscriptionInput"
+                            "$ref": "#/definitions/models.ServiceInput"
                         }
                     }
                 ],
@@ -43,33 +81,32 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Subscription"
+                            "$ref": "#/definitions/models.Service"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/view/list": {
+        "/services/{id}": {
             "get": {
-                "description": "Возвращает список всех подписок без фильтров",
                 "consumes": [
                     "application/json"
                 ],
@@ -77,34 +114,47 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "services"
+                ],
+                "summary": "Вернуть сервис каталога",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сервиса (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Получить список всех подписок",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Subscription"
-                            }
+                            "$ref": "#/definitions/models.Service"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/subscriptions/view/total/{date}": {
-            "get": {
-                "description": "Возвращает сумму подписок за указанную дату с возможностью фильтрации по пользователю и сервису",
+            },
+            "put": {
+                "description": "Заменяет запись каталога. При смене названия связанные подписки получают новое service_name.",
                 "consumes": [
                     "application/json"
                 ],
@@ -112,62 +162,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "services"
                 ],
-                "summary": "Подсчитывает общую стоимость подписок за выбранную дату",
+                "summary": "Изменить сервис каталога",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Дата в формате YYYY-MM-DD",
-                        "name": "date",
+                        "description": "ID сервиса (UUID)",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "ID пользователя (UUID)",
-                        "name": "user_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Название сервиса",
-                        "name": "service_name",
-                        "in": "query"
+                        "description": "Данные сервиса",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ServiceInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.Service"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/subscriptions/{id}": {
-            "get": {
-                "description": "Возвращает подписку по id пользователя.",
+            },
+            "delete": {
+                "description": "Подписки на сервис сохраняют service_name, но теряют service_id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -175,47 +225,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "services"
                 ],
-                "summary": "Вернуть подписку по ID",
+                "summary": "Удалить сервис из каталога",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID пользователя (UUID)",
+                        "description": "ID сервиса (UUID)",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "$ref": "#/definitions/models.Subscription"
+                            "type": "string"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Обновляет информацию о подписке по заданному ID.",
+            }
+        },
+        "/subscriptions": {
+            "post": {
+                "description": "Создает новую подписку с указанными параметрами.",
                 "consumes": [
                     "application/json"
                 ],
@@ -225,28 +277,21 @@ const docTemplate = `{
                 "tags": [
                     "subscriptions"
                 ],
-                "summary": "Обновить подписку по ID",
+                "summary": "Создать новую подписку",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID пользователя (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Данные для обновления подписки",
+                        "description": "Данные подписки",
                         "name": "subscription",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateSubscriptionInput"
+                            "$ref": "#/definitions/models.CreateSubscriptionInput"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "$ref": "#/definitions/models.Subscription"
                         }
@@ -254,34 +299,21 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет подписку по ID пользователя",
+            }
+        },
+        "/subscriptions/search": {
+            "get": {
+                "description": "Нечеткий поиск без учета регистра: запрос \"netflix\" находит \"Netflix\", \"NETFLIX\" и \"Netflix Premium\".\nРезультаты упорядочены по убыванию релевантности.",
                 "consumes": [
                     "application/json"
                 ],
@@ -291,95 +323,2491 @@ const docTemplate = `{
                 "tags": [
                     "subscriptions"
                 ],
-                "summary": "Удаляет подписку по ID",
+                "summary": "Найти подписки по названию сервиса",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID пользователя (UUID)",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Поисковый запрос",
+                        "name": "q",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимум результатов, не больше 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SearchResult"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/upcoming": {
+            "get": {
+                "description": "Возвращает подписки, ближайшее списание которых попадает в окно [сегодня, сегодня + within].\nДаты списаний отсчитываются от start_date с шагом billing_period; в пробный период и на паузе списаний нет.\nРезультаты упорядочены по дате списания.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Предстоящие списания",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Окно поиска: число дней с суффиксом d (7d) или длительность Go (36h), по умолчанию 7d",
+                        "name": "within",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UpcomingCharge"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.CreateSubscriptionInput": {
-            "type": "object",
-            "properties": {
-                "end_date": {
-                    "type": "string",
-                    "example": "08-2025"
-                },
-                "price": {
-                    "type": "integer",
-                    "example": 10
+        },
+        "/subscriptions/view/breakdown": {
+            "get": {
+                "description": "Возвращает стоимость подписок за день date по сервисам, категориям каталога или пользователям\nи долю каждой группы в процентах. Стоимость считается так же, как в /subscriptions/view/total/{date}.\nПодписки вне каталога и сервисы без категории попадают в категорию uncategorized.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Разбивка стоимости подписок по группам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дата в формате YYYY-MM-DD",
+                        "name": "date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "service",
+                            "category",
+                            "user"
+                        ],
+                        "type": "string",
+                        "description": "Группировка",
+                        "name": "group_by",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта результата (ISO 4217), по умолчанию RUB",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CostBreakdown"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/view/list": {
+            "get": {
+                "description": "Возвращает страницу подписок и их общее число. in_trial=true оставляет только подписки в пробном периоде.\nСтраницу можно выбрать номером page или курсором cursor из next_cursor предыдущего ответа.\nnext_cursor возвращается только при сортировке по умолчанию.\nВыборка по курсору не пропускает и не повторяет подписки, созданные во время листания.\nСсылки на соседние страницы передаются в заголовке Link (RFC 8288).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Получить список всех подписок",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы (с 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор из next_cursor, нельзя передавать вместе с page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы, не больше 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Фильтр по пробному периоду",
+                        "name": "in_trial",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса, точное совпадение",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало названия сервиса",
+                        "name": "service_name_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Минимальная начальная цена в минорных единицах",
+                        "name": "price_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальная начальная цена в минорных единицах",
+                        "name": "price_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только подписки, действующие в этот день (YYYY-MM-DD)",
+                        "name": "active_on",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "start_date не раньше (YYYY-MM-DD)",
+                        "name": "start_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "start_date не позже (YYYY-MM-DD)",
+                        "name": "start_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка field:asc|desc через запятую: service_name, price, currency, billing_period, user_id, start_date, end_date, trial_end",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Добавить удаленные подписки; только с X-Admin-Token",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Токен администратора",
+                        "name": "X-Admin-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки first, prev, next, last"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/view/report": {
+            "get": {
+                "description": "Возвращает стоимость подписок по месяцам периода [from, to] и общий итог в минорных единицах валюты.\nДля каждого месяца перечислены подписки, которые в него вошли.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Помесячный отчет о стоимости подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Первый месяц периода в формате YYYY-MM",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний месяц периода в формате YYYY-MM",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта отчета (ISO 4217), по умолчанию RUB",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CostReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/view/total/{date}": {
+            "get": {
+                "description": "Возвращает сумму подписок за указанную дату в минорных единицах валюты с возможностью фильтрации по пользователю и сервису.\nЦены в других валютах пересчитываются по курсу, опубликованному не позже date; дата курса возвращается в rate_date.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Подсчитывает общую стоимость подписок за выбранную дату",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дата в формате YYYY-MM-DD",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта результата (ISO 4217), по умолчанию RUB",
+                        "name": "currency",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "Возвращает подписку по её id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Вернуть подписку по ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки для If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет информацию о подписке по заданному ID.\nЗаголовок If-Match должен содержать ETag текущей версии подписки (или *).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Обновить подписку по ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag подписки из GET /subscriptions/{id}",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для обновления подписки",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateSubscriptionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Подписку изменили, в ответе актуальная версия",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Помечает подписку удаленной: она пропадает из выборок и расчетов, но её можно восстановить\nчерез POST /subscriptions/{id}/restore, пока она не очищена по сроку хранения.\nЗаголовок If-Match должен содержать ETag текущей версии подписки (или *).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Удаляет подписку по ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag подписки из GET /subscriptions/{id}",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Подписку изменили, в ответе актуальная версия",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Применяет к подписке документ JSON Merge Patch (RFC 7396): переданные поля заменяются, null удаляет значение, остальные поля не меняются.\nПоля те же, что при создании подписки; результат проверяется по тем же правилам. Сохраняются только изменившиеся колонки.\nЗаголовок If-Match должен содержать ETag текущей версии подписки (или *).",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Частично обновить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag подписки из GET /subscriptions/{id}",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменяемые поля подписки",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateSubscriptionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Подписку изменили, в ответе актуальная версия",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/cancel": {
+            "post": {
+                "description": "Завершает подписку сразу или в конце текущего оплаченного периода и сохраняет причину отмены.\nУже завершившуюся подписку отменить нельзя; если подписка изменилась во время отмены, возвращается 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Отменить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Параметры отмены",
+                        "name": "cancellation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CancelSubscriptionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CancelSubscriptionResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/history": {
+            "get": {
+                "description": "Возвращает записи о создании, изменениях, отмене, удалении и восстановлении подписки в порядке времени.\nКаждая запись содержит автора (X-Actor), ID запроса (X-Request-ID) и снимки подписки до и после изменения.\nЖурнал доступен и после удаления подписки.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Журнал изменений подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы (с 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы, не больше 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionEventPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки first, prev, next, last"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/pause": {
+            "post": {
+                "description": "Замораживает подписку с даты from (по умолчанию сегодня) до until включительно или до возобновления.\nДни паузы не учитываются в стоимости.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Приостановить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Интервал паузы",
+                        "name": "pause",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.PauseSubscriptionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/prices": {
+            "post": {
+                "description": "Сохраняет новую цену, действующую с первого дня месяца effective_from.\nСтоимость за прошлые периоды считается по ценам, действовавшим в те дни.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Изменить цену подписки с указанного месяца",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая цена",
+                        "name": "price",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SchedulePriceChangeInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/restore": {
+            "post": {
+                "description": "Возвращает подписку, удаленную через DELETE /subscriptions/{id}, пока она не очищена по сроку хранения.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Восстановить удаленную подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/resume": {
+            "post": {
+                "description": "Завершает текущую или запланированную паузу: подписка снова оплачивается с даты date (по умолчанию сегодня).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Возобновить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Дата возобновления",
+                        "name": "resume",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResumeSubscriptionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/budget": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Вернуть бюджет пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Budget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Создает или заменяет месячный лимит расходов пользователя на подписки.\nПри достижении каждого порога (в процентах лимита) пользователь получает уведомление, не чаще раза в месяц.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Установить бюджет пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные бюджета",
+                        "name": "budget",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BudgetInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Budget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Удалить бюджет пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/budget/status": {
+            "get": {
+                "description": "Сравнивает лимит со стоимостью подписок пользователя за текущий месяц.\nРасходы — итоги GET /subscriptions/view/total за каждый день месяца, включая еще не наступившие дни.\nСуммы в других валютах пересчитываются в валюту бюджета по текущему курсу.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Расходы пользователя в сравнении с бюджетом",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BudgetStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/subscriptions": {
+            "get": {
+                "description": "Возвращает подписки указанного пользователя с постраничной выборкой",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получить подписки пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы (с 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы, не больше 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Subscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает новую подписку для пользователя из пути. Поле user_id в теле можно не передавать.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Создать подписку пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные подписки",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateSubscriptionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/subscriptions/{id}": {
+            "get": {
+                "description": "Возвращает подписку по id, если она принадлежит указанному пользователю.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Вернуть подписку пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID подписки (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки для If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.BillingPeriod": {
+            "type": "string",
+            "enum": [
+                "weekly",
+                "monthly",
+                "quarterly",
+                "yearly"
+            ],
+            "x-enum-varnames": [
+                "BillingWeekly",
+                "BillingMonthly",
+                "BillingQuarterly",
+                "BillingYearly"
+            ]
+        },
+        "models.Budget": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "monthly_limit": {
+                    "description": "в минорных единицах currency",
+                    "type": "integer",
+                    "example": 500000
+                },
+                "thresholds": {
+                    "description": "проценты лимита по возрастанию",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        80,
+                        100
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BudgetInput": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "description": "ISO 4217, по умолчанию RUB",
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "monthly_limit": {
+                    "description": "в минорных единицах currency",
+                    "type": "integer",
+                    "example": 500000
+                },
+                "thresholds": {
+                    "description": "по умолчанию 80 и 100",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        80,
+                        100
+                    ]
+                }
+            }
+        },
+        "models.BudgetStatus": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2025-07"
+                },
+                "percent_used": {
+                    "type": "number",
+                    "example": 83.4
+                },
+                "rate_date": {
+                    "description": "дата курса, если был пересчет валют",
+                    "type": "string",
+                    "example": "2025-07-01"
+                },
+                "reached_thresholds": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "remaining": {
+                    "type": "number"
+                },
+                "spent": {
+                    "description": "сумма дневных итогов за все дни месяца",
+                    "type": "number"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CancelEffective": {
+            "type": "string",
+            "enum": [
+                "immediate",
+                "end_of_period"
+            ],
+            "x-enum-varnames": [
+                "CancelImmediate",
+                "CancelEndOfPeriod"
+            ]
+        },
+        "models.CancelSubscriptionInput": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "example": "Нашли тариф дешевле"
+                },
+                "effective": {
+                    "description": "по умолчанию immediate",
+                    "enum": [
+                        "immediate",
+                        "end_of_period"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CancelEffective"
+                        }
+                    ],
+                    "example": "end_of_period"
+                },
+                "reason": {
+                    "enum": [
+                        "too_expensive",
+                        "not_used",
+                        "switched_service",
+                        "other"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CancellationReason"
+                        }
+                    ],
+                    "example": "too_expensive"
+                }
+            }
+        },
+        "models.CancelSubscriptionResult": {
+            "type": "object",
+            "properties": {
+                "cancellation": {
+                    "$ref": "#/definitions/models.Cancellation"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/models.Subscription"
+                }
+            }
+        },
+        "models.Cancellation": {
+            "type": "object",
+            "properties": {
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "effective_date": {
+                    "description": "новый end_date подписки",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "$ref": "#/definitions/models.CancellationReason"
+                },
+                "subscription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CancellationReason": {
+            "type": "string",
+            "enum": [
+                "too_expensive",
+                "not_used",
+                "switched_service",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "ReasonTooExpensive",
+                "ReasonNotUsed",
+                "ReasonSwitchedService",
+                "ReasonOther"
+            ]
+        },
+        "models.CostBreakdown": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2025-07-15"
+                },
+                "group_by": {
+                    "type": "string",
+                    "enum": [
+                        "service",
+                        "category",
+                        "user"
+                    ],
+                    "example": "category"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CostBreakdownGroup"
+                    }
+                },
+                "rate_date": {
+                    "description": "дата курса, если был пересчет валют",
+                    "type": "string",
+                    "example": "2025-07-01"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CostBreakdownGroup": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "description": "название сервиса, категория или ID пользователя",
+                    "type": "string",
+                    "example": "video"
+                },
+                "percentage": {
+                    "type": "number",
+                    "example": 42.5
+                },
+                "total": {
+                    "description": "в минорных единицах валюты разбивки",
+                    "type": "number"
+                }
+            }
+        },
+        "models.CostReport": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2025-01"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CostReportMonth"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-12"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CostReportItem": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "description": "в минорных единицах валюты отчета",
+                    "type": "number"
+                },
+                "currency": {
+                    "description": "исходная валюта подписки",
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CostReportMonth": {
+            "type": "object",
+            "properties": {
+                "month": {
+                    "type": "string",
+                    "example": "2025-07"
+                },
+                "rate_date": {
+                    "description": "дата курса, если был пересчет валют",
+                    "type": "string",
+                    "example": "2025-07-01"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CostReportItem"
+                    }
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CreateSubscriptionInput": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "description": "по умолчанию monthly",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BillingPeriod"
+                        }
+                    ],
+                    "example": "monthly"
+                },
+                "currency": {
+                    "description": "ISO 4217, по умолчанию RUB",
+                    "type": "string",
+                    "example": "USD"
+                },
+                "end_date": {
+                    "description": "не указывается для бессрочной подписки",
+                    "type": "string",
+                    "example": "08-2025"
+                },
+                "price": {
+                    "description": "в минорных единицах валюты; по умолчанию цена из каталога, тогда currency и billing_period берутся из каталога",
+                    "type": "integer",
+                    "example": 1099
+                },
+                "service_id": {
+                    "description": "запись каталога; если не указана, ищется по service_name и псевдонимам",
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "start_date": {
+                    "description": "формат \"01-2006\"",
+                    "type": "string",
+                    "example": "07-2025"
+                },
+                "trial_end": {
+                    "description": "первый оплачиваемый день, формат \"2006-01-02\"",
+                    "type": "string",
+                    "example": "2025-07-15"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "models.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "enum": [
+                        "required",
+                        "invalid_format",
+                        "invalid_value",
+                        "out_of_range",
+                        "unknown"
+                    ],
+                    "example": "invalid_value"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "currency"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Invalid currency"
+                }
+            }
+        },
+        "models.Pause": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "description": "последний день паузы; пусто — до возобновления",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PauseSubscriptionInput": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "первый день паузы, по умолчанию сегодня",
+                    "type": "string",
+                    "example": "2025-09-01"
+                },
+                "until": {
+                    "description": "последний день паузы; пусто — до возобновления",
+                    "type": "string",
+                    "example": "2025-11-30"
+                }
+            }
+        },
+        "models.PriceChange": {
+            "type": "object",
+            "properties": {
+                "effective_from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "price": {
+                    "description": "в минорных единицах валюты подписки",
+                    "type": "integer"
+                },
+                "subscription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "Invalid currency"
+                },
+                "errors": {
+                    "description": "ошибки проверки отдельных полей",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/subscriptions"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Bad Request"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "models.ResumeSubscriptionInput": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "день возобновления, по умолчанию сегодня",
+                    "type": "string",
+                    "example": "2025-10-15"
+                }
+            }
+        },
+        "models.SchedulePriceChangeInput": {
+            "type": "object",
+            "properties": {
+                "effective_from": {
+                    "description": "месяц, с которого действует цена, формат \"01-2006\"",
+                    "type": "string",
+                    "example": "09-2025"
+                },
+                "price": {
+                    "description": "в минорных единицах валюты подписки",
+                    "type": "integer",
+                    "example": 1299
+                }
+            }
+        },
+        "models.SearchResult": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "description": "weekly, monthly, quarterly или yearly",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BillingPeriod"
+                        }
+                    ]
+                },
+                "currency": {
+                    "description": "код валюты ISO 4217, например RUB",
+                    "type": "string"
+                },
+                "current_price": {
+                    "description": "цена, действующая сегодня",
+                    "type": "integer"
+                },
+                "deleted_at": {
+                    "description": "момент удаления; удаленные подписки видны только администратору",
+                    "type": "string"
+                },
+                "end_date": {
+                    "description": "пусто — подписка действует до отмены",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "pauses": {
+                    "description": "история пауз, по возрастанию start_date",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Pause"
+                    }
+                },
+                "price": {
+                    "description": "начальная цена в минорных единицах валюты (копейки, центы) за один billing_period",
+                    "type": "integer"
+                },
+                "price_changes": {
+                    "description": "изменения цены, по возрастанию effective_from",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PriceChange"
+                    }
+                },
+                "relevance": {
+                    "description": "от 0 до 1, чем больше, тем ближе название к запросу",
+                    "type": "number",
+                    "example": 0.83
+                },
+                "service_id": {
+                    "description": "запись каталога сервисов; пусто — service_name не найден в каталоге",
+                    "type": "string"
                 },
                 "service_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "description": "месяц и год, например 07-2025",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Вычисляемые поля, в базе не хранятся",
+                    "enum": [
+                        "active",
+                        "trial",
+                        "paused",
+                        "ended"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SubscriptionStatus"
+                        }
+                    ]
+                },
+                "trial_end": {
+                    "description": "первый оплачиваемый день после пробного периода",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "растет при каждом изменении подписки, передается в ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Service": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "description": "другие написания в нижнем регистре",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "billing_period": {
+                    "$ref": "#/definitions/models.BillingPeriod"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "default_price": {
+                    "description": "в минорных единицах Currency",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "каноническое название",
+                    "type": "string"
+                },
+                "website": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ServiceInput": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "netflix premium",
+                        "нетфликс"
+                    ]
+                },
+                "billing_period": {
+                    "description": "по умолчанию monthly",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BillingPeriod"
+                        }
+                    ],
+                    "example": "monthly"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "video"
+                },
+                "currency": {
+                    "description": "по умолчанию RUB",
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "default_price": {
+                    "description": "в минорных единицах валюты",
+                    "type": "integer",
+                    "example": 79900
+                },
+                "name": {
                     "type": "string",
                     "example": "Netflix"
                 },
-                "start_date": {
-                    "description": "формат \"01-2006\"",
+                "website": {
                     "type": "string",
-                    "example": "07-2025"
+                    "example": "https://www.netflix.com"
+                }
+            }
+        },
+        "models.Subscription": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "description": "weekly, monthly, quarterly или yearly",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BillingPeriod"
+                        }
+                    ]
+                },
+                "currency": {
+                    "description": "код валюты ISO 4217, например RUB",
+                    "type": "string"
+                },
+                "current_price": {
+                    "description": "цена, действующая сегодня",
+                    "type": "integer"
+                },
+                "deleted_at": {
+                    "description": "момент удаления; удаленные подписки видны только администратору",
+                    "type": "string"
+                },
+                "end_date": {
+                    "description": "пусто — подписка действует до отмены",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "pauses": {
+                    "description": "история пауз, по возрастанию start_date",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Pause"
+                    }
+                },
+                "price": {
+                    "description": "начальная цена в минорных единицах валюты (копейки, центы) за один billing_period",
+                    "type": "integer"
+                },
+                "price_changes": {
+                    "description": "изменения цены, по возрастанию effective_from",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PriceChange"
+                    }
+                },
+                "service_id": {
+                    "description": "запись каталога сервисов; пусто — service_name не найден в каталоге",
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "description": "месяц и год, например 07-2025",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Вычисляемые поля, в базе не хранятся",
+                    "enum": [
+                        "active",
+                        "trial",
+                        "paused",
+                        "ended"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SubscriptionStatus"
+                        }
+                    ]
+                },
+                "trial_end": {
+                    "description": "первый оплачиваемый день после пробного периода",
+                    "type": "string"
                 },
                 "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "растет при каждом изменении подписки, передается в ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SubscriptionAction": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "delete",
+                "cancel",
+                "restore"
+            ],
+            "x-enum-varnames": [
+                "ActionCreate",
+                "ActionUpdate",
+                "ActionDelete",
+                "ActionCancel",
+                "ActionRestore"
+            ]
+        },
+        "models.SubscriptionEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "cancel",
+                        "restore"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SubscriptionAction"
+                        }
+                    ]
+                },
+                "actor": {
+                    "description": "из заголовка X-Actor",
                     "type": "string",
-                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                    "example": "alice@example.com"
+                },
+                "after": {
+                    "description": "подписка после изменения; null для delete",
+                    "type": "object"
+                },
+                "before": {
+                    "description": "подписка до изменения; null для create, для restore — удаленная подписка",
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "из заголовка X-Request-ID",
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "string"
                 }
             }
         },
-        "models.Subscription": {
+        "models.SubscriptionEventPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SubscriptionEvent"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "models.SubscriptionPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Subscription"
+                    }
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "description": "NextCursor — курсор следующей страницы; пусто, если страница последняя",
+                    "type": "string"
+                },
+                "page": {
+                    "description": "не заполняется при выборке по курсору",
+                    "type": "integer",
+                    "example": 1
+                },
+                "total": {
+                    "description": "число подписок, подходящих под фильтр",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "models.SubscriptionStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "trial",
+                "paused",
+                "ended"
+            ],
+            "x-enum-varnames": [
+                "StatusActive",
+                "StatusTrial",
+                "StatusPaused",
+                "StatusEnded"
+            ]
+        },
+        "models.UpcomingCharge": {
             "type": "object",
             "properties": {
+                "amount": {
+                    "description": "цена, действующая в день списания",
+                    "type": "integer"
+                },
+                "billing_period": {
+                    "description": "weekly, monthly, quarterly или yearly",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BillingPeriod"
+                        }
+                    ]
+                },
+                "charge_date": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "код валюты ISO 4217, например RUB",
+                    "type": "string"
+                },
+                "current_price": {
+                    "description": "цена, действующая сегодня",
+                    "type": "integer"
+                },
+                "deleted_at": {
+                    "description": "момент удаления; удаленные подписки видны только администратору",
+                    "type": "string"
+                },
                 "end_date": {
-                    "description": "опционально",
+                    "description": "пусто — подписка действует до отмены",
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
+                "pauses": {
+                    "description": "история пауз, по возрастанию start_date",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Pause"
+                    }
+                },
                 "price": {
-                    "description": "в рублях, целое число",
+                    "description": "начальная цена в минорных единицах валюты (копейки, центы) за один billing_period",
                     "type": "integer"
                 },
+                "price_changes": {
+                    "description": "изменения цены, по возрастанию effective_from",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PriceChange"
+                    }
+                },
+                "service_id": {
+                    "description": "запись каталога сервисов; пусто — service_name не найден в каталоге",
+                    "type": "string"
+                },
                 "service_name": {
                     "type": "string"
                 },
@@ -387,21 +2815,64 @@ const docTemplate = `{
                     "description": "месяц и год, например 07-2025",
                     "type": "string"
                 },
+                "status": {
+                    "description": "Вычисляемые поля, в базе не хранятся",
+                    "enum": [
+                        "active",
+                        "trial",
+                        "paused",
+                        "ended"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SubscriptionStatus"
+                        }
+                    ]
+                },
+                "trial_end": {
+                    "description": "первый оплачиваемый день после пробного периода",
+                    "type": "string"
+                },
                 "user_id": {
                     "type": "string"
+                },
+                "version": {
+                    "description": "растет при каждом изменении подписки, передается в ETag",
+                    "type": "integer"
                 }
             }
         },
         "models.UpdateSubscriptionInput": {
             "type": "object",
             "properties": {
+                "billing_period": {
+                    "description": "если не указан, не меняется",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BillingPeriod"
+                        }
+                    ],
+                    "example": "yearly"
+                },
+                "currency": {
+                    "description": "если не указана, не меняется",
+                    "type": "string",
+                    "example": "USD"
+                },
                 "end_date": {
                     "type": "string",
                     "example": "2025-08-01"
                 },
                 "price": {
+                    "description": "в минорных единицах валюты",
                     "type": "integer",
-                    "example": 15
+                    "example": 1599
                 },
                 "service_name": {
                     "type": "string",
@@ -412,6 +2883,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "2025-07-01"
                 },
+                "trial_end": {
+                    "type": "string",
+                    "example": "2025-07-15"
+                },
                 "user_id": {
                     "type": "string",
                     "example": "550e8400-e29b-41d4-a716-446655440000"
@@ -428,7 +2903,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "",
 	Schemes:          []string{},
 	Title:            "Subscription Service API",
-	Description:      "API для управления подписками.",
+	Description:      "API для управления подписками.\nОшибки возвращаются в формате application/problem+json (RFC 7807); ошибки проверки полей перечислены в errors.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
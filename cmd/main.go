@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	_ "github.com/EvgenyiK/subscription-service/cmd/docs"
 	"github.com/EvgenyiK/subscription-service/internal/handlers"
 	"log"
@@ -11,7 +12,12 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/EvgenyiK/subscription-service/internal/budgets"
 	"github.com/EvgenyiK/subscription-service/internal/config"
+	"github.com/EvgenyiK/subscription-service/internal/events"
+	"github.com/EvgenyiK/subscription-service/internal/jobs"
+	"github.com/EvgenyiK/subscription-service/internal/notify"
+	"github.com/EvgenyiK/subscription-service/internal/rates"
 	"github.com/EvgenyiK/subscription-service/internal/repository"
 	"github.com/EvgenyiK/subscription-service/internal/server"
 	"github.com/joho/godotenv"
@@ -20,6 +26,7 @@ import (
 // @title Subscription Service API
 // @version 1.0
 // @description API для управления подписками.
+// @description Ошибки возвращаются в формате application/problem+json (RFC 7807); ошибки проверки полей перечислены в errors.
 // @host localhost:8080
 
 func main() {
@@ -33,12 +40,49 @@ func main() {
 		log.Fatal(err)
 	}
 
-	repo, err := repository.NewRepository(cfg)
+	// Подкоманда управления схемой: main migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	repo, err := newRepository(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rateProvider, err := rates.NewFileProvider(cfg.RatesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cursorSecret, err := newCursorSecret(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	h := handlers.NewHandler(repo)
+	if cfg.AdminToken == "" {
+		log.Println("ADMIN_TOKEN не задан, административные запросы отключены")
+	}
+	h := handlers.NewHandler(repo, rateProvider, cursorSecret, cfg.AdminToken)
+
+	// Фоновые задачи останавливаются вместе с сервером
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	trialJob := jobs.NewTrialConversionJob(repo, events.LogPublisher{}, cfg.TrialNoticeAhead, cfg.TrialCheckInterval)
+	go trialJob.Run(jobsCtx)
+
+	budgetJob := jobs.NewBudgetAlertJob(repo, budgets.NewEvaluator(repo, rateProvider), events.LogPublisher{}, cfg.BudgetCheckInterval)
+	go budgetJob.Run(jobsCtx)
+
+	reminderJob := jobs.NewRenewalReminderJob(repo, newNotifier(cfg), cfg.ReminderAhead, cfg.ReminderCheckInterval)
+	go reminderJob.Run(jobsCtx)
+
+	purgeJob := jobs.NewPurgeDeletedJob(repo, cfg.DeletedRetention, cfg.PurgeInterval)
+	go purgeJob.Run(jobsCtx)
 
 	router := server.NewRouter(h)
 
@@ -63,6 +107,8 @@ func main() {
 	sig := <-sigs
 	log.Printf("Получен сигнал %s. Начинаем graceful shutdown...", sig)
 
+	stopJobs()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -74,3 +120,42 @@ func main() {
 
 	log.Println("Выход из программы")
 }
+
+// newRepository выбирает хранилище подписок по cfg.Storage
+func newRepository(cfg *config.Config) (repository.Storage, error) {
+	if cfg.Storage == config.StorageMemory {
+		log.Println("Используется хранилище в памяти, данные не сохраняются между запусками")
+		return repository.NewMemoryRepository(), nil
+	}
+
+	if cfg.MigrateOnStart {
+		if err := migrateOnStart(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return repository.NewRepository(cfg)
+}
+
+// newNotifier выбирает способ доставки напоминаний по cfg.ReminderNotifier
+func newNotifier(cfg *config.Config) notify.Notifier {
+	if cfg.ReminderNotifier == config.NotifierFile {
+		log.Printf("Напоминания о списаниях записываются в %s", cfg.ReminderFile)
+		return notify.NewFileNotifier(cfg.ReminderFile)
+	}
+	return notify.LogNotifier{}
+}
+
+// newCursorSecret возвращает ключ подписи курсоров из конфигурации
+// или случайный ключ, если CURSOR_SECRET не задан
+func newCursorSecret(cfg *config.Config) ([]byte, error) {
+	if cfg.CursorSecret != "" {
+		return []byte(cfg.CursorSecret), nil
+	}
+
+	log.Println("CURSOR_SECRET не задан, курсоры будут недействительны после перезапуска")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/EvgenyiK/subscription-service/internal/config"
+	"github.com/EvgenyiK/subscription-service/internal/migrate"
+	"github.com/EvgenyiK/subscription-service/migrations"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const migrateUsage = "usage: main migrate up|down [steps]|status"
+
+// runMigrate выполняет подкоманду "migrate up|down [steps]|status"
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.Connect(ctx, cfg.DSN())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Применено миграций: %d\n", len(applied))
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %q: %s", args[1], migrateUsage)
+			}
+		}
+		reverted, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Откачено миграций: %d\n", len(reverted))
+
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.AppliedAt != nil {
+				state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-45s %s\n", st.Name, state)
+		}
+
+	default:
+		return fmt.Errorf("unknown migrate command %q: %s", args[0], migrateUsage)
+	}
+
+	return nil
+}
+
+// migrateOnStart применяет миграции перед запуском сервера
+func migrateOnStart(cfg *config.Config) error {
+	return runMigrate(cfg, []string{"up"})
+}
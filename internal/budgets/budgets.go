@@ -0,0 +1,82 @@
+package budgets
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/rates"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+)
+
+// Evaluator сравнивает бюджет пользователя с его расходами на подписки за месяц
+type Evaluator struct {
+	repo  repository.SubscriptionRepository
+	rates rates.RateProvider
+}
+
+// NewEvaluator создает Evaluator, который считает расходы по repo и пересчитывает валюты по rateProvider
+func NewEvaluator(repo repository.SubscriptionRepository, rateProvider rates.RateProvider) *Evaluator {
+	return &Evaluator{repo: repo, rates: rateProvider}
+}
+
+// Status считает расходы пользователя за календарный месяц, в который попадает now:
+// итоги GetTotalSubscriptionCost за каждый день месяца, то есть те же суммы, что
+// возвращает GET /subscriptions/view/total, сложенные по дням. Дни после now тоже
+// учитываются, поэтому списания, которые будут позже в этом месяце, уже в расходах.
+// Суммы в других валютах пересчитываются в валюту бюджета по курсу на now.
+func (e *Evaluator) Status(ctx context.Context, b *models.Budget, now time.Time) (*models.BudgetStatus, error) {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	spent := map[string]float64{}
+	for day := monthStart; day.Before(monthEnd); day = day.AddDate(0, 0, 1) {
+		totals, err := e.repo.GetTotalSubscriptionCost(ctx, day, true, b.UserID, "")
+		if err != nil {
+			return nil, err
+		}
+		for currency, amount := range totals {
+			spent[currency] += amount
+		}
+	}
+
+	status := &models.BudgetStatus{
+		UserID:            b.UserID,
+		Month:             monthStart.Format("2006-01"),
+		Currency:          b.Currency,
+		Limit:             b.MonthlyLimit,
+		ReachedThresholds: []int{},
+	}
+
+	var rateDate *time.Time
+	for currency, amount := range spent {
+		if currency == b.Currency {
+			status.Spent += amount
+			continue
+		}
+		rate, publishedAt, err := e.rates.Rate(ctx, currency, b.Currency, now)
+		if err != nil {
+			return nil, err
+		}
+		status.Spent += rates.ConvertMinor(amount, currency, b.Currency, rate)
+		if rateDate == nil || publishedAt.After(*rateDate) {
+			rateDate = &publishedAt
+		}
+	}
+	if rateDate != nil {
+		status.RateDate = rateDate.Format("2006-01-02")
+	}
+
+	status.Remaining = float64(b.MonthlyLimit) - status.Spent
+	used := status.Spent / float64(b.MonthlyLimit) * 100
+	// Проценты округляем до сотых, пороги сравниваем с точным значением
+	status.PercentUsed = math.Round(used*100) / 100
+	for _, threshold := range b.Thresholds {
+		if used >= float64(threshold) {
+			status.ReachedThresholds = append(status.ReachedThresholds, threshold)
+		}
+	}
+
+	return status, nil
+}
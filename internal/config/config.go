@@ -1,10 +1,28 @@
 package config
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
+// Способы доставки напоминаний о списаниях
+const (
+	NotifierLog  = "log"
+	NotifierFile = "file"
+)
+
+// Варианты хранилища подписок
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
 type Config struct {
+	// Storage — postgres (по умолчанию) или memory для демо без базы данных
+	Storage string
+
 	DBHost     string
 	DBPort     string
 	DBUser     string
@@ -12,20 +30,129 @@ type Config struct {
 	DBName     string
 
 	ServerPort string
+
+	// MigrateOnStart — применять миграции схемы при запуске сервера
+	MigrateOnStart bool
+
+	// RatesFile — JSON-файл с курсами валют; если пустой, используются встроенные курсы
+	RatesFile string
+
+	// CursorSecret — ключ подписи курсоров списка подписок.
+	// Если не задан, при запуске создается случайный и курсоры не переживают перезапуск.
+	CursorSecret string
+
+	// TrialNoticeAhead — за сколько до конца пробного периода публиковать событие
+	TrialNoticeAhead time.Duration
+	// TrialCheckInterval — как часто проверять пробные периоды
+	TrialCheckInterval time.Duration
+
+	// BudgetCheckInterval — как часто сравнивать бюджеты пользователей с расходами
+	BudgetCheckInterval time.Duration
+
+	// ReminderNotifier — log (по умолчанию) или file
+	ReminderNotifier string
+	// ReminderFile — файл, в который FileNotifier дописывает напоминания
+	ReminderFile string
+	// ReminderAhead — за сколько до списания напоминать пользователю
+	ReminderAhead time.Duration
+	// ReminderCheckInterval — как часто искать предстоящие списания
+	ReminderCheckInterval time.Duration
+
+	// AdminToken — токен административных запросов (заголовок X-Admin-Token).
+	// Если не задан, административные запросы запрещены.
+	AdminToken string
+
+	// DeletedRetention — сколько хранить удаленные подписки до окончательного удаления
+	DeletedRetention time.Duration
+	// PurgeInterval — как часто очищать удаленные подписки
+	PurgeInterval time.Duration
 }
 
 func LoadConfig() (*Config, error) {
 	viper.SetConfigFile(".env")
 	viper.AutomaticEnv()
 
+	viper.SetDefault("STORAGE", StoragePostgres)
+	viper.SetDefault("TRIAL_NOTICE_AHEAD", "72h")
+	viper.SetDefault("TRIAL_CHECK_INTERVAL", "1h")
+	viper.SetDefault("BUDGET_CHECK_INTERVAL", "1h")
+	viper.SetDefault("REMINDER_NOTIFIER", NotifierLog)
+	viper.SetDefault("REMINDER_FILE", "reminders.jsonl")
+	viper.SetDefault("REMINDER_AHEAD", "72h")
+	viper.SetDefault("REMINDER_CHECK_INTERVAL", "1h")
+	viper.SetDefault("DELETED_RETENTION", "720h")
+	viper.SetDefault("PURGE_INTERVAL", "24h")
+
 	config := &Config{
+		Storage: viper.GetString("STORAGE"),
+
 		DBHost:     viper.GetString("DB_HOST"),
 		DBPort:     viper.GetString("DB_PORT"),
 		DBUser:     viper.GetString("DB_USER"),
 		DBPassword: viper.GetString("DB_PASSWORD"),
 		DBName:     viper.GetString("DB_NAME"),
 		ServerPort: viper.GetString("SERVER_PORT"),
+		RatesFile:  viper.GetString("RATES_FILE"),
+
+		CursorSecret: viper.GetString("CURSOR_SECRET"),
+
+		MigrateOnStart: viper.GetBool("MIGRATE_ON_START"),
+
+		ReminderNotifier: viper.GetString("REMINDER_NOTIFIER"),
+		ReminderFile:     viper.GetString("REMINDER_FILE"),
+
+		AdminToken: viper.GetString("ADMIN_TOKEN"),
+	}
+
+	// Длительности фоновых задач должны быть положительными: на нулевом интервале
+	// задача падает, а нераспознанное значение не должно молча становиться нулем
+	durations := []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"TRIAL_NOTICE_AHEAD", &config.TrialNoticeAhead},
+		{"TRIAL_CHECK_INTERVAL", &config.TrialCheckInterval},
+		{"BUDGET_CHECK_INTERVAL", &config.BudgetCheckInterval},
+		{"REMINDER_AHEAD", &config.ReminderAhead},
+		{"REMINDER_CHECK_INTERVAL", &config.ReminderCheckInterval},
+		{"PURGE_INTERVAL", &config.PurgeInterval},
+		// Нулевой срок хранения окончательно удалил бы все удаленные подписки при первой очистке
+		{"DELETED_RETENTION", &config.DeletedRetention},
+	}
+	for _, d := range durations {
+		value, err := positiveDuration(d.key)
+		if err != nil {
+			return nil, err
+		}
+		*d.value = value
+	}
+
+	if config.Storage != StoragePostgres && config.Storage != StorageMemory {
+		return nil, fmt.Errorf("unknown STORAGE %q, expected %s or %s", config.Storage, StoragePostgres, StorageMemory)
+	}
+	if config.ReminderNotifier != NotifierLog && config.ReminderNotifier != NotifierFile {
+		return nil, fmt.Errorf("unknown REMINDER_NOTIFIER %q, expected %s or %s", config.ReminderNotifier, NotifierLog, NotifierFile)
 	}
 
 	return config, nil
 }
+
+// positiveDuration читает длительность в формате time.ParseDuration ("90m", "72h")
+// и требует, чтобы она была больше нуля
+func positiveDuration(key string) (time.Duration, error) {
+	raw := viper.GetString(key)
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be positive", key, raw)
+	}
+	return value, nil
+}
+
+// DSN возвращает строку подключения к Postgres
+func (c *Config) DSN() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
+		c.DBUser, c.DBPassword, c.DBHost, c.DBPort, c.DBName)
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	// Пустые переменные окружения не перекрывают значения по умолчанию
+	for _, key := range []string{"DELETED_RETENTION", "PURGE_INTERVAL", "TRIAL_CHECK_INTERVAL"} {
+		t.Setenv(key, "")
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.DeletedRetention != 720*time.Hour || cfg.PurgeInterval != 24*time.Hour || cfg.TrialCheckInterval != time.Hour {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadConfigRejectsInvalidDurations(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"DELETED_RETENTION", "30d"},
+		{"DELETED_RETENTION", "0"},
+		{"DELETED_RETENTION", "-1h"},
+		{"PURGE_INTERVAL", "daily"},
+		{"TRIAL_CHECK_INTERVAL", "0s"},
+		{"REMINDER_AHEAD", "-72h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+
+			cfg, err := LoadConfig()
+			if err == nil {
+				t.Fatalf("LoadConfig accepted %s=%s: %+v", tt.key, tt.value, cfg)
+			}
+			if !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("error %q does not name %s", err, tt.key)
+			}
+		})
+	}
+}
+
+func TestLoadConfigParsesDurations(t *testing.T) {
+	t.Setenv("DELETED_RETENTION", "168h")
+	t.Setenv("PURGE_INTERVAL", "90m")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.DeletedRetention != 168*time.Hour || cfg.PurgeInterval != 90*time.Minute {
+		t.Errorf("DeletedRetention/PurgeInterval = %s/%s, want 168h/90m", cfg.DeletedRetention, cfg.PurgeInterval)
+	}
+}
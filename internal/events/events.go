@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы событий
+const (
+	TypeTrialConverting        = "subscription.trial_converting"
+	TypeBudgetThresholdReached = "budget.threshold_reached"
+)
+
+// Event — событие, которое сервис сообщает внешнему миру
+type Event struct {
+	Type           string                 `json:"type"`
+	OccurredAt     time.Time              `json:"occurred_at"`
+	SubscriptionID uuid.UUID              `json:"subscription_id,omitempty"`
+	UserID         uuid.UUID              `json:"user_id,omitempty"`
+	Data           map[string]interface{} `json:"data,omitempty"`
+}
+
+// Publisher доставляет события получателям
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// LogPublisher пишет события в лог в формате JSON
+type LogPublisher struct{}
+
+func (LogPublisher) Publish(_ context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	log.Printf("event: %s", payload)
+	return nil
+}
+
+var _ Publisher = LogPublisher{}
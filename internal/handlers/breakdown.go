@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/rates"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// GetCostBreakdown godoc
+// @Summary Разбивка стоимости подписок по группам
+// @Description Возвращает стоимость подписок за день date по сервисам, категориям каталога или пользователям
+// @Description и долю каждой группы в процентах. Стоимость считается так же, как в /subscriptions/view/total/{date}.
+// @Description Подписки вне каталога и сервисы без категории попадают в категорию uncategorized.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param date query string true "Дата в формате YYYY-MM-DD"
+// @Param group_by query string true "Группировка" Enums(service, category, user)
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса"
+// @Param currency query string false "Валюта результата (ISO 4217), по умолчанию RUB"
+// @Success 200 {object} models.CostBreakdown
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/view/breakdown [get]
+func (h *Handler) GetCostBreakdown(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var v validator
+	day := v.date("date", dateFormatDay, query.Get("date"))
+	if day == nil {
+		v.add("date", codeRequired, "date is required")
+	}
+
+	groupBy := repository.BreakdownGroupBy(query.Get("group_by"))
+	if groupBy == "" {
+		v.add("group_by", codeRequired, "group_by is required")
+	} else if !groupBy.Valid() {
+		v.add("group_by", codeInvalidValue, "Invalid group_by, expected service, category or user")
+	}
+
+	var filter repository.CostFilter
+	if userUUID := v.id("user_id", query.Get("user_id")); userUUID != uuid.Nil {
+		filter.UserID = &userUUID
+	}
+	filter.ServiceName = query.Get("service_name")
+
+	currency := query.Get("currency")
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	currency = h.currency(r.Context(), &v, "currency", currency)
+
+	if !v.valid() {
+		v.respond(w, r)
+		return
+	}
+	date := *day
+
+	groups, err := h.repo.GetCostBreakdown(r.Context(), date, groupBy, filter)
+	if err != nil {
+		log.Println("Failed to calculate cost breakdown:", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error calculating cost breakdown")
+		return
+	}
+
+	breakdown := models.CostBreakdown{
+		Date:     date.Format(dateFormatDay),
+		GroupBy:  string(groupBy),
+		Currency: currency,
+		Groups:   []models.CostBreakdownGroup{},
+	}
+
+	// Группа может прийти в нескольких валютах: сводим их в одну
+	index := map[string]int{}
+	var rateDate *time.Time
+	for _, g := range groups {
+		cost, publishedAt, err := h.convert(r.Context(), g.Total, g.Currency, currency, date)
+		if err != nil {
+			if errors.Is(err, rates.ErrUnknownCurrency) {
+				respondWithError(w, r, http.StatusBadRequest, "Unsupported currency")
+			} else {
+				respondWithError(w, r, http.StatusInternalServerError, "Error converting currency")
+			}
+			return
+		}
+		rateDate = laterDate(rateDate, publishedAt)
+
+		i, ok := index[g.Key]
+		if !ok {
+			i = len(breakdown.Groups)
+			index[g.Key] = i
+			breakdown.Groups = append(breakdown.Groups, models.CostBreakdownGroup{Key: g.Key})
+		}
+		breakdown.Groups[i].Total += cost
+		breakdown.Total += cost
+	}
+	if rateDate != nil {
+		breakdown.RateDate = rateDate.Format(dateFormatDay)
+	}
+
+	for i := range breakdown.Groups {
+		if breakdown.Total > 0 {
+			// Проценты округляем до сотых
+			breakdown.Groups[i].Percentage = math.Round(breakdown.Groups[i].Total/breakdown.Total*10000) / 100
+		}
+	}
+	sort.SliceStable(breakdown.Groups, func(i, j int) bool {
+		return breakdown.Groups[i].Total > breakdown.Groups[j].Total
+	})
+
+	respondWithJSON(w, http.StatusOK, breakdown)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/gorilla/mux"
+)
+
+// maxBudgetThreshold ограничивает порог бюджета в процентах
+const maxBudgetThreshold = 1000
+
+// SetBudget godoc
+// @Summary Установить бюджет пользователя
+// @Description Создает или заменяет месячный лимит расходов пользователя на подписки.
+// @Description При достижении каждого порога (в процентах лимита) пользователь получает уведомление, не чаще раза в месяц.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param budget body models.BudgetInput true "Данные бюджета"
+// @Success 200 {object} models.Budget
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/budget [put]
+func (h *Handler) SetBudget(w http.ResponseWriter, r *http.Request) {
+	var input models.BudgetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	var v validator
+	userUUID := v.id("user_id", mux.Vars(r)["user_id"])
+
+	if input.MonthlyLimit <= 0 {
+		v.add("monthly_limit", codeOutOfRange, "monthly_limit must be positive")
+	}
+
+	currency := input.Currency
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	currency = h.currency(r.Context(), &v, "currency", currency)
+
+	thresholds, ok := normalizeThresholds(input.Thresholds)
+	if !ok {
+		v.add("thresholds", codeOutOfRange, "Invalid thresholds, expected percentages from 1 to 1000")
+	}
+
+	if !v.valid() {
+		v.respond(w, r)
+		return
+	}
+
+	budget := models.Budget{
+		UserID:       userUUID,
+		MonthlyLimit: input.MonthlyLimit,
+		Currency:     currency,
+		Thresholds:   thresholds,
+		UpdatedAt:    time.Now().UTC(),
+	}
+	if err := h.repo.SetBudget(r.Context(), &budget); err != nil {
+		log.Println("Failed to set budget:", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to set budget")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, budget)
+}
+
+// GetBudget godoc
+// @Summary Вернуть бюджет пользователя
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Success 200 {object} models.Budget
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/budget [get]
+func (h *Handler) GetBudget(w http.ResponseWriter, r *http.Request) {
+	budget, ok := h.getBudget(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, budget)
+}
+
+// DeleteBudget godoc
+// @Summary Удалить бюджет пользователя
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/budget [delete]
+func (h *Handler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	var v validator
+	userUUID := v.id("user_id", mux.Vars(r)["user_id"])
+	if !v.valid() {
+		v.respond(w, r)
+		return
+	}
+
+	if err := h.repo.DeleteBudget(r.Context(), userUUID); err != nil {
+		if errors.Is(err, repository.ErrBudgetNotFound) {
+			respondWithError(w, r, http.StatusNotFound, "Budget not found")
+		} else {
+			log.Println("Failed to delete budget:", err)
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to delete budget")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetBudgetStatus godoc
+// @Summary Расходы пользователя в сравнении с бюджетом
+// @Description Сравнивает лимит со стоимостью подписок пользователя за текущий месяц.
+// @Description Расходы — итоги GET /subscriptions/view/total за каждый день месяца, включая еще не наступившие дни.
+// @Description Суммы в других валютах пересчитываются в валюту бюджета по текущему курсу.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Success 200 {object} models.BudgetStatus
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/budget/status [get]
+func (h *Handler) GetBudgetStatus(w http.ResponseWriter, r *http.Request) {
+	budget, ok := h.getBudget(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.evaluator.Status(r.Context(), budget, time.Now().UTC())
+	if err != nil {
+		log.Println("Failed to evaluate budget:", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error calculating budget status")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, status)
+}
+
+// getBudget загружает бюджет пользователя из пути и сам отвечает клиенту, если его нет
+func (h *Handler) getBudget(w http.ResponseWriter, r *http.Request) (*models.Budget, bool) {
+	var v validator
+	userUUID := v.id("user_id", mux.Vars(r)["user_id"])
+	if !v.valid() {
+		v.respond(w, r)
+		return nil, false
+	}
+
+	budget, err := h.repo.GetBudget(r.Context(), userUUID)
+	if err != nil {
+		if errors.Is(err, repository.ErrBudgetNotFound) {
+			respondWithError(w, r, http.StatusNotFound, "Budget not found")
+		} else {
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch budget")
+		}
+		return nil, false
+	}
+	return budget, true
+}
+
+// normalizeThresholds сортирует пороги и убирает повторы; пустой список заменяется порогами по умолчанию
+func normalizeThresholds(thresholds []int) ([]int, bool) {
+	if len(thresholds) == 0 {
+		return append([]int{}, models.DefaultBudgetThresholds...), true
+	}
+
+	seen := make(map[int]bool)
+	result := make([]int, 0, len(thresholds))
+	for _, t := range thresholds {
+		if t <= 0 || t > maxBudgetThreshold {
+			return nil, false
+		}
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	sort.Ints(result)
+	return result, true
+}
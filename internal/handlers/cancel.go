@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CancelSubscription godoc
+// @Summary Отменить подписку
+// @Description Завершает подписку сразу или в конце текущего оплаченного периода и сохраняет причину отмены.
+// @Description Уже завершившуюся подписку отменить нельзя; если подписка изменилась во время отмены, возвращается 409.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки (UUID)"
+// @Param cancellation body models.CancelSubscriptionInput true "Параметры отмены"
+// @Success 200 {object} models.CancelSubscriptionResult
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/cancel [post]
+func (h *Handler) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	subUUID, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
+		return
+	}
+
+	var input models.CancelSubscriptionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if input.Effective == "" {
+		input.Effective = models.CancelImmediate
+	}
+	if input.Effective != models.CancelImmediate && input.Effective != models.CancelEndOfPeriod {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid effective, expected immediate or end_of_period")
+		return
+	}
+	if !input.Reason.Valid() {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid reason")
+		return
+	}
+
+	subscription, ok := h.getSubscription(w, r, subUUID)
+	if !ok {
+		return
+	}
+	if subscription.Status == models.StatusEnded {
+		respondWithError(w, r, http.StatusConflict, "Subscription has already ended")
+		return
+	}
+
+	now := time.Now().UTC()
+	effectiveDate := cancellationDate(subscription, input.Effective, now)
+
+	cancellation := models.Cancellation{
+		ID:             uuid.New(),
+		SubscriptionID: subscription.ID,
+		EffectiveDate:  effectiveDate,
+		Reason:         input.Reason,
+		Comment:        input.Comment,
+		CancelledAt:    now,
+	}
+
+	// Cancel проверяет, что подписка не изменилась после чтения, и увеличивает
+	// её версию вместе с end_date
+	cancelled := *subscription
+	cancelled.EndDate = &effectiveDate
+	cancelled.Version++
+	cancelled.RefreshDerived()
+
+	if err := h.repo.Cancel(r.Context(), &cancellation, subscription.Version, change(r, subscription, &cancelled)); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			respondWithError(w, r, http.StatusNotFound, "Subscription not found")
+		case errors.Is(err, repository.ErrAlreadyEnded):
+			respondWithError(w, r, http.StatusConflict, "Subscription has already ended")
+		case errors.Is(err, repository.ErrVersionConflict):
+			respondWithError(w, r, http.StatusConflict, "Subscription was changed during cancellation")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to cancel subscription")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.CancelSubscriptionResult{
+		Subscription: cancelled,
+		Cancellation: cancellation,
+	})
+}
+
+// cancellationDate вычисляет новый end_date подписки.
+// Отмена не продлевает подписку: если end_date уже назначен раньше, он сохраняется.
+func cancellationDate(sub *models.Subscription, effective models.CancelEffective, now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	date := day
+	if effective == models.CancelEndOfPeriod {
+		date = sub.CurrentPeriodEnd(day)
+	}
+
+	// Еще не начавшаяся подписка завершается в день начала
+	if date.Before(sub.StartDate) {
+		date = sub.StartDate
+	}
+	if sub.EndDate != nil && sub.EndDate.Before(date) {
+		date = *sub.EndDate
+	}
+	return date
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursorPayload — позиция в списке подписок, которую клиент получает в next_cursor
+type cursorPayload struct {
+	StartDate string    `json:"s"` // формат "2006-01-02"
+	ID        uuid.UUID `json:"id"`
+}
+
+// encodeCursor возвращает непрозрачный курсор, указывающий на подписку sub.
+// Курсор состоит из данных и их HMAC-подписи, каждая часть в base64url.
+func (h *Handler) encodeCursor(sub *models.Subscription) string {
+	data, _ := json.Marshal(cursorPayload{
+		StartDate: sub.StartDate.Format(dateFormatDay),
+		ID:        sub.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data) + "." +
+		base64.RawURLEncoding.EncodeToString(h.signCursor(data))
+}
+
+// decodeCursor проверяет подпись курсора и возвращает позицию в списке
+func (h *Handler) decodeCursor(cursor string) (*repository.ListCursor, error) {
+	dataPart, sigPart, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(dataPart)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, h.signCursor(data)) {
+		return nil, errInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, errInvalidCursor
+	}
+	startDate, err := time.Parse(dateFormatDay, payload.StartDate)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	return &repository.ListCursor{StartDate: startDate, ID: payload.ID}, nil
+}
+
+func (h *Handler) signCursor(data []byte) []byte {
+	mac := hmac.New(sha256.New, h.cursorSecret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
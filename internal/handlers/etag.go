@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// subscriptionETag возвращает сильный ETag подписки — её версию в кавычках
+func subscriptionETag(sub *models.Subscription) string {
+	return strconv.Quote(strconv.Itoa(sub.Version))
+}
+
+// respondWithSubscription отвечает подпиской вместе с её ETag
+func respondWithSubscription(w http.ResponseWriter, status int, sub *models.Subscription) {
+	w.Header().Set("ETag", subscriptionETag(sub))
+	respondWithJSON(w, status, sub)
+}
+
+// checkIfMatch проверяет заголовок If-Match изменяющего запроса по текущей версии подписки.
+// Без заголовка отвечает 428, при устаревшей версии — 412 с актуальной подпиской.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, current *models.Subscription) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		respondWithError(w, r, http.StatusPreconditionRequired, "If-Match header is required")
+		return false
+	}
+
+	etag := subscriptionETag(current)
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		// If-Match использует строгое сравнение, слабые ETag не совпадают никогда
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	respondWithSubscription(w, http.StatusPreconditionFailed, current)
+	return false
+}
+
+// respondStale отвечает 412, когда подписку изменили между проверкой If-Match и записью
+func (h *Handler) respondStale(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	current, ok := h.getSubscription(w, r, id)
+	if !ok {
+		return
+	}
+	respondWithSubscription(w, http.StatusPreconditionFailed, current)
+}
@@ -2,14 +2,15 @@ package handlers
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
+	"github.com/EvgenyiK/subscription-service/internal/budgets"
 	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/rates"
 	"github.com/EvgenyiK/subscription-service/internal/repository"
 	"github.com/gorilla/mux"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,11 +22,39 @@ const (
 )
 
 type Handler struct {
-	repo repository.SubscriptionRepository
+	repo  repository.Storage
+	rates rates.RateProvider
+
+	// cursorSecret — ключ подписи курсоров постраничной выборки
+	cursorSecret []byte
+
+	// adminToken — значение X-Admin-Token для административных запросов; пустое отключает их
+	adminToken string
+
+	evaluator *budgets.Evaluator
+}
+
+func NewHandler(repo repository.Storage, rateProvider rates.RateProvider, cursorSecret []byte, adminToken string) *Handler {
+	return &Handler{
+		repo:         repo,
+		rates:        rateProvider,
+		cursorSecret: cursorSecret,
+		adminToken:   adminToken,
+		evaluator:    budgets.NewEvaluator(repo, rateProvider),
+	}
 }
 
-func NewHandler(repo repository.SubscriptionRepository) *Handler {
-	return &Handler{repo: repo}
+// createSubscriptionInput — тело запроса на создание подписки
+type createSubscriptionInput struct {
+	ServiceName   string               `json:"service_name"`
+	ServiceID     string               `json:"service_id"`     // если не указан, сервис ищется в каталоге по service_name
+	Price         *int                 `json:"price"`          // в минорных единицах валюты; по умолчанию цена из каталога в его валюте и за его период
+	Currency      string               `json:"currency"`       // по умолчанию из каталога или RUB
+	BillingPeriod models.BillingPeriod `json:"billing_period"` // по умолчанию из каталога или monthly
+	UserID        string               `json:"user_id"`
+	StartDate     string               `json:"start_date"` // формат "07-2025"
+	EndDate       *string              `json:"end_date,omitempty"`
+	TrialEnd      *string              `json:"trial_end,omitempty"` // формат "2025-07-15"
 }
 
 // CreateSubscription godoc
@@ -36,174 +65,327 @@ func NewHandler(repo repository.SubscriptionRepository) *Handler {
 // @Produce json
 // @Param subscription body models.CreateSubscriptionInput true "Данные подписки"
 // @Success 201 {object} models.Subscription
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions [post]
 func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		ServiceName string  `json:"service_name"`
-		Price       int     `json:"price"`
-		UserID      string  `json:"user_id"`
-		StartDate   string  `json:"start_date"` // формат "07-2025"
-		EndDate     *string `json:"end_date,omitempty"`
-	}
-
+	var input createSubscriptionInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
-	if input.ServiceName == "" || input.UserID == "" || input.StartDate == "" || input.Price <= 0 {
-		respondWithError(w, http.StatusBadRequest, "Missing required fields")
-		return
-	}
+	h.createSubscription(w, r, input)
+}
 
-	userUUID, err := parseUUID(input.UserID)
+// CreateUserSubscription godoc
+// @Summary Создать подписку пользователя
+// @Description Создает новую подписку для пользователя из пути. Поле user_id в теле можно не передавать.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param subscription body models.CreateSubscriptionInput true "Данные подписки"
+// @Success 201 {object} models.Subscription
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/subscriptions [post]
+func (h *Handler) CreateUserSubscription(w http.ResponseWriter, r *http.Request) {
+	userUUID, err := parseUUID(mux.Vars(r)["user_id"])
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid user_id format")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid user_id format")
 		return
 	}
 
-	startTime, err := parseDate(dateFormatStart, input.StartDate)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid start_date format")
+	var input createSubscriptionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
-	var endTime *time.Time
-	if input.EndDate != nil && *input.EndDate != "" {
-		endTimeParsed, err := parseDate(dateFormatStart, *input.EndDate)
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid end_date format")
+	// user_id из тела должен совпадать с пользователем из пути
+	if input.UserID != "" {
+		bodyUUID, err := parseUUID(input.UserID)
+		if err != nil || bodyUUID != userUUID {
+			respondWithError(w, r, http.StatusBadRequest, "user_id in body does not match path")
 			return
 		}
-		endTime = endTimeParsed
-	} else {
-		newEndTime := startTime.Add(30 * 24 * time.Hour)
-		endTime = &newEndTime
 	}
+	input.UserID = userUUID.String()
 
-	sub := models.Subscription{
-		ID:          uuid.New(),
-		ServiceName: input.ServiceName,
-		Price:       input.Price,
-		UserID:      userUUID,
-		StartDate:   *startTime,
-		EndDate:     endTime,
+	h.createSubscription(w, r, input)
+}
+
+// createSubscription валидирует входные данные и сохраняет новую подписку
+func (h *Handler) createSubscription(w http.ResponseWriter, r *http.Request, input createSubscriptionInput) {
+	sub, ok := h.subscriptionFromInput(w, r, input)
+	if !ok {
+		return
 	}
+	sub.ID = uuid.New()
+	sub.RefreshDerived()
 
-	if err := h.repo.Create(context.Background(), &sub); err != nil {
+	if err := h.repo.Create(r.Context(), sub, change(r, nil, sub)); err != nil {
 		log.Println("Failed to create subscription:", err)
-		respondWithError(w, http.StatusInternalServerError, "Failed to create subscription")
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to create subscription")
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(sub)
+	respondWithSubscription(w, http.StatusCreated, sub)
+}
+
+// subscriptionFromInput проверяет входные данные и собирает по ним подписку без ID.
+// При ошибке отвечает клиенту сам и возвращает false.
+func (h *Handler) subscriptionFromInput(w http.ResponseWriter, r *http.Request, input createSubscriptionInput) (*models.Subscription, bool) {
+	var v validator
+
+	if input.ServiceName == "" && input.ServiceID == "" {
+		v.add("service_name", codeRequired, "service_name or service_id is required")
+	}
+	if input.UserID == "" {
+		v.add("user_id", codeRequired, "user_id is required")
+	}
+	if input.StartDate == "" {
+		v.add("start_date", codeRequired, "start_date is required")
+	}
+
+	var serviceUUID *uuid.UUID
+	if id := v.id("service_id", input.ServiceID); id != uuid.Nil {
+		serviceUUID = &id
+	}
+
+	// Сервис из каталога задает каноническое название и значения по умолчанию
+	var svc *models.Service
+	if !v.has("service_id") {
+		var err error
+		svc, err = h.lookupService(r.Context(), serviceUUID, input.ServiceName)
+		if errors.Is(err, repository.ErrServiceNotFound) {
+			v.add("service_id", codeUnknown, "Unknown service_id")
+		} else if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to resolve service")
+			return nil, false
+		}
+	}
+	if svc != nil {
+		input.ServiceName = svc.Name
+		// Цена из каталога указана в валюте и за период каталога,
+		// поэтому другие значения вместе с ней не принимаются
+		if input.Price == nil && svc.DefaultPrice != nil {
+			input.Price = svc.DefaultPrice
+			if input.Currency != "" && !strings.EqualFold(input.Currency, svc.Currency) {
+				v.add("currency", codeInvalidValue, "currency must match the catalog currency "+svc.Currency+" when price is omitted")
+			}
+			if input.BillingPeriod != "" && input.BillingPeriod != svc.BillingPeriod {
+				v.add("billing_period", codeInvalidValue, "billing_period must match the catalog billing_period "+string(svc.BillingPeriod)+" when price is omitted")
+			}
+			input.Currency = svc.Currency
+			input.BillingPeriod = svc.BillingPeriod
+		}
+		if input.Currency == "" {
+			input.Currency = svc.Currency
+		}
+		if input.BillingPeriod == "" {
+			input.BillingPeriod = svc.BillingPeriod
+		}
+	}
+
+	if input.Price == nil {
+		v.add("price", codeRequired, "price is required")
+		input.Price = new(int)
+	}
+	if input.Currency == "" {
+		input.Currency = models.DefaultCurrency
+	}
+	if input.BillingPeriod == "" {
+		input.BillingPeriod = models.BillingMonthly
+	}
+
+	sub := models.Subscription{
+		ServiceName:   input.ServiceName,
+		Price:         *input.Price,
+		Currency:      h.currency(r.Context(), &v, "currency", input.Currency),
+		BillingPeriod: input.BillingPeriod,
+		UserID:        v.id("user_id", input.UserID),
+	}
+	v.billingPeriod("billing_period", sub.BillingPeriod)
+	if svc != nil {
+		sub.ServiceID = &svc.ID
+	}
+
+	if start := v.date("start_date", dateFormatStart, input.StartDate); start != nil {
+		sub.StartDate = *start
+	}
+	// Без end_date подписка действует до отмены, дни до trial_end бесплатны
+	if input.EndDate != nil {
+		sub.EndDate = v.date("end_date", dateFormatStart, *input.EndDate)
+	}
+	if input.TrialEnd != nil {
+		sub.TrialEnd = v.date("trial_end", dateFormatDay, *input.TrialEnd)
+	}
+
+	validateSubscription(&v, &sub)
+	if !v.valid() {
+		v.respond(w, r)
+		return nil, false
+	}
+	return &sub, true
 }
 
 // GetSubscription godoc
 // @Summary Вернуть подписку по ID
-// @Description Возвращает подписку по id пользователя.
+// @Description Возвращает подписку по её id.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param id path string true "ID пользователя (UUID)"
-// @Success 201 {object} models.Subscription
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Param id path string true "ID подписки (UUID)"
+// @Success 200 {object} models.Subscription
+// @Header 200 {string} ETag "Версия подписки для If-Match"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions/{id} [get]
 func (h *Handler) GetSubscription(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
-	userUUID, err := parseUUID(idStr)
+	subUUID, err := parseUUID(idStr)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid user_id format")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
 		return
 	}
 
-	subscription, err := h.repo.GetByID(r.Context(), userUUID)
-	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Subscription not found")
+	subscription, ok := h.getSubscription(w, r, subUUID)
+	if !ok {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(subscription)
+	respondWithSubscription(w, http.StatusOK, subscription)
 }
 
 // UpdateSubscription godoc
 // @Summary Обновить подписку по ID
 // @Description Обновляет информацию о подписке по заданному ID.
+// @Description Заголовок If-Match должен содержать ETag текущей версии подписки (или *).
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param id path string true "ID пользователя (UUID)"
+// @Param id path string true "ID подписки (UUID)"
+// @Param If-Match header string true "ETag подписки из GET /subscriptions/{id}"
 // @Param subscription body models.UpdateSubscriptionInput true "Данные для обновления подписки"
 // @Success 200 {object} models.Subscription
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Новая версия подписки"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 412 {object} models.Subscription "Подписку изменили, в ответе актуальная версия"
+// @Failure 428 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions/{id} [put]
 func (h *Handler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
-	userUUID, err := parseUUID(idStr)
+	subUUID, err := parseUUID(idStr)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid subscription ID format")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
 		return
 	}
 
 	// Получаем существующую подписку
-	subscription, err := h.repo.GetByID(r.Context(), userUUID)
-	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Subscription not found")
+	subscription, ok := h.getSubscription(w, r, subUUID)
+	if !ok {
 		return
 	}
+	if !checkIfMatch(w, r, subscription) {
+		return
+	}
+	before := *subscription
 
 	// Парсим тело запроса для новых данных
 	var updateData struct {
-		ServiceName string     `json:"service_name"`
-		Price       int        `json:"price"`
-		UserID      uuid.UUID  `json:"user_id"`
-		StartDate   time.Time  `json:"start_date"`
-		EndDate     *time.Time `json:"end_date"` // nullable
+		ServiceName   string               `json:"service_name"`
+		Price         int                  `json:"price"`
+		Currency      string               `json:"currency"`
+		BillingPeriod models.BillingPeriod `json:"billing_period"`
+		UserID        uuid.UUID            `json:"user_id"`
+		StartDate     time.Time            `json:"start_date"`
+		EndDate       *time.Time           `json:"end_date"`  // nullable
+		TrialEnd      *time.Time           `json:"trial_end"` // nullable
 	}
 	if err := json.NewDecoder(r.Body).Decode(&updateData); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
+	var v validator
+
+	// Валюту и периодичность меняем только если они переданы явно
+	if updateData.Currency != "" {
+		subscription.Currency = h.currency(r.Context(), &v, "currency", updateData.Currency)
+	}
+	if updateData.BillingPeriod != "" {
+		v.billingPeriod("billing_period", updateData.BillingPeriod)
+		subscription.BillingPeriod = updateData.BillingPeriod
+	}
+
 	// Обновляем поля подписки
 	subscription.ServiceName = updateData.ServiceName
 	subscription.Price = updateData.Price
 	subscription.UserID = updateData.UserID
 	subscription.StartDate = updateData.StartDate
 	subscription.EndDate = updateData.EndDate
+	subscription.TrialEnd = updateData.TrialEnd
+
+	validateSubscription(&v, subscription)
+	if !v.valid() {
+		v.respond(w, r)
+		return
+	}
+
+	// Связь с каталогом следует за service_name
+	svc, err := h.lookupService(r.Context(), nil, subscription.ServiceName)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to resolve service")
+		return
+	}
+	subscription.ServiceID = nil
+	if svc != nil {
+		subscription.ServiceName = svc.Name
+		subscription.ServiceID = &svc.ID
+	}
 
 	// Обновляем в базе данных
-	if err := h.repo.Update(r.Context(), subscription); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to update subscription")
+	subscription.RefreshDerived()
+	if err := h.repo.Update(r.Context(), subscription, change(r, &before, subscription)); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			respondWithError(w, r, http.StatusNotFound, "Subscription not found")
+		case errors.Is(err, repository.ErrVersionConflict):
+			h.respondStale(w, r, subUUID)
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to update subscription")
+		}
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(subscription)
+	respondWithSubscription(w, http.StatusOK, subscription)
 }
 
 // DeleteSubscription godoc
 // @Summary Удаляет подписку по ID
-// @Description Удаляет подписку по ID пользователя
+// @Description Помечает подписку удаленной: она пропадает из выборок и расчетов, но её можно восстановить
+// @Description через POST /subscriptions/{id}/restore, пока она не очищена по сроку хранения.
+// @Description Заголовок If-Match должен содержать ETag текущей версии подписки (или *).
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param id path string true "ID пользователя (UUID)"
+// @Param id path string true "ID подписки (UUID)"
+// @Param If-Match header string true "ETag подписки из GET /subscriptions/{id}"
 // @Success 204 {string} string "No Content"
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 412 {object} models.Subscription "Подписку изменили, в ответе актуальная версия"
+// @Failure 428 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions/{id} [delete]
 func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	// Получение переменной из URL
@@ -211,20 +393,32 @@ func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["id"]
 
 	// Парсинг UUID
-	userUUID, err := parseUUID(idStr)
+	subUUID, err := parseUUID(idStr)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid subscription ID format")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
+		return
+	}
+
+	// Удалять можно только версию, которую видел клиент
+	subscription, ok := h.getSubscription(w, r, subUUID)
+	if !ok {
+		return
+	}
+	if !checkIfMatch(w, r, subscription) {
 		return
 	}
 
 	// Вызов метода удаления
-	err = h.repo.Delete(r.Context(), userUUID)
+	err = h.repo.Delete(r.Context(), subUUID, subscription.Version, change(r, subscription, nil))
 	if err != nil {
-		// Можно уточнить ошибку: если не найден — 404, иначе 500
-		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, http.StatusNotFound, "Subscription not found")
-		} else {
-			respondWithError(w, http.StatusInternalServerError, "Failed to delete subscription")
+		// Если не найден — 404, устарела версия — 412, иначе 500
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			respondWithError(w, r, http.StatusNotFound, "Subscription not found")
+		case errors.Is(err, repository.ErrVersionConflict):
+			h.respondStale(w, r, subUUID)
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to delete subscription")
 		}
 		return
 	}
@@ -235,99 +429,330 @@ func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 
 // ListSubscriptions godoc
 // @Summary Получить список всех подписок
-// @Description Возвращает список всех подписок без фильтров
+// @Description Возвращает страницу подписок и их общее число. in_trial=true оставляет только подписки в пробном периоде.
+// @Description Страницу можно выбрать номером page или курсором cursor из next_cursor предыдущего ответа.
+// @Description next_cursor возвращается только при сортировке по умолчанию.
+// @Description Выборка по курсору не пропускает и не повторяет подписки, созданные во время листания.
+// @Description Ссылки на соседние страницы передаются в заголовке Link (RFC 8288).
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Subscription
-// @Failure 500 {object} map[string]string
-// @Router /subscriptions/view/list?page&limit [get]
+// @Param page query int false "Номер страницы (с 1)"
+// @Param cursor query string false "Курсор из next_cursor, нельзя передавать вместе с page"
+// @Param limit query int false "Размер страницы, не больше 100"
+// @Param in_trial query bool false "Фильтр по пробному периоду"
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса, точное совпадение"
+// @Param service_name_prefix query string false "Начало названия сервиса"
+// @Param price_min query int false "Минимальная начальная цена в минорных единицах"
+// @Param price_max query int false "Максимальная начальная цена в минорных единицах"
+// @Param active_on query string false "Только подписки, действующие в этот день (YYYY-MM-DD)"
+// @Param start_from query string false "start_date не раньше (YYYY-MM-DD)"
+// @Param start_to query string false "start_date не позже (YYYY-MM-DD)"
+// @Param sort query string false "Сортировка field:asc|desc через запятую: service_name, price, currency, billing_period, user_id, start_date, end_date, trial_end"
+// @Param include_deleted query bool false "Добавить удаленные подписки; только с X-Admin-Token"
+// @Param X-Admin-Token header string false "Токен администратора"
+// @Success 200 {object} models.SubscriptionPage
+// @Header 200 {string} Link "Ссылки first, prev, next, last"
+// @Failure 400 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/view/list [get]
 func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
+	page, limit := parsePagination(r)
+
+	var v validator
+	var after *repository.ListCursor
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		if r.URL.Query().Get("page") != "" {
+			v.add("cursor", codeInvalidValue, "page and cursor cannot be used together")
+		} else if cursor, err := h.decodeCursor(cursorStr); err != nil {
+			v.add("cursor", codeInvalidFormat, "Invalid cursor")
+		} else {
+			after = cursor
+		}
+	}
+
+	filter := parseListFilter(&v, r.URL.Query())
+	// Курсор задает позицию в порядке (start_date, id) и несовместим с другой сортировкой
+	if after != nil && len(filter.Sort) > 0 {
+		v.add("sort", codeInvalidValue, "sort cannot be used with cursor")
+	}
+	if !v.valid() {
+		v.respond(w, r)
+		return
+	}
+	// Удаленные подписки видит только администратор
+	if filter.IncludeDeleted && !h.isAdmin(r) {
+		respondWithError(w, r, http.StatusForbidden, "include_deleted requires a valid X-Admin-Token")
+		return
+	}
 
-	pageStr := vars["page"]
-	limitStr := vars["limit"]
+	total, err := h.repo.CountSubscriptions(r.Context(), filter)
+	if err != nil {
+		log.Println("Failed to count subscriptions:", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error fetching subscriptions")
+		return
+	}
 
-	page := 1
-	limit := 10
+	var subscriptions []models.Subscription
+	var hasMore bool
+	if after != nil {
+		// Лишняя подписка показывает, есть ли следующая страница
+		subscriptions, err = h.repo.ListSubscriptionsAfter(r.Context(), after, limit+1, filter)
+		if len(subscriptions) > limit {
+			subscriptions = subscriptions[:limit]
+			hasMore = true
+		}
+	} else {
+		offset := (page - 1) * limit
+		subscriptions, err = h.repo.GetAllSubscriptions(r.Context(), limit, offset, filter)
+		hasMore = offset+len(subscriptions) < total
+	}
+	if err != nil {
+		log.Println("Failed to list subscriptions:", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error fetching subscriptions")
+		return
+	}
 
-	if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-		page = p
+	for i := range subscriptions {
+		subscriptions[i].RefreshDerived()
 	}
 
-	if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-		limit = l
+	resp := models.SubscriptionPage{
+		Items: subscriptions,
+		Total: total,
+		Limit: limit,
+	}
+	if hasMore && len(subscriptions) > 0 && len(filter.Sort) == 0 {
+		resp.NextCursor = h.encodeCursor(&subscriptions[len(subscriptions)-1])
 	}
 
+	if after != nil {
+		setCursorLinks(w, r, limit, resp.NextCursor)
+	} else {
+		resp.Page = page
+		setPaginationLinks(w, r, page, limit, total)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// ListUserSubscriptions godoc
+// @Summary Получить подписки пользователя
+// @Description Возвращает подписки указанного пользователя с постраничной выборкой
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param page query int false "Номер страницы (с 1)"
+// @Param limit query int false "Размер страницы, не больше 100"
+// @Success 200 {array} models.Subscription
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/subscriptions [get]
+func (h *Handler) ListUserSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userUUID, err := parseUUID(mux.Vars(r)["user_id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid user_id format")
+		return
+	}
+
+	page, limit := parsePagination(r)
 	offset := (page - 1) * limit
 
-	subscriptions, err := h.repo.GetAllSubscriptions(r.Context(), offset, limit)
+	subscriptions, err := h.repo.ListByUser(r.Context(), userUUID, limit, offset)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error fetching subscriptions: "+err.Error())
+		respondWithError(w, r, http.StatusInternalServerError, "Error fetching subscriptions")
 		return
 	}
 
-	// Отправляем результат в формате JSON
+	for i := range subscriptions {
+		subscriptions[i].RefreshDerived()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(subscriptions)
 }
 
+// GetUserSubscription godoc
+// @Summary Вернуть подписку пользователя
+// @Description Возвращает подписку по id, если она принадлежит указанному пользователю.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "ID пользователя (UUID)"
+// @Param id path string true "ID подписки (UUID)"
+// @Success 200 {object} models.Subscription
+// @Header 200 {string} ETag "Версия подписки для If-Match"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/subscriptions/{id} [get]
+func (h *Handler) GetUserSubscription(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userUUID, err := parseUUID(vars["user_id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid user_id format")
+		return
+	}
+
+	subUUID, err := parseUUID(vars["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
+		return
+	}
+
+	subscription, ok := h.getSubscription(w, r, subUUID)
+	if !ok {
+		return
+	}
+
+	// Подписка другого пользователя для этого ресурса не существует
+	if subscription.UserID != userUUID {
+		respondWithError(w, r, http.StatusNotFound, "Subscription not found")
+		return
+	}
+
+	respondWithSubscription(w, http.StatusOK, subscription)
+}
+
 // GetTotalCost godoc
 // @Summary Подсчитывает общую стоимость подписок за выбранную дату
-// @Description Возвращает сумму подписок за указанную дату с возможностью фильтрации по пользователю и сервису
+// @Description Возвращает сумму подписок за указанную дату в минорных единицах валюты с возможностью фильтрации по пользователю и сервису.
+// @Description Цены в других валютах пересчитываются по курсу, опубликованному не позже date; дата курса возвращается в rate_date.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param date path string true "Дата в формате YYYY-MM-DD"
 // @Param user_id query string false "ID пользователя (UUID)"
 // @Param service_name query string false "Название сервиса"
+// @Param currency query string false "Валюта результата (ISO 4217), по умолчанию RUB"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions/view/total/{date} [get]
 func (h *Handler) GetTotalCost(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	dateStr := vars["date"] // например, "2023-10-15"
 
+	var v validator
+
 	// Парсим дату
-	date, err := time.Parse("2006-01-02", dateStr)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid date format")
-		return
+	date := v.date("date", dateFormatDay, dateStr)
+	if date == nil {
+		v.add("date", codeRequired, "date is required")
 	}
 
 	// Получаем фильтры из query-параметров
-	userIDStr := r.URL.Query().Get("user_id")
 	serviceName := r.URL.Query().Get("service_name")
+	userUUID := v.id("user_id", r.URL.Query().Get("user_id"))
+	filterByUser := userUUID != uuid.Nil
 
-	var userUUID uuid.UUID
-	var filterByUser bool
+	currency := r.URL.Query().Get("currency")
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	currency = h.currency(r.Context(), &v, "currency", currency)
 
-	if userIDStr != "" {
-		userUUID, err = parseUUID(userIDStr)
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Invalid subscription ID format")
-			return
-		}
-		filterByUser = true
+	if !v.valid() {
+		v.respond(w, r)
+		return
 	}
 
 	// Вызов вашей функции подсчета
-	totalCost, err := h.repo.GetTotalSubscriptionCost(r.Context(), date, filterByUser, userUUID, serviceName)
+	totals, err := h.repo.GetTotalSubscriptionCost(r.Context(), *date, filterByUser, userUUID, serviceName)
 	if err != nil {
-		http.Error(w, "Error calculating total cost", http.StatusInternalServerError)
+		log.Println("Failed to calculate total cost:", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error calculating total cost")
+		return
+	}
+
+	totalCost, rateDate, err := h.convertTotals(r.Context(), totals, currency, *date)
+	if err != nil {
+		if errors.Is(err, rates.ErrUnknownCurrency) {
+			respondWithError(w, r, http.StatusBadRequest, "Unsupported currency")
+		} else {
+			respondWithError(w, r, http.StatusInternalServerError, "Error converting currency")
+		}
 		return
 	}
 
 	// Отправляем ответ в JSON
 	resp := map[string]interface{}{
-		"date":  dateStr,
-		"total": totalCost,
+		"date":     dateStr,
+		"total":    totalCost,
+		"currency": currency,
+	}
+	if rateDate != nil {
+		resp["rate_date"] = rateDate.Format("2006-01-02")
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+// convertTotals переводит суммы по валютам в одну валюту по курсу на дату date.
+// Возвращает дату использованного курса или nil, если пересчет не понадобился.
+func (h *Handler) convertTotals(ctx context.Context, totals map[string]float64, currency string, date time.Time) (float64, *time.Time, error) {
+	var total float64
+	var rateDate *time.Time
+
+	for from, amount := range totals {
+		converted, publishedAt, err := h.convert(ctx, amount, from, currency, date)
+		if err != nil {
+			return 0, nil, err
+		}
+		total += converted
+		rateDate = laterDate(rateDate, publishedAt)
+	}
+
+	// Целевую валюту проверяем, даже если пересчитывать нечего
+	if rateDate == nil && currency != models.DefaultCurrency {
+		if _, _, err := h.rates.Rate(ctx, currency, models.DefaultCurrency, date); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return total, rateDate, nil
+}
+
+// convert переводит сумму в минорных единицах из валюты from в валюту to.
+// Если валюты совпадают, курс не запрашивается и дата курса равна nil.
+func (h *Handler) convert(ctx context.Context, amount float64, from, to string, date time.Time) (float64, *time.Time, error) {
+	if from == to {
+		return amount, nil, nil
+	}
+
+	rate, publishedAt, err := h.rates.Rate(ctx, from, to, date)
+	if err != nil {
+		return 0, nil, err
+	}
+	return rates.ConvertMinor(amount, from, to, rate), &publishedAt, nil
+}
+
+// laterDate возвращает более позднюю из двух дат, nil считается отсутствием даты
+func laterDate(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.After(*a)) {
+		return b
+	}
+	return a
+}
+
+// getSubscription загружает подписку и сам отвечает клиенту, если её нет
+func (h *Handler) getSubscription(w http.ResponseWriter, r *http.Request, id uuid.UUID) (*models.Subscription, bool) {
+	subscription, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondWithError(w, r, http.StatusNotFound, "Subscription not found")
+		} else {
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch subscription")
+		}
+		return nil, false
+	}
+	subscription.RefreshDerived()
+	return subscription, true
+}
+
 func parseDate(layout, dateStr string) (*time.Time, error) {
 	t, err := time.Parse(layout, dateStr)
 	if err != nil {
@@ -341,9 +766,15 @@ func parseUUID(userIDStr string) (uuid.UUID, error) {
 	return uuid.Parse(userIDStr)
 }
 
-// Обработка ошибок с логированием
-func respondWithError(w http.ResponseWriter, status int, message string) {
-	log.Printf("Error: %s", message)
+// respondWithJSON отправляет ответ в формате JSON с указанным статусом
+func respondWithJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	json.NewEncoder(w).Encode(payload)
+}
+
+// Обработка ошибок с логированием: ответ в формате application/problem+json
+func respondWithError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	log.Printf("Error: %s", message)
+	respondWithProblem(w, r, status, message, nil)
 }
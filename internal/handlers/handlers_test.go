@@ -0,0 +1,1552 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/handlers"
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/rates"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/EvgenyiK/subscription-service/internal/server"
+	"github.com/google/uuid"
+)
+
+var (
+	userA = uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	userB = uuid.MustParse("22222222-2222-2222-2222-222222222222")
+
+	netflixID = uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+	spotifyID = uuid.MustParse("bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb")
+	youtubeID = uuid.MustParse("cccccccc-cccc-cccc-cccc-cccccccccccc")
+	trialID   = uuid.MustParse("dddddddd-dddd-dddd-dddd-dddddddddddd")
+	missingID = uuid.MustParse("eeeeeeee-eeee-eeee-eeee-eeeeeeeeeeee")
+
+	netflixServiceID = uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff")
+
+	// malformedID проходит шаблон маршрута, но не является UUID
+	malformedID = "0123456789abcdef0123456789abcdef0123"
+)
+
+var errStorage = errors.New("storage is unavailable")
+
+// testAdminToken — токен администратора тестового роутера
+const testAdminToken = "test-admin-token"
+
+// failingRepository имитирует недоступное хранилище
+type failingRepository struct {
+	*repository.MemoryRepository
+}
+
+func (failingRepository) Create(context.Context, *models.Subscription, *repository.Change) error {
+	return errStorage
+}
+
+func (failingRepository) GetByID(context.Context, uuid.UUID) (*models.Subscription, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) Delete(context.Context, uuid.UUID, int, *repository.Change) error {
+	return errStorage
+}
+
+func (failingRepository) GetAllSubscriptions(context.Context, int, int, repository.ListFilter) ([]models.Subscription, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) CountSubscriptions(context.Context, repository.ListFilter) (int, error) {
+	return 0, errStorage
+}
+
+func (failingRepository) ListSubscriptionsAfter(context.Context, *repository.ListCursor, int, repository.ListFilter) ([]models.Subscription, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) SearchSubscriptions(context.Context, string, *uuid.UUID, int) ([]models.SearchResult, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) ListByUser(context.Context, uuid.UUID, int, int) ([]models.Subscription, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) GetTotalSubscriptionCost(context.Context, time.Time, bool, uuid.UUID, string) (map[string]float64, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) GetCostReport(context.Context, time.Time, time.Time, repository.CostFilter) ([]models.CostReportMonth, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) GetCostBreakdown(context.Context, time.Time, repository.BreakdownGroupBy, repository.CostFilter) ([]repository.CostGroup, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) CountEvents(context.Context, uuid.UUID) (int, error) {
+	return 0, errStorage
+}
+
+func (failingRepository) ListUpcomingCharges(context.Context, time.Time, time.Time, *uuid.UUID) ([]models.UpcomingCharge, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) SetBudget(context.Context, *models.Budget) error {
+	return errStorage
+}
+
+func (failingRepository) GetBudget(context.Context, uuid.UUID) (*models.Budget, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) CreateService(context.Context, *models.Service) error {
+	return errStorage
+}
+
+func (failingRepository) GetService(context.Context, uuid.UUID) (*models.Service, error) {
+	return nil, errStorage
+}
+
+func (failingRepository) ListServices(context.Context, string) ([]models.Service, error) {
+	return nil, errStorage
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func datePtr(year int, month time.Month, day int) *time.Time {
+	d := date(year, month, day)
+	return &d
+}
+
+// seededRepository возвращает хранилище с фиксированным набором подписок:
+// у userA — Netflix (3100 RUB, бессрочно) и Spotify (6200 RUB, до 12-2025),
+// у userB — YouTube (1000 USD) и Trial с пробным периодом до 2099 года.
+// В каталоге есть только Netflix (категория video, псевдоним "nflx", цена по умолчанию 3100 RUB),
+// с ним связана подписка Netflix.
+func seededRepository(t *testing.T) *repository.MemoryRepository {
+	t.Helper()
+
+	repo := repository.NewMemoryRepository()
+	defaultPrice := 3100
+	if err := repo.CreateService(context.Background(), &models.Service{
+		ID: netflixServiceID, Name: "Netflix", Aliases: []string{"nflx"}, Category: "video",
+		DefaultPrice: &defaultPrice, Currency: "RUB", BillingPeriod: models.BillingMonthly,
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	subs := []models.Subscription{
+		{ID: netflixID, ServiceName: "Netflix", Price: 3100, Currency: "RUB", BillingPeriod: models.BillingMonthly,
+			UserID: userA, StartDate: date(2025, 1, 1), ServiceID: &netflixServiceID},
+		{ID: spotifyID, ServiceName: "Spotify", Price: 6200, Currency: "RUB", BillingPeriod: models.BillingMonthly,
+			UserID: userA, StartDate: date(2025, 2, 1), EndDate: datePtr(2025, 12, 1)},
+		{ID: youtubeID, ServiceName: "YouTube", Price: 1000, Currency: "USD", BillingPeriod: models.BillingMonthly,
+			UserID: userB, StartDate: date(2025, 3, 1)},
+		{ID: trialID, ServiceName: "Trial", Price: 500, Currency: "RUB", BillingPeriod: models.BillingMonthly,
+			UserID: userB, StartDate: date(2025, 4, 1), TrialEnd: datePtr(2099, 1, 1)},
+	}
+	for i := range subs {
+		if err := repo.Create(context.Background(), &subs[i], nil); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return repo
+}
+
+func newRouter(t *testing.T, repo repository.Storage) http.Handler {
+	t.Helper()
+
+	provider, err := rates.NewFileProvider("")
+	if err != nil {
+		t.Fatalf("rates: %v", err)
+	}
+	return server.NewRouter(handlers.NewHandler(repo, provider, []byte("test-cursor-secret"), testAdminToken))
+}
+
+// routeTest описывает один запрос к API и ожидаемый ответ.
+// Если задан wantBody, тело ответа сравнивается с ним целиком.
+// wantError — ожидаемый detail ответа application/problem+json,
+// wantFields — ожидаемые коды ошибок по полям.
+type routeTest struct {
+	name       string
+	method     string
+	path       string
+	body       string
+	headers    map[string]string
+	failing    bool
+	wantStatus int
+	wantBody   string
+	wantError  string
+	wantFields map[string]string
+	wantLink   string
+	wantETag   string
+	check      func(t *testing.T, body []byte)
+}
+
+func runRouteTests(t *testing.T, tests []routeTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var repo repository.Storage = seededRepository(t)
+			if tt.failing {
+				repo = failingRepository{repository.NewMemoryRepository()}
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			newRouter(t, repo).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("%s %s: status = %d, want %d; body: %s", tt.method, tt.path, rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" {
+				if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+					t.Errorf("%s %s: body = %s, want %s", tt.method, tt.path, got, tt.wantBody)
+				}
+			}
+			if tt.wantError != "" || tt.wantFields != nil {
+				problem := decodeProblem(t, rec, tt.path)
+				if tt.wantError != "" && problem.Detail != tt.wantError {
+					t.Errorf("%s %s: detail = %q, want %q", tt.method, tt.path, problem.Detail, tt.wantError)
+				}
+				if tt.wantFields != nil {
+					got := make(map[string]string, len(problem.Errors))
+					for _, e := range problem.Errors {
+						got[e.Field] = e.Code
+					}
+					if !reflect.DeepEqual(got, tt.wantFields) {
+						t.Errorf("%s %s: field errors = %v, want %v", tt.method, tt.path, got, tt.wantFields)
+					}
+				}
+			}
+			if tt.wantLink != "" {
+				if got := rec.Header().Get("Link"); got != tt.wantLink {
+					t.Errorf("%s %s: Link = %s, want %s", tt.method, tt.path, got, tt.wantLink)
+				}
+			}
+			if tt.wantETag != "" {
+				if got := rec.Header().Get("ETag"); got != tt.wantETag {
+					t.Errorf("%s %s: ETag = %s, want %s", tt.method, tt.path, got, tt.wantETag)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, rec.Body.Bytes())
+			}
+		})
+	}
+}
+
+// ifMatch — заголовки изменяющего запроса к подписке исходной версии
+var ifMatch = map[string]string{"If-Match": `"1"`}
+
+// mergePatch — заголовки запроса с телом JSON Merge Patch к подписке исходной версии
+var mergePatch = map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `"1"`}
+
+// decodeProblem разбирает ответ application/problem+json и проверяет его общие поля
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder, path string) models.Problem {
+	t.Helper()
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", ct)
+	}
+	problem := decode[models.Problem](t, rec.Body.Bytes())
+	if problem.Type != "about:blank" || problem.Status != rec.Code || problem.Title != http.StatusText(rec.Code) {
+		t.Errorf("problem type/status/title = %q/%d/%q, want about:blank/%d/%q",
+			problem.Type, problem.Status, problem.Title, rec.Code, http.StatusText(rec.Code))
+	}
+	if want, _, _ := strings.Cut(path, "?"); problem.Instance != want {
+		t.Errorf("problem instance = %q, want %q", problem.Instance, want)
+	}
+	return problem
+}
+
+func decode[T any](t *testing.T, body []byte) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	return v
+}
+
+func assertClose(t *testing.T, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-6 {
+		t.Errorf("got %.6f, want %.6f", got, want)
+	}
+}
+
+func expectIDs(want ...uuid.UUID) func(t *testing.T, body []byte) {
+	return func(t *testing.T, body []byte) {
+		t.Helper()
+		assertIDs(t, decode[[]models.Subscription](t, body), want)
+	}
+}
+
+func expectPage(total, page, limit int, want ...uuid.UUID) func(t *testing.T, body []byte) {
+	return func(t *testing.T, body []byte) {
+		t.Helper()
+		resp := decode[models.SubscriptionPage](t, body)
+		if resp.Total != total || resp.Page != page || resp.Limit != limit {
+			t.Errorf("total/page/limit = %d/%d/%d, want %d/%d/%d", resp.Total, resp.Page, resp.Limit, total, page, limit)
+		}
+		assertIDs(t, resp.Items, want)
+	}
+}
+
+func assertIDs(t *testing.T, subs []models.Subscription, want []uuid.UUID) {
+	t.Helper()
+	if len(subs) != len(want) {
+		t.Fatalf("got %d subscriptions, want %d", len(subs), len(want))
+	}
+	for i, id := range want {
+		if subs[i].ID != id {
+			t.Errorf("subscriptions[%d] = %s, want %s", i, subs[i].ID, id)
+		}
+	}
+}
+
+func expectSubscription(check func(t *testing.T, sub models.Subscription)) func(t *testing.T, body []byte) {
+	return func(t *testing.T, body []byte) {
+		t.Helper()
+		check(t, decode[models.Subscription](t, body))
+	}
+}
+
+func TestCreateSubscription(t *testing.T) {
+	valid := `{"service_name":"Kinopoisk","price":29900,"user_id":"` + userA.String() + `","start_date":"07-2025"}`
+
+	runRouteTests(t, []routeTest{
+		{name: "created with defaults", method: "POST", path: "/subscriptions", body: valid,
+			wantStatus: http.StatusCreated,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.ServiceName != "Kinopoisk" || sub.Price != 29900 || sub.UserID != userA {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+				if sub.Currency != "RUB" || sub.BillingPeriod != models.BillingMonthly || sub.EndDate != nil {
+					t.Errorf("defaults not applied: %+v", sub)
+				}
+				if !sub.StartDate.Equal(date(2025, 7, 1)) {
+					t.Errorf("start_date = %v, want 2025-07-01", sub.StartDate)
+				}
+			})},
+		{name: "lowercase currency is normalized", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"Steam","price":999,"currency":"usd","billing_period":"yearly","user_id":"` + userA.String() + `","start_date":"07-2025","end_date":"07-2026","trial_end":"2025-07-15"}`,
+			wantStatus: http.StatusCreated,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.Currency != "USD" || sub.BillingPeriod != models.BillingYearly {
+					t.Errorf("currency/billing_period = %s/%s, want USD/yearly", sub.Currency, sub.BillingPeriod)
+				}
+				if sub.TrialEnd == nil || !sub.TrialEnd.Equal(date(2025, 7, 15)) {
+					t.Errorf("trial_end = %v, want 2025-07-15", sub.TrialEnd)
+				}
+			})},
+		{name: "invalid json", method: "POST", path: "/subscriptions", body: `{"service_name":`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid request payload"},
+		{name: "empty body", method: "POST", path: "/subscriptions",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid request payload"},
+		{name: "missing service_name", method: "POST", path: "/subscriptions",
+			body:       `{"price":100,"user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "service_name or service_id is required",
+			wantFields: map[string]string{"service_name": "required"}},
+		{name: "missing start_date", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":100,"user_id":"` + userA.String() + `"}`,
+			wantStatus: http.StatusBadRequest, wantError: "start_date is required",
+			wantFields: map[string]string{"start_date": "required"}},
+		{name: "non-positive price", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":0,"user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "price must be positive",
+			wantFields: map[string]string{"price": "out_of_range"}},
+		{name: "every invalid field is reported", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":-1,"currency":"RUBL","billing_period":"daily","user_id":"42","start_date":"07-2025","end_date":"06-2025","trial_end":"soon"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "Invalid currency; Invalid user_id format; Invalid billing_period; Invalid trial_end format; price must be positive; end_date must not be before start_date",
+			wantFields: map[string]string{
+				"price": "out_of_range", "currency": "invalid_value", "billing_period": "invalid_value",
+				"user_id": "invalid_format", "end_date": "out_of_range", "trial_end": "invalid_format",
+			}},
+		{name: "invalid currency", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":100,"currency":"RUBL","user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid currency",
+			wantFields: map[string]string{"currency": "invalid_value"}},
+		{name: "currency without a rate", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":100,"currency":"XYZ","user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Unsupported currency",
+			wantFields: map[string]string{"currency": "invalid_value"}},
+		{name: "invalid billing_period", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":100,"billing_period":"daily","user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid billing_period"},
+		{name: "invalid user_id", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":100,"user_id":"not-a-uuid","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid user_id format",
+			wantFields: map[string]string{"user_id": "invalid_format"}},
+		{name: "invalid start_date", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":100,"user_id":"` + userA.String() + `","start_date":"2025-07-01"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid start_date format"},
+		{name: "invalid end_date", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":100,"user_id":"` + userA.String() + `","start_date":"07-2025","end_date":"2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid end_date format"},
+		{name: "end_date before start_date", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":100,"user_id":"` + userA.String() + `","start_date":"07-2025","end_date":"06-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "end_date must not be before start_date",
+			wantFields: map[string]string{"end_date": "out_of_range"}},
+		{name: "invalid trial_end", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":100,"user_id":"` + userA.String() + `","start_date":"07-2025","trial_end":"15-07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid trial_end format"},
+		{name: "trial_end before start_date", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"X","price":100,"user_id":"` + userA.String() + `","start_date":"07-2025","trial_end":"2025-06-30"}`,
+			wantStatus: http.StatusBadRequest, wantError: "trial_end must not be before start_date"},
+		{name: "storage failure", method: "POST", path: "/subscriptions", body: valid, failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Failed to create subscription"},
+
+		{name: "alias resolves to catalog entry", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"NFLX","user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusCreated,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.ServiceName != "Netflix" || sub.ServiceID == nil || *sub.ServiceID != netflixServiceID {
+					t.Errorf("service = %s/%v, want Netflix/%s", sub.ServiceName, sub.ServiceID, netflixServiceID)
+				}
+				if sub.Price != 3100 || sub.Currency != "RUB" {
+					t.Errorf("catalog defaults not applied: %+v", sub)
+				}
+			})},
+		{name: "catalog price keeps catalog currency", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"Netflix","currency":"rub","user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusCreated,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.Price != 3100 || sub.Currency != "RUB" || sub.BillingPeriod != models.BillingMonthly {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+			})},
+		{name: "catalog price with another currency", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"Netflix","currency":"USD","user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "currency must match the catalog currency RUB when price is omitted",
+			wantFields: map[string]string{"currency": "invalid_value"}},
+		{name: "catalog price with another billing_period", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"Netflix","billing_period":"yearly","user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "billing_period must match the catalog billing_period monthly when price is omitted",
+			wantFields: map[string]string{"billing_period": "invalid_value"}},
+		{name: "own price with another currency", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"Netflix","price":1299,"currency":"USD","user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusCreated,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.Price != 1299 || sub.Currency != "USD" {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+			})},
+		{name: "explicit service_id with own price", method: "POST", path: "/subscriptions",
+			body:       `{"service_id":"` + netflixServiceID.String() + `","price":4500,"user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusCreated,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.ServiceName != "Netflix" || sub.Price != 4500 {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+			})},
+		{name: "unknown service_id", method: "POST", path: "/subscriptions",
+			body:       `{"service_id":"` + missingID.String() + `","price":100,"user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Unknown service_id",
+			wantFields: map[string]string{"service_id": "unknown"}},
+		{name: "invalid service_id", method: "POST", path: "/subscriptions",
+			body:       `{"service_id":"42","price":100,"user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid service_id format",
+			wantFields: map[string]string{"service_id": "invalid_format"}},
+		{name: "missing price outside catalog", method: "POST", path: "/subscriptions",
+			body:       `{"service_name":"Kinopoisk","user_id":"` + userA.String() + `","start_date":"07-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "price is required",
+			wantFields: map[string]string{"price": "required"}},
+	})
+}
+
+// Подписка в валюте без курса не сохраняется и не ломает итоги в других валютах
+func TestCreateSubscriptionRejectsCurrencyWithoutRate(t *testing.T) {
+	repo := seededRepository(t)
+	router := newRouter(t, repo)
+	count := func() int {
+		t.Helper()
+		n, err := repo.CountSubscriptions(context.Background(), repository.ListFilter{})
+		if err != nil {
+			t.Fatalf("CountSubscriptions: %v", err)
+		}
+		return n
+	}
+	before := count()
+
+	body := `{"service_name":"Unknown","price":100,"currency":"XYZ","user_id":"` + userA.String() + `","start_date":"07-2025"}`
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/subscriptions", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("create: status = %d, want 400; body: %s", rec.Code, rec.Body.String())
+	}
+	if got := count(); got != before {
+		t.Errorf("stored %d subscriptions after rejected create, want %d", got, before)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/subscriptions/view/total/2025-07-15?currency=RUB", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("total in RUB: status = %d, want 200; body: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSubscriptionByID(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "get", method: "GET", path: "/subscriptions/" + netflixID.String(),
+			wantStatus: http.StatusOK, wantETag: `"1"`,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.ID != netflixID || sub.ServiceName != "Netflix" || sub.Status != models.StatusActive || sub.CurrentPrice != 3100 {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+			})},
+		{name: "get not found", method: "GET", path: "/subscriptions/" + missingID.String(),
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+		{name: "get malformed id", method: "GET", path: "/subscriptions/" + malformedID,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid subscription ID format"},
+		{name: "get storage failure", method: "GET", path: "/subscriptions/" + netflixID.String(), failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Failed to fetch subscription"},
+
+		{name: "update", method: "PUT", path: "/subscriptions/" + netflixID.String(), headers: ifMatch,
+			body:       `{"service_name":"Netflix Premium","price":4500,"currency":"eur","user_id":"` + userA.String() + `","start_date":"2025-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusOK, wantETag: `"2"`,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.ServiceName != "Netflix Premium" || sub.Price != 4500 || sub.Currency != "EUR" || sub.BillingPeriod != models.BillingMonthly || sub.Version != 2 {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+			})},
+		{name: "update invalid body", method: "PUT", path: "/subscriptions/" + netflixID.String(), headers: ifMatch, body: `[]`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid request body"},
+		{name: "update invalid currency", method: "PUT", path: "/subscriptions/" + netflixID.String(), headers: ifMatch,
+			body:       `{"service_name":"Netflix","price":3100,"currency":"R1B","user_id":"` + userA.String() + `","start_date":"2025-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid currency",
+			wantFields: map[string]string{"currency": "invalid_value"}},
+		{name: "update currency without a rate", method: "PUT", path: "/subscriptions/" + netflixID.String(), headers: ifMatch,
+			body:       `{"service_name":"Netflix","price":3100,"currency":"XYZ","user_id":"` + userA.String() + `","start_date":"2025-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Unsupported currency",
+			wantFields: map[string]string{"currency": "invalid_value"}},
+		{name: "update invalid billing_period", method: "PUT", path: "/subscriptions/" + netflixID.String(), headers: ifMatch,
+			body:       `{"service_name":"Netflix","price":3100,"billing_period":"hourly","user_id":"` + userA.String() + `","start_date":"2025-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid billing_period",
+			wantFields: map[string]string{"billing_period": "invalid_value"}},
+		{name: "update missing fields", method: "PUT", path: "/subscriptions/" + netflixID.String(), headers: ifMatch,
+			body:       `{"price":0,"end_date":"2024-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "service_name is required; price must be positive; user_id is required; start_date is required",
+			wantFields: map[string]string{"service_name": "required", "price": "out_of_range", "user_id": "required", "start_date": "required"}},
+		{name: "update end before start", method: "PUT", path: "/subscriptions/" + netflixID.String(), headers: ifMatch,
+			body:       `{"service_name":"Netflix","price":3100,"user_id":"` + userA.String() + `","start_date":"2025-01-01T00:00:00Z","end_date":"2024-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusBadRequest, wantError: "end_date must not be before start_date",
+			wantFields: map[string]string{"end_date": "out_of_range"}},
+		{name: "update without If-Match", method: "PUT", path: "/subscriptions/" + netflixID.String(),
+			body:       `{"service_name":"Netflix","price":3100}`,
+			wantStatus: http.StatusPreconditionRequired, wantError: "If-Match header is required"},
+		{name: "update stale version", method: "PUT", path: "/subscriptions/" + netflixID.String(),
+			headers:    map[string]string{"If-Match": `"7", W/"1"`},
+			body:       `{"service_name":"Netflix","price":100}`,
+			wantStatus: http.StatusPreconditionFailed, wantETag: `"1"`,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.ID != netflixID || sub.Price != 3100 || sub.Version != 1 {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+			})},
+		{name: "update not found", method: "PUT", path: "/subscriptions/" + missingID.String(), headers: ifMatch, body: `{}`,
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+		{name: "update malformed id", method: "PUT", path: "/subscriptions/" + malformedID, headers: ifMatch, body: `{}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid subscription ID format"},
+
+		{name: "patch price", method: "PATCH", path: "/subscriptions/" + spotifyID.String(),
+			body: `{"price":7500}`, headers: mergePatch,
+			wantStatus: http.StatusOK,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.Price != 7500 || sub.ServiceName != "Spotify" || sub.UserID != userA || sub.Currency != "RUB" ||
+					!sub.StartDate.Equal(date(2025, 2, 1)) || sub.EndDate == nil || !sub.EndDate.Equal(date(2025, 12, 1)) {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+			})},
+		{name: "patch null end_date", method: "PATCH", path: "/subscriptions/" + spotifyID.String(),
+			body: `{"end_date":null,"currency":"usd"}`, headers: map[string]string{"Content-Type": "application/json", "If-Match": `"1"`},
+			wantStatus: http.StatusOK,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.EndDate != nil || sub.Currency != "USD" || sub.Price != 6200 {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+			})},
+		{name: "patch service name links catalog", method: "PATCH", path: "/subscriptions/" + spotifyID.String(),
+			body: `{"service_name":"nflx"}`, headers: mergePatch,
+			wantStatus: http.StatusOK,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.ServiceName != "Netflix" || sub.ServiceID == nil || *sub.ServiceID != netflixServiceID || sub.Price != 6200 {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+			})},
+		{name: "patch invalid currency", method: "PATCH", path: "/subscriptions/" + netflixID.String(),
+			body: `{"currency":"R1B"}`, headers: mergePatch,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid currency"},
+		{name: "patch currency without a rate", method: "PATCH", path: "/subscriptions/" + netflixID.String(),
+			body: `{"currency":"xyz"}`, headers: mergePatch,
+			wantStatus: http.StatusBadRequest, wantError: "Unsupported currency"},
+		{name: "patch end before start", method: "PATCH", path: "/subscriptions/" + spotifyID.String(),
+			body: `{"end_date":"01-2025"}`, headers: mergePatch,
+			wantStatus: http.StatusBadRequest, wantError: "end_date must not be before start_date"},
+		{name: "patch removes required field", method: "PATCH", path: "/subscriptions/" + spotifyID.String(),
+			body: `{"user_id":null}`, headers: mergePatch,
+			wantStatus: http.StatusBadRequest, wantError: "user_id is required",
+			wantFields: map[string]string{"user_id": "required"}},
+		{name: "patch wrong type", method: "PATCH", path: "/subscriptions/" + spotifyID.String(),
+			body: `{"price":"free"}`, headers: mergePatch,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid request payload"},
+		{name: "patch not an object", method: "PATCH", path: "/subscriptions/" + spotifyID.String(),
+			body: `[]`, headers: mergePatch,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid merge patch, expected a JSON object"},
+		{name: "patch unsupported content type", method: "PATCH", path: "/subscriptions/" + spotifyID.String(),
+			body: `{"price":7500}`, headers: map[string]string{"Content-Type": "text/plain"},
+			wantStatus: http.StatusUnsupportedMediaType, wantError: "Content-Type must be application/merge-patch+json"},
+		{name: "patch stale version", method: "PATCH", path: "/subscriptions/" + spotifyID.String(),
+			body: `{"price":7500}`, headers: map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `"2"`},
+			wantStatus: http.StatusPreconditionFailed, wantETag: `"1"`},
+		{name: "patch not found", method: "PATCH", path: "/subscriptions/" + missingID.String(),
+			body: `{}`, headers: mergePatch,
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+
+		{name: "delete", method: "DELETE", path: "/subscriptions/" + netflixID.String(), headers: ifMatch,
+			wantStatus: http.StatusNoContent},
+		{name: "delete any version", method: "DELETE", path: "/subscriptions/" + netflixID.String(),
+			headers: map[string]string{"If-Match": "*"}, wantStatus: http.StatusNoContent},
+		{name: "delete without If-Match", method: "DELETE", path: "/subscriptions/" + netflixID.String(),
+			wantStatus: http.StatusPreconditionRequired, wantError: "If-Match header is required"},
+		{name: "delete stale version", method: "DELETE", path: "/subscriptions/" + netflixID.String(),
+			headers: map[string]string{"If-Match": `"0"`}, wantStatus: http.StatusPreconditionFailed, wantETag: `"1"`},
+		{name: "delete not found", method: "DELETE", path: "/subscriptions/" + missingID.String(), headers: ifMatch,
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+		{name: "delete malformed id", method: "DELETE", path: "/subscriptions/" + malformedID, headers: ifMatch,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid subscription ID format"},
+		{name: "delete storage failure", method: "DELETE", path: "/subscriptions/" + netflixID.String(), headers: ifMatch, failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Failed to fetch subscription"},
+	})
+}
+
+func TestSoftDelete(t *testing.T) {
+	router := newRouter(t, seededRepository(t))
+
+	do := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	listTotal := func(path string, headers map[string]string) models.SubscriptionPage {
+		t.Helper()
+		rec := do("GET", path, headers)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d; body: %s", path, rec.Code, rec.Body.String())
+		}
+		return decode[models.SubscriptionPage](t, rec.Body.Bytes())
+	}
+
+	netflixPath := "/subscriptions/" + netflixID.String()
+	if rec := do("DELETE", netflixPath, ifMatch); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("GET", netflixPath, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("GET deleted: status = %d, want 404", rec.Code)
+	}
+	if page := listTotal("/subscriptions/view/list", nil); page.Total != 3 {
+		t.Errorf("list total = %d, want 3", page.Total)
+	}
+
+	// Удаленные подписки в списке видны только с токеном администратора
+	const withDeleted = "/subscriptions/view/list?include_deleted=true"
+	if rec := do("GET", withDeleted, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("include_deleted without token: status = %d, want 403", rec.Code)
+	}
+	if rec := do("GET", withDeleted, map[string]string{"X-Admin-Token": "wrong"}); rec.Code != http.StatusForbidden {
+		t.Errorf("include_deleted with wrong token: status = %d, want 403", rec.Code)
+	}
+	page := listTotal(withDeleted, map[string]string{"X-Admin-Token": testAdminToken})
+	if page.Total != 4 || page.Items[0].ID != netflixID || page.Items[0].DeletedAt == nil {
+		t.Errorf("include_deleted page = %+v", page)
+	}
+
+	rec := do("POST", netflixPath+"/restore", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore: status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+	if sub := decode[models.Subscription](t, rec.Body.Bytes()); sub.DeletedAt != nil || sub.Version != 3 || rec.Header().Get("ETag") != `"3"` {
+		t.Errorf("restored subscription %+v, ETag %s", sub, rec.Header().Get("ETag"))
+	}
+	if rec := do("GET", netflixPath, nil); rec.Code != http.StatusOK {
+		t.Errorf("GET restored: status = %d, want 200", rec.Code)
+	}
+	if rec := do("POST", netflixPath+"/restore", nil); rec.Code != http.StatusConflict || decodeProblem(t, rec, netflixPath+"/restore").Detail != "Subscription is not deleted" {
+		t.Errorf("second restore: status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRestoreSubscription(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "not found", method: "POST", path: "/subscriptions/" + missingID.String() + "/restore",
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+		{name: "malformed id", method: "POST", path: "/subscriptions/" + malformedID + "/restore",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid subscription ID format"},
+		{name: "invalid include_deleted", method: "GET", path: "/subscriptions/view/list?include_deleted=maybe",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid include_deleted value",
+			wantFields: map[string]string{"include_deleted": "invalid_format"}},
+	})
+}
+
+func TestSubscriptionHistory(t *testing.T) {
+	router := newRouter(t, seededRepository(t))
+
+	do := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code >= 300 {
+			t.Fatalf("%s %s: status = %d; body: %s", method, path, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+
+	alice := map[string]string{"X-Actor": "alice", "X-Request-ID": "req-create"}
+	rec := do("POST", "/subscriptions", `{"service_name":"Kinopoisk","price":29900,"user_id":"`+userA.String()+`","start_date":"07-2025"}`, alice)
+	if got := rec.Header().Get("X-Request-ID"); got != "req-create" {
+		t.Errorf("X-Request-ID = %q, want the client's ID", got)
+	}
+	path := "/subscriptions/" + decode[models.Subscription](t, rec.Body.Bytes()).ID.String()
+
+	bob := map[string]string{"X-Actor": "bob", "Content-Type": "application/merge-patch+json", "If-Match": `"1"`}
+	do("PATCH", path, `{"price":19900}`, bob)
+	// Патч без изменений не попадает в журнал
+	bob["If-Match"] = `"2"`
+	do("PATCH", path, `{"price":19900}`, bob)
+	do("DELETE", path, "", map[string]string{"If-Match": `"2"`})
+	rec = do("POST", path+"/restore", "", nil)
+	if rec.Header().Get("X-Request-ID") == "" {
+		t.Error("X-Request-ID is not generated")
+	}
+	do("POST", path+"/cancel", `{"effective":"immediate","reason":"not_used"}`, nil)
+
+	rec = do("GET", path+"/history?limit=10", "", nil)
+	page := decode[models.SubscriptionEventPage](t, rec.Body.Bytes())
+	wantActions := []models.SubscriptionAction{
+		models.ActionCreate, models.ActionUpdate, models.ActionDelete, models.ActionRestore, models.ActionCancel,
+	}
+	if page.Total != len(wantActions) || len(page.Items) != len(wantActions) {
+		t.Fatalf("history = %+v, want %d events", page, len(wantActions))
+	}
+	for i, action := range wantActions {
+		if page.Items[i].Action != action {
+			t.Errorf("event %d action = %s, want %s", i, page.Items[i].Action, action)
+		}
+	}
+
+	created := page.Items[0]
+	if created.Actor != "alice" || created.RequestID != "req-create" || string(created.Before) != "null" {
+		t.Errorf("create event = %+v", created)
+	}
+	if page.Items[2].Actor != "anonymous" || string(page.Items[2].After) != "null" {
+		t.Errorf("delete event = %+v", page.Items[2])
+	}
+	if page.Items[3].RequestID == "" {
+		t.Error("restore event has no generated request ID")
+	}
+	// Восстановление записывается как переход из удаленной подписки
+	deleted := decode[models.Subscription](t, page.Items[3].Before)
+	restored := decode[models.Subscription](t, page.Items[3].After)
+	if deleted.DeletedAt == nil || deleted.Version != 3 || restored.DeletedAt != nil || restored.Version != 4 {
+		t.Errorf("restore event: before %+v, after %+v", deleted, restored)
+	}
+
+	updated := page.Items[1]
+	before := decode[models.Subscription](t, updated.Before)
+	after := decode[models.Subscription](t, updated.After)
+	if updated.Actor != "bob" || before.Price != 29900 || after.Price != 19900 || after.Version != 2 {
+		t.Errorf("update event = %+v; before %+v, after %+v", updated, before, after)
+	}
+
+	rec = do("GET", path+"/history?page=2&limit=2", "", nil)
+	page = decode[models.SubscriptionEventPage](t, rec.Body.Bytes())
+	if page.Total != 5 || len(page.Items) != 2 || page.Items[0].Action != models.ActionDelete {
+		t.Errorf("second history page = %+v", page)
+	}
+	if link := rec.Header().Get("Link"); !strings.Contains(link, `rel="next"`) {
+		t.Errorf("Link = %s, want a next page", link)
+	}
+}
+
+func TestGetSubscriptionHistory(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "created outside the API", method: "GET", path: "/subscriptions/" + netflixID.String() + "/history",
+			wantStatus: http.StatusOK, wantBody: `{"items":[],"total":0,"page":1,"limit":10}`},
+		{name: "not found", method: "GET", path: "/subscriptions/" + missingID.String() + "/history",
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+		{name: "malformed id", method: "GET", path: "/subscriptions/" + malformedID + "/history",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid subscription ID format"},
+		{name: "storage failure", method: "GET", path: "/subscriptions/" + netflixID.String() + "/history", failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Error fetching subscription history"},
+	})
+}
+
+func TestListSubscriptions(t *testing.T) {
+	const list = "/subscriptions/view/list"
+
+	runRouteTests(t, []routeTest{
+		{name: "default page", method: "GET", path: list,
+			wantStatus: http.StatusOK, check: expectPage(4, 1, 10, netflixID, spotifyID, youtubeID, trialID),
+			wantLink: `<` + list + `?limit=10&page=1>; rel="first", <` + list + `?limit=10&page=1>; rel="last"`},
+		{name: "first page", method: "GET", path: list + "?page=1&limit=2",
+			wantStatus: http.StatusOK, check: expectPage(4, 1, 2, netflixID, spotifyID),
+			wantLink: `<` + list + `?limit=2&page=1>; rel="first", <` + list + `?limit=2&page=2>; rel="next", <` + list + `?limit=2&page=2>; rel="last"`},
+		{name: "second page", method: "GET", path: list + "?page=2&limit=3",
+			wantStatus: http.StatusOK, check: expectPage(4, 2, 3, trialID),
+			wantLink: `<` + list + `?limit=3&page=1>; rel="first", <` + list + `?limit=3&page=1>; rel="prev", <` + list + `?limit=3&page=2>; rel="last"`},
+		{name: "page past the end", method: "GET", path: list + "?page=5&limit=2",
+			wantStatus: http.StatusOK, wantBody: `{"items":[],"total":4,"page":5,"limit":2}`,
+			wantLink: `<` + list + `?limit=2&page=1>; rel="first", <` + list + `?limit=2&page=2>; rel="prev", <` + list + `?limit=2&page=2>; rel="last"`},
+		{name: "limit is capped", method: "GET", path: list + "?limit=1000",
+			wantStatus: http.StatusOK, check: expectPage(4, 1, 100, netflixID, spotifyID, youtubeID, trialID)},
+		{name: "invalid page falls back to defaults", method: "GET", path: list + "?page=-1&limit=abc",
+			wantStatus: http.StatusOK, check: expectPage(4, 1, 10, netflixID, spotifyID, youtubeID, trialID)},
+		{name: "in trial", method: "GET", path: list + "?in_trial=true",
+			wantStatus: http.StatusOK, check: expectPage(1, 1, 10, trialID)},
+		{name: "links keep filters", method: "GET", path: list + "?in_trial=false&limit=2",
+			wantStatus: http.StatusOK, check: expectPage(3, 1, 2, netflixID, spotifyID),
+			wantLink: `<` + list + `?in_trial=false&limit=2&page=1>; rel="first", <` + list + `?in_trial=false&limit=2&page=2>; rel="next", <` + list + `?in_trial=false&limit=2&page=2>; rel="last"`},
+		{name: "invalid cursor", method: "GET", path: list + "?cursor=eyJzIjoiMjAyNS0wMS0wMSJ9.c2ln",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid cursor",
+			wantFields: map[string]string{"cursor": "invalid_format"}},
+		{name: "cursor with page", method: "GET", path: list + "?cursor=abc&page=2",
+			wantStatus: http.StatusBadRequest, wantError: "page and cursor cannot be used together",
+			wantFields: map[string]string{"cursor": "invalid_value"}},
+		{name: "invalid in_trial", method: "GET", path: list + "?in_trial=maybe",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid in_trial value",
+			wantFields: map[string]string{"in_trial": "invalid_format"}},
+		{name: "storage failure", method: "GET", path: list, failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Error fetching subscriptions"},
+	})
+}
+
+func TestListSubscriptionsFilters(t *testing.T) {
+	const list = "/subscriptions/view/list"
+
+	runRouteTests(t, []routeTest{
+		{name: "by user", method: "GET", path: list + "?user_id=" + userB.String(),
+			wantStatus: http.StatusOK, check: expectPage(2, 1, 10, youtubeID, trialID)},
+		{name: "by service name", method: "GET", path: list + "?service_name=Spotify",
+			wantStatus: http.StatusOK, check: expectPage(1, 1, 10, spotifyID)},
+		{name: "by service name prefix", method: "GET", path: list + "?service_name_prefix=Spo",
+			wantStatus: http.StatusOK, check: expectPage(1, 1, 10, spotifyID)},
+		{name: "prefix is not a pattern", method: "GET", path: list + "?service_name_prefix=%25",
+			wantStatus: http.StatusOK, check: expectPage(0, 1, 10)},
+		{name: "by price range", method: "GET", path: list + "?price_min=1000&price_max=3100",
+			wantStatus: http.StatusOK, check: expectPage(2, 1, 10, netflixID, youtubeID)},
+		{name: "active on date", method: "GET", path: list + "?active_on=2025-02-15",
+			wantStatus: http.StatusOK, check: expectPage(2, 1, 10, netflixID, spotifyID)},
+		{name: "by start_date range", method: "GET", path: list + "?start_from=2025-02-01&start_to=2025-03-01",
+			wantStatus: http.StatusOK, check: expectPage(2, 1, 10, spotifyID, youtubeID)},
+		{name: "combined filters", method: "GET", path: list + "?user_id=" + userA.String() + "&active_on=2026-01-15",
+			wantStatus: http.StatusOK, check: expectPage(1, 1, 10, netflixID)},
+		{name: "sort by price desc", method: "GET", path: list + "?sort=price:desc",
+			wantStatus: http.StatusOK, check: expectPage(4, 1, 10, spotifyID, netflixID, youtubeID, trialID)},
+		{name: "sort by several fields", method: "GET", path: list + "?sort=user_id:desc,service_name",
+			wantStatus: http.StatusOK, check: expectPage(4, 1, 10, trialID, youtubeID, netflixID, spotifyID)},
+		{name: "sorted page has no cursor", method: "GET", path: list + "?sort=price&limit=1",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				if resp := decode[models.SubscriptionPage](t, body); resp.NextCursor != "" {
+					t.Errorf("next_cursor = %q, want empty", resp.NextCursor)
+				}
+			}},
+
+		{name: "invalid user_id", method: "GET", path: list + "?user_id=42",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid user_id format",
+			wantFields: map[string]string{"user_id": "invalid_format"}},
+		{name: "invalid price_min", method: "GET", path: list + "?price_min=-5",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid price_min, expected a non-negative integer",
+			wantFields: map[string]string{"price_min": "out_of_range"}},
+		{name: "invalid price_max", method: "GET", path: list + "?price_max=lots",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid price_max, expected a non-negative integer",
+			wantFields: map[string]string{"price_max": "invalid_format"}},
+		{name: "inverted price range", method: "GET", path: list + "?price_min=10&price_max=5",
+			wantStatus: http.StatusBadRequest, wantError: "price_min must not be greater than price_max",
+			wantFields: map[string]string{"price_min": "out_of_range"}},
+		{name: "invalid active_on", method: "GET", path: list + "?active_on=07-2025",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid active_on format, expected YYYY-MM-DD",
+			wantFields: map[string]string{"active_on": "invalid_format"}},
+		{name: "invalid start_from", method: "GET", path: list + "?start_from=yesterday",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid start_from format, expected YYYY-MM-DD",
+			wantFields: map[string]string{"start_from": "invalid_format"}},
+		{name: "inverted start range", method: "GET", path: list + "?start_from=2025-05-01&start_to=2025-01-01",
+			wantStatus: http.StatusBadRequest, wantError: "start_from must not be after start_to",
+			wantFields: map[string]string{"start_from": "out_of_range"}},
+		{name: "sort field not allowed", method: "GET", path: list + "?sort=id:asc",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid sort field: id",
+			wantFields: map[string]string{"sort": "invalid_value"}},
+		{name: "invalid sort direction", method: "GET", path: list + "?sort=price:up",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid sort direction, expected asc or desc",
+			wantFields: map[string]string{"sort": "invalid_value"}},
+		{name: "duplicate sort field", method: "GET", path: list + "?sort=price,price:desc",
+			wantStatus: http.StatusBadRequest, wantError: "Duplicate sort field: price",
+			wantFields: map[string]string{"sort": "invalid_value"}},
+		{name: "every invalid parameter is reported", method: "GET", path: list + "?in_trial=maybe&user_id=42&price_max=lots&sort=id",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid in_trial value; Invalid user_id format; Invalid price_max, expected a non-negative integer; Invalid sort field: id",
+			wantFields: map[string]string{"in_trial": "invalid_format", "user_id": "invalid_format", "price_max": "invalid_format", "sort": "invalid_value"}},
+	})
+
+	t.Run("sort with cursor", func(t *testing.T) {
+		router := newRouter(t, seededRepository(t))
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", list+"?limit=1", nil))
+		cursor := decode[models.SubscriptionPage](t, rec.Body.Bytes()).NextCursor
+
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", list+"?sort=price&cursor="+cursor, nil))
+		if rec.Code != http.StatusBadRequest || decodeProblem(t, rec, list).Detail != "sort cannot be used with cursor" {
+			t.Errorf("status = %d, body = %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestListSubscriptionsCursor(t *testing.T) {
+	repo := seededRepository(t)
+	router := newRouter(t, repo)
+
+	get := func(path string) (models.SubscriptionPage, string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d; body: %s", path, rec.Code, rec.Body.String())
+		}
+		return decode[models.SubscriptionPage](t, rec.Body.Bytes()), rec.Header().Get("Link")
+	}
+
+	first, _ := get("/subscriptions/view/list?limit=2")
+	assertIDs(t, first.Items, []uuid.UUID{netflixID, spotifyID})
+	if first.NextCursor == "" {
+		t.Fatal("first page has no next_cursor")
+	}
+
+	// Подписка, созданная во время листания в начале списка, не сдвигает следующую страницу
+	early := models.Subscription{ID: uuid.New(), ServiceName: "Early", Price: 100, Currency: "RUB",
+		BillingPeriod: models.BillingMonthly, UserID: userA, StartDate: date(2024, 1, 1)}
+	if err := repo.Create(context.Background(), &early, nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	second, link := get("/subscriptions/view/list?limit=2&cursor=" + first.NextCursor)
+	assertIDs(t, second.Items, []uuid.UUID{youtubeID, trialID})
+	if second.Total != 5 || second.Page != 0 || second.Limit != 2 {
+		t.Errorf("total/page/limit = %d/%d/%d, want 5/0/2", second.Total, second.Page, second.Limit)
+	}
+	if second.NextCursor != "" {
+		t.Errorf("last page next_cursor = %q, want empty", second.NextCursor)
+	}
+	if want := `</subscriptions/view/list?limit=2&page=1>; rel="first"`; link != want {
+		t.Errorf("Link = %s, want %s", link, want)
+	}
+
+	// Измененный курсор отклоняется
+	tampered := []byte(first.NextCursor)
+	tampered[0] ^= 1
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/subscriptions/view/list?cursor="+string(tampered), nil))
+	if rec.Code != http.StatusBadRequest || decodeProblem(t, rec, "/subscriptions/view/list").Detail != "Invalid cursor" {
+		t.Errorf("tampered cursor: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGetTotalCost(t *testing.T) {
+	// 15 июля 2025: Netflix 3100/31 = 100, Spotify 6200/31 = 200,
+	// YouTube 1000 центов / 31 по курсу 78.52 на 2025-07-01, Trial бесплатен
+	youtubeRUB := 1000.0 / 31 * 78.52
+
+	expectTotal := func(total float64, currency, rateDate string) func(t *testing.T, body []byte) {
+		return func(t *testing.T, body []byte) {
+			t.Helper()
+			resp := decode[map[string]interface{}](t, body)
+			got, _ := resp["total"].(float64)
+			assertClose(t, got, total)
+			if resp["currency"] != currency || resp["date"] != "2025-07-15" {
+				t.Errorf("currency/date = %v/%v, want %s/2025-07-15", resp["currency"], resp["date"], currency)
+			}
+			if got, _ := resp["rate_date"].(string); got != rateDate {
+				t.Errorf("rate_date = %q, want %q", got, rateDate)
+			}
+		}
+	}
+
+	runRouteTests(t, []routeTest{
+		{name: "all subscriptions", method: "GET", path: "/subscriptions/view/total/2025-07-15",
+			wantStatus: http.StatusOK, check: expectTotal(300+youtubeRUB, "RUB", "2025-07-01")},
+		{name: "by user without conversion", method: "GET", path: "/subscriptions/view/total/2025-07-15?user_id=" + userA.String(),
+			wantStatus: http.StatusOK, wantBody: `{"currency":"RUB","date":"2025-07-15","total":300}`},
+		{name: "by service", method: "GET", path: "/subscriptions/view/total/2025-07-15?service_name=Spotify",
+			wantStatus: http.StatusOK, wantBody: `{"currency":"RUB","date":"2025-07-15","total":200}`},
+		{name: "by user and service", method: "GET", path: "/subscriptions/view/total/2025-07-15?user_id=" + userB.String() + "&service_name=Netflix",
+			wantStatus: http.StatusOK, wantBody: `{"currency":"RUB","date":"2025-07-15","total":0}`},
+		{name: "in another currency", method: "GET", path: "/subscriptions/view/total/2025-07-15?user_id=" + userA.String() + "&currency=usd",
+			wantStatus: http.StatusOK, check: expectTotal(300/78.52, "USD", "2025-07-01")},
+		{name: "invalid date", method: "GET", path: "/subscriptions/view/total/15-07-2025",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid date format"},
+		{name: "invalid user_id", method: "GET", path: "/subscriptions/view/total/2025-07-15?user_id=42",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid user_id format",
+			wantFields: map[string]string{"user_id": "invalid_format"}},
+		{name: "every invalid parameter is reported", method: "GET", path: "/subscriptions/view/total/15-07-2025?user_id=42&currency=RU",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid date format; Invalid user_id format; Invalid currency",
+			wantFields: map[string]string{"date": "invalid_format", "user_id": "invalid_format", "currency": "invalid_value"}},
+		{name: "invalid currency", method: "GET", path: "/subscriptions/view/total/2025-07-15?currency=RU",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid currency"},
+		{name: "unsupported currency", method: "GET", path: "/subscriptions/view/total/2025-07-15?currency=XYZ",
+			wantStatus: http.StatusBadRequest, wantError: "Unsupported currency"},
+		{name: "storage failure", method: "GET", path: "/subscriptions/view/total/2025-07-15", failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Error calculating total cost"},
+	})
+}
+
+func TestGetCostReport(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "by user", method: "GET", path: "/subscriptions/view/report?from=2025-01&to=2025-02&user_id=" + userA.String(),
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				report := decode[models.CostReport](t, body)
+				if report.From != "2025-01" || report.To != "2025-02" || report.Currency != "RUB" || len(report.Months) != 2 {
+					t.Fatalf("unexpected report %+v", report)
+				}
+				assertClose(t, report.Months[0].Total, 3100)
+				assertClose(t, report.Months[1].Total, 9300)
+				assertClose(t, report.Total, 12400)
+			}},
+		{name: "invalid from", method: "GET", path: "/subscriptions/view/report?from=01-2025&to=2025-02",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid from format",
+			wantFields: map[string]string{"from": "invalid_format"}},
+		{name: "missing to", method: "GET", path: "/subscriptions/view/report?from=2025-01",
+			wantStatus: http.StatusBadRequest, wantError: "to is required",
+			wantFields: map[string]string{"to": "required"}},
+		{name: "from after to", method: "GET", path: "/subscriptions/view/report?from=2025-03&to=2025-02",
+			wantStatus: http.StatusBadRequest, wantError: "from must not be after to",
+			wantFields: map[string]string{"from": "out_of_range"}},
+		{name: "period too long", method: "GET", path: "/subscriptions/view/report?from=2000-01&to=2025-02",
+			wantStatus: http.StatusBadRequest, wantError: "Report period is too long",
+			wantFields: map[string]string{"to": "out_of_range"}},
+		{name: "invalid user_id", method: "GET", path: "/subscriptions/view/report?from=2025-01&to=2025-02&user_id=42",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid user_id format",
+			wantFields: map[string]string{"user_id": "invalid_format"}},
+		{name: "unsupported currency", method: "GET", path: "/subscriptions/view/report?from=2025-01&to=2025-02&currency=XYZ",
+			wantStatus: http.StatusBadRequest, wantError: "Unsupported currency",
+			wantFields: map[string]string{"currency": "invalid_value"}},
+		{name: "every invalid parameter is reported", method: "GET", path: "/subscriptions/view/report?from=01-2025&user_id=42&currency=RU",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid from format; to is required; Invalid user_id format; Invalid currency",
+			wantFields: map[string]string{"from": "invalid_format", "to": "required", "user_id": "invalid_format", "currency": "invalid_value"}},
+		{name: "storage failure", method: "GET", path: "/subscriptions/view/report?from=2025-01&to=2025-02", failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Error building cost report"},
+	})
+}
+
+func TestGetCostBreakdown(t *testing.T) {
+	// Те же суммы, что в TestGetTotalCost: Netflix 100, Spotify 200, YouTube в долларах
+	youtubeRUB := 1000.0 / 31 * 78.52
+	total := 300 + youtubeRUB
+
+	expectGroups := func(want ...models.CostBreakdownGroup) func(t *testing.T, body []byte) {
+		return func(t *testing.T, body []byte) {
+			t.Helper()
+			resp := decode[models.CostBreakdown](t, body)
+			assertClose(t, resp.Total, total)
+			if resp.Currency != "RUB" || resp.RateDate != "2025-07-01" {
+				t.Errorf("currency/rate_date = %s/%s, want RUB/2025-07-01", resp.Currency, resp.RateDate)
+			}
+			if len(resp.Groups) != len(want) {
+				t.Fatalf("groups = %+v, want %+v", resp.Groups, want)
+			}
+			for i := range want {
+				if resp.Groups[i].Key != want[i].Key || resp.Groups[i].Percentage != want[i].Percentage {
+					t.Errorf("group %d = %s %.2f%%, want %s %.2f%%", i, resp.Groups[i].Key, resp.Groups[i].Percentage, want[i].Key, want[i].Percentage)
+				}
+				assertClose(t, resp.Groups[i].Total, want[i].Total)
+			}
+		}
+	}
+	percent := func(v float64) float64 { return math.Round(v/total*10000) / 100 }
+
+	runRouteTests(t, []routeTest{
+		{name: "by service", method: "GET", path: "/subscriptions/view/breakdown?date=2025-07-15&group_by=service",
+			wantStatus: http.StatusOK, check: expectGroups(
+				models.CostBreakdownGroup{Key: "YouTube", Total: youtubeRUB, Percentage: percent(youtubeRUB)},
+				models.CostBreakdownGroup{Key: "Spotify", Total: 200, Percentage: percent(200)},
+				models.CostBreakdownGroup{Key: "Netflix", Total: 100, Percentage: percent(100)},
+			)},
+		{name: "by user", method: "GET", path: "/subscriptions/view/breakdown?date=2025-07-15&group_by=user",
+			wantStatus: http.StatusOK, check: expectGroups(
+				models.CostBreakdownGroup{Key: userB.String(), Total: youtubeRUB, Percentage: percent(youtubeRUB)},
+				models.CostBreakdownGroup{Key: userA.String(), Total: 300, Percentage: percent(300)},
+			)},
+		{name: "by category", method: "GET", path: "/subscriptions/view/breakdown?date=2025-07-15&group_by=category",
+			wantStatus: http.StatusOK, check: expectGroups(
+				models.CostBreakdownGroup{Key: repository.Uncategorized, Total: 200 + youtubeRUB, Percentage: percent(200 + youtubeRUB)},
+				models.CostBreakdownGroup{Key: "video", Total: 100, Percentage: percent(100)},
+			)},
+		{name: "empty", method: "GET", path: "/subscriptions/view/breakdown?date=2020-01-01&group_by=service",
+			wantStatus: http.StatusOK, wantBody: `{"date":"2020-01-01","group_by":"service","currency":"RUB","total":0,"groups":[]}`},
+		{name: "invalid date", method: "GET", path: "/subscriptions/view/breakdown?date=15-07-2025&group_by=service",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid date format",
+			wantFields: map[string]string{"date": "invalid_format"}},
+		{name: "invalid group_by", method: "GET", path: "/subscriptions/view/breakdown?date=2025-07-15&group_by=currency",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid group_by, expected service, category or user",
+			wantFields: map[string]string{"group_by": "invalid_value"}},
+		{name: "invalid user_id", method: "GET", path: "/subscriptions/view/breakdown?date=2025-07-15&group_by=user&user_id=42",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid user_id format",
+			wantFields: map[string]string{"user_id": "invalid_format"}},
+		{name: "unsupported currency", method: "GET", path: "/subscriptions/view/breakdown?date=2025-07-15&group_by=user&currency=XYZ",
+			wantStatus: http.StatusBadRequest, wantError: "Unsupported currency",
+			wantFields: map[string]string{"currency": "invalid_value"}},
+		{name: "every invalid parameter is reported", method: "GET", path: "/subscriptions/view/breakdown?user_id=42&currency=XYZ",
+			wantStatus: http.StatusBadRequest, wantError: "date is required; group_by is required; Invalid user_id format; Unsupported currency",
+			wantFields: map[string]string{"date": "required", "group_by": "required", "user_id": "invalid_format", "currency": "invalid_value"}},
+		{name: "storage failure", method: "GET", path: "/subscriptions/view/breakdown?date=2025-07-15&group_by=service", failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Error calculating cost breakdown"},
+	})
+}
+
+func TestCancelSubscription(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "immediate", method: "POST", path: "/subscriptions/" + netflixID.String() + "/cancel", body: `{"reason":"too_expensive"}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				result := decode[models.CancelSubscriptionResult](t, body)
+				if result.Subscription.EndDate == nil || result.Cancellation.Reason != models.ReasonTooExpensive {
+					t.Errorf("unexpected result %+v", result)
+				}
+			}},
+		{name: "already ended", method: "POST", path: "/subscriptions/" + spotifyID.String() + "/cancel", body: `{"reason":"other"}`,
+			wantStatus: http.StatusConflict, wantError: "Subscription has already ended"},
+		{name: "invalid effective", method: "POST", path: "/subscriptions/" + netflixID.String() + "/cancel", body: `{"effective":"tomorrow","reason":"other"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid effective, expected immediate or end_of_period"},
+		{name: "invalid reason", method: "POST", path: "/subscriptions/" + netflixID.String() + "/cancel", body: `{"reason":"bored"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid reason"},
+		{name: "invalid json", method: "POST", path: "/subscriptions/" + netflixID.String() + "/cancel", body: `reason`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid request payload"},
+		{name: "not found", method: "POST", path: "/subscriptions/" + missingID.String() + "/cancel", body: `{"reason":"other"}`,
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+		{name: "malformed id", method: "POST", path: "/subscriptions/" + malformedID + "/cancel", body: `{"reason":"other"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid subscription ID format"},
+	})
+}
+
+func TestPauseAndResume(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "pause", method: "POST", path: "/subscriptions/" + netflixID.String() + "/pause", body: `{"from":"2025-03-01","until":"2025-03-10"}`,
+			wantStatus: http.StatusOK, wantETag: `"2"`,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if len(sub.Pauses) != 1 || !sub.Pauses[0].StartDate.Equal(date(2025, 3, 1)) {
+					t.Errorf("pauses = %+v, want one starting 2025-03-01", sub.Pauses)
+				}
+			})},
+		{name: "pause before start", method: "POST", path: "/subscriptions/" + netflixID.String() + "/pause", body: `{"from":"2024-12-01"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Pause cannot start before the subscription"},
+		{name: "pause invalid from", method: "POST", path: "/subscriptions/" + netflixID.String() + "/pause", body: `{"from":"03-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid from format, expected YYYY-MM-DD"},
+		{name: "pause until before from", method: "POST", path: "/subscriptions/" + netflixID.String() + "/pause", body: `{"from":"2025-03-10","until":"2025-03-01"}`,
+			wantStatus: http.StatusBadRequest, wantError: "until must not be before from"},
+		{name: "pause not found", method: "POST", path: "/subscriptions/" + missingID.String() + "/pause",
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+		{name: "resume not paused", method: "POST", path: "/subscriptions/" + netflixID.String() + "/resume",
+			wantStatus: http.StatusConflict, wantError: "Subscription is not paused"},
+		{name: "resume invalid date", method: "POST", path: "/subscriptions/" + netflixID.String() + "/resume", body: `{"date":"tomorrow"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid date format, expected YYYY-MM-DD"},
+		{name: "resume not found", method: "POST", path: "/subscriptions/" + missingID.String() + "/resume",
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+	})
+
+	t.Run("overlapping pause and resume", func(t *testing.T) {
+		router := newRouter(t, seededRepository(t))
+		path := "/subscriptions/" + netflixID.String()
+
+		steps := []struct {
+			endpoint, body string
+			wantStatus     int
+			wantError      string
+		}{
+			{"/pause", `{"from":"2025-03-01"}`, http.StatusOK, ""},
+			{"/pause", `{"from":"2025-04-01"}`, http.StatusConflict, "Subscription is already paused in this period"},
+			{"/resume", `{"date":"2025-03-15"}`, http.StatusOK, ""},
+			{"/resume", `{"date":"2025-03-20"}`, http.StatusConflict, "Subscription is not paused"},
+		}
+		for _, step := range steps {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("POST", path+step.endpoint, strings.NewReader(step.body)))
+			if rec.Code != step.wantStatus {
+				t.Fatalf("%s %s: status = %d, want %d", step.endpoint, step.body, rec.Code, step.wantStatus)
+			}
+			if step.wantError != "" {
+				if detail := decodeProblem(t, rec, path+step.endpoint).Detail; detail != step.wantError {
+					t.Errorf("%s %s: detail = %q, want %q", step.endpoint, step.body, detail, step.wantError)
+				}
+			}
+		}
+	})
+}
+
+func TestSchedulePriceChange(t *testing.T) {
+	path := "/subscriptions/" + netflixID.String() + "/prices"
+
+	runRouteTests(t, []routeTest{
+		{name: "scheduled", method: "POST", path: path, body: `{"price":4500,"effective_from":"06-2025"}`,
+			wantStatus: http.StatusCreated, wantETag: `"2"`,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if len(sub.PriceChanges) != 1 || sub.PriceChanges[0].Price != 4500 || sub.CurrentPrice != 4500 {
+					t.Errorf("unexpected price changes %+v, current_price %d", sub.PriceChanges, sub.CurrentPrice)
+				}
+			})},
+		{name: "non-positive price", method: "POST", path: path, body: `{"price":-1,"effective_from":"06-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "price must be positive"},
+		{name: "invalid effective_from", method: "POST", path: path, body: `{"price":4500,"effective_from":"2025-06"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid effective_from format"},
+		{name: "effective_from at start", method: "POST", path: path, body: `{"price":4500,"effective_from":"01-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "effective_from must be after start_date"},
+		{name: "effective_from after end", method: "POST", path: "/subscriptions/" + spotifyID.String() + "/prices", body: `{"price":4500,"effective_from":"01-2026"}`,
+			wantStatus: http.StatusBadRequest, wantError: "effective_from must not be after end_date"},
+		{name: "not found", method: "POST", path: "/subscriptions/" + missingID.String() + "/prices", body: `{"price":4500,"effective_from":"06-2025"}`,
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+	})
+}
+
+func TestUserSubscriptions(t *testing.T) {
+	userPath := "/users/" + userA.String() + "/subscriptions"
+
+	runRouteTests(t, []routeTest{
+		{name: "list", method: "GET", path: userPath,
+			wantStatus: http.StatusOK, check: expectIDs(netflixID, spotifyID)},
+		{name: "list second page", method: "GET", path: userPath + "?page=2&limit=1",
+			wantStatus: http.StatusOK, check: expectIDs(spotifyID)},
+		{name: "list unknown user", method: "GET", path: "/users/" + missingID.String() + "/subscriptions",
+			wantStatus: http.StatusOK, wantBody: "[]"},
+		{name: "list malformed user", method: "GET", path: "/users/" + malformedID + "/subscriptions",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid user_id format"},
+		{name: "list storage failure", method: "GET", path: userPath, failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Error fetching subscriptions"},
+
+		{name: "create", method: "POST", path: userPath, body: `{"service_name":"Okko","price":39900,"start_date":"08-2025"}`,
+			wantStatus: http.StatusCreated,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.UserID != userA || sub.ServiceName != "Okko" {
+					t.Errorf("unexpected subscription %+v", sub)
+				}
+			})},
+		{name: "create with mismatched user_id", method: "POST", path: userPath,
+			body:       `{"service_name":"Okko","price":39900,"user_id":"` + userB.String() + `","start_date":"08-2025"}`,
+			wantStatus: http.StatusBadRequest, wantError: "user_id in body does not match path"},
+		{name: "create missing fields", method: "POST", path: userPath, body: `{"service_name":"Okko"}`,
+			wantStatus: http.StatusBadRequest, wantError: "start_date is required; price is required",
+			wantFields: map[string]string{"start_date": "required", "price": "required"}},
+		{name: "create malformed user", method: "POST", path: "/users/" + malformedID + "/subscriptions", body: `{}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid user_id format"},
+
+		{name: "get", method: "GET", path: userPath + "/" + netflixID.String(),
+			wantStatus: http.StatusOK, wantETag: `"1"`,
+			check: expectSubscription(func(t *testing.T, sub models.Subscription) {
+				if sub.ID != netflixID {
+					t.Errorf("id = %s, want %s", sub.ID, netflixID)
+				}
+			})},
+		{name: "get subscription of another user", method: "GET", path: userPath + "/" + youtubeID.String(),
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+		{name: "get missing", method: "GET", path: userPath + "/" + missingID.String(),
+			wantStatus: http.StatusNotFound, wantError: "Subscription not found"},
+		{name: "get malformed id", method: "GET", path: userPath + "/" + malformedID,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid subscription ID format"},
+	})
+}
+
+func TestBudget(t *testing.T) {
+	budgetPath := "/users/" + userA.String() + "/budget"
+
+	runRouteTests(t, []routeTest{
+		{name: "set with defaults", method: "PUT", path: budgetPath, body: `{"monthly_limit":500000}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				b := decode[models.Budget](t, body)
+				if b.UserID != userA || b.MonthlyLimit != 500000 || b.Currency != "RUB" {
+					t.Errorf("unexpected budget %+v", b)
+				}
+				if len(b.Thresholds) != 2 || b.Thresholds[0] != 80 || b.Thresholds[1] != 100 {
+					t.Errorf("thresholds = %v, want [80 100]", b.Thresholds)
+				}
+			}},
+		{name: "set normalizes thresholds", method: "PUT", path: budgetPath,
+			body:       `{"monthly_limit":5000,"currency":"usd","thresholds":[100,50,100]}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				b := decode[models.Budget](t, body)
+				if b.Currency != "USD" || len(b.Thresholds) != 2 || b.Thresholds[0] != 50 || b.Thresholds[1] != 100 {
+					t.Errorf("currency/thresholds = %s/%v, want USD/[50 100]", b.Currency, b.Thresholds)
+				}
+			}},
+		{name: "set non-positive limit", method: "PUT", path: budgetPath, body: `{"monthly_limit":0}`,
+			wantStatus: http.StatusBadRequest, wantError: "monthly_limit must be positive",
+			wantFields: map[string]string{"monthly_limit": "out_of_range"}},
+		{name: "set invalid threshold", method: "PUT", path: budgetPath, body: `{"monthly_limit":100,"thresholds":[0]}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid thresholds, expected percentages from 1 to 1000",
+			wantFields: map[string]string{"thresholds": "out_of_range"}},
+		{name: "set unsupported currency", method: "PUT", path: budgetPath, body: `{"monthly_limit":100,"currency":"XYZ"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Unsupported currency",
+			wantFields: map[string]string{"currency": "invalid_value"}},
+		{name: "set invalid json", method: "PUT", path: budgetPath, body: `{`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid request payload"},
+		{name: "set invalid user_id", method: "PUT", path: "/users/" + malformedID + "/budget", body: `{"monthly_limit":100}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid user_id format",
+			wantFields: map[string]string{"user_id": "invalid_format"}},
+		{name: "set reports every invalid field", method: "PUT", path: budgetPath, body: `{"monthly_limit":-1,"currency":"XYZ","thresholds":[2000]}`,
+			wantStatus: http.StatusBadRequest, wantError: "monthly_limit must be positive; Unsupported currency; Invalid thresholds, expected percentages from 1 to 1000",
+			wantFields: map[string]string{"monthly_limit": "out_of_range", "currency": "invalid_value", "thresholds": "out_of_range"}},
+		{name: "set storage failure", method: "PUT", path: budgetPath, body: `{"monthly_limit":100}`, failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Failed to set budget"},
+
+		{name: "get without budget", method: "GET", path: budgetPath,
+			wantStatus: http.StatusNotFound, wantError: "Budget not found"},
+		{name: "status without budget", method: "GET", path: budgetPath + "/status",
+			wantStatus: http.StatusNotFound, wantError: "Budget not found"},
+		{name: "delete without budget", method: "DELETE", path: budgetPath,
+			wantStatus: http.StatusNotFound, wantError: "Budget not found"},
+		{name: "get storage failure", method: "GET", path: budgetPath, failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Failed to fetch budget"},
+	})
+}
+
+func TestBudgetStatus(t *testing.T) {
+	repo := seededRepository(t)
+	router := newRouter(t, repo)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	// В текущем месяце у userA действует только Netflix за 3100 RUB
+	budgetPath := "/users/" + userA.String() + "/budget"
+	if rec := do("PUT", budgetPath, `{"monthly_limit":3500,"thresholds":[50,80,100]}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT budget: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("GET", budgetPath, ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET budget: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec := do("GET", budgetPath+"/status", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET status: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	status := decode[models.BudgetStatus](t, rec.Body.Bytes())
+	if want := time.Now().UTC().Format("2006-01"); status.Month != want || status.Limit != 3500 || status.Currency != "RUB" {
+		t.Errorf("month/limit/currency = %s/%d/%s, want %s/3500/RUB", status.Month, status.Limit, status.Currency, want)
+	}
+	assertClose(t, status.Spent, 3100)
+
+	// Расходы — это итоги GET /subscriptions/view/total, сложенные по дням месяца
+	var daily float64
+	monthStart := time.Now().UTC().AddDate(0, 0, 1-time.Now().UTC().Day())
+	for day := monthStart; day.Month() == monthStart.Month(); day = day.AddDate(0, 0, 1) {
+		rec := do("GET", "/subscriptions/view/total/"+day.Format("2006-01-02")+"?user_id="+userA.String(), "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET total for %s: status = %d, body = %s", day.Format("2006-01-02"), rec.Code, rec.Body.String())
+		}
+		daily += decode[struct {
+			Total float64 `json:"total"`
+		}](t, rec.Body.Bytes()).Total
+	}
+	assertClose(t, status.Spent, daily)
+	assertClose(t, status.Remaining, 400)
+	assertClose(t, status.PercentUsed, 88.57)
+	if len(status.ReachedThresholds) != 2 || status.ReachedThresholds[0] != 50 || status.ReachedThresholds[1] != 80 {
+		t.Errorf("reached_thresholds = %v, want [50 80]", status.ReachedThresholds)
+	}
+
+	if rec := do("DELETE", budgetPath, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE budget: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("GET", budgetPath, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET deleted budget: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestListUpcomingCharges(t *testing.T) {
+	// Netflix и YouTube списываются первого числа каждого месяца, Spotify уже закончилась,
+	// у Trial пробный период до 2099 года, поэтому за 31 день спишутся только Netflix и YouTube
+	expectCharges := func(want ...uuid.UUID) func(t *testing.T, body []byte) {
+		return func(t *testing.T, body []byte) {
+			t.Helper()
+			charges := decode[[]models.UpcomingCharge](t, body)
+			subs := make([]models.Subscription, len(charges))
+			for i := range charges {
+				subs[i] = charges[i].Subscription
+				if charges[i].ChargeDate.Day() != 1 || charges[i].Amount != charges[i].Price {
+					t.Errorf("charge %d = %v for %d", i, charges[i].ChargeDate, charges[i].Amount)
+				}
+			}
+			assertIDs(t, subs, want)
+		}
+	}
+
+	runRouteTests(t, []routeTest{
+		{name: "month ahead", method: "GET", path: "/subscriptions/upcoming?within=31d",
+			wantStatus: http.StatusOK, check: expectCharges(netflixID, youtubeID)},
+		{name: "go duration", method: "GET", path: "/subscriptions/upcoming?within=744h",
+			wantStatus: http.StatusOK, check: expectCharges(netflixID, youtubeID)},
+		{name: "scoped to user", method: "GET", path: "/subscriptions/upcoming?within=31d&user_id=" + userB.String(),
+			wantStatus: http.StatusOK, check: expectCharges(youtubeID)},
+		{name: "invalid within", method: "GET", path: "/subscriptions/upcoming?within=week",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid within, expected a duration like 7d",
+			wantFields: map[string]string{"within": "invalid_format"}},
+		{name: "negative within", method: "GET", path: "/subscriptions/upcoming?within=-1d",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid within, expected a duration like 7d",
+			wantFields: map[string]string{"within": "invalid_format"}},
+		{name: "within too long", method: "GET", path: "/subscriptions/upcoming?within=367d",
+			wantStatus: http.StatusBadRequest, wantError: "within must not exceed 366d",
+			wantFields: map[string]string{"within": "out_of_range"}},
+		{name: "invalid user_id", method: "GET", path: "/subscriptions/upcoming?user_id=42",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid user_id format",
+			wantFields: map[string]string{"user_id": "invalid_format"}},
+		{name: "every invalid parameter is reported", method: "GET", path: "/subscriptions/upcoming?within=367d&user_id=42",
+			wantStatus: http.StatusBadRequest, wantError: "within must not exceed 366d; Invalid user_id format",
+			wantFields: map[string]string{"within": "out_of_range", "user_id": "invalid_format"}},
+		{name: "storage failure", method: "GET", path: "/subscriptions/upcoming", failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Error fetching upcoming charges"},
+	})
+}
+
+func TestSearchSubscriptions(t *testing.T) {
+	expectResults := func(want ...uuid.UUID) func(t *testing.T, body []byte) {
+		return func(t *testing.T, body []byte) {
+			t.Helper()
+			results := decode[[]models.SearchResult](t, body)
+			subs := make([]models.Subscription, len(results))
+			for i := range results {
+				subs[i] = results[i].Subscription
+				if results[i].Relevance <= 0 || results[i].Relevance > 1 {
+					t.Errorf("relevance = %v, want (0, 1]", results[i].Relevance)
+				}
+			}
+			assertIDs(t, subs, want)
+		}
+	}
+
+	runRouteTests(t, []routeTest{
+		{name: "case insensitive", method: "GET", path: "/subscriptions/search?q=NETFLIX",
+			wantStatus: http.StatusOK, check: expectResults(netflixID)},
+		{name: "typo", method: "GET", path: "/subscriptions/search?q=spotfy",
+			wantStatus: http.StatusOK, check: expectResults(spotifyID)},
+		{name: "scoped to user", method: "GET", path: "/subscriptions/search?q=netflix&user_id=" + userB.String(),
+			wantStatus: http.StatusOK, wantBody: "[]"},
+		{name: "missing q", method: "GET", path: "/subscriptions/search?q=%20",
+			wantStatus: http.StatusBadRequest, wantError: "q is required",
+			wantFields: map[string]string{"q": "required"}},
+		{name: "q too long", method: "GET", path: "/subscriptions/search?q=" + strings.Repeat("a", 101),
+			wantStatus: http.StatusBadRequest, wantError: "q is too long",
+			wantFields: map[string]string{"q": "out_of_range"}},
+		{name: "invalid user_id", method: "GET", path: "/subscriptions/search?q=netflix&user_id=42",
+			wantStatus: http.StatusBadRequest, wantError: "Invalid user_id format",
+			wantFields: map[string]string{"user_id": "invalid_format"}},
+		{name: "every invalid parameter is reported", method: "GET", path: "/subscriptions/search?user_id=42",
+			wantStatus: http.StatusBadRequest, wantError: "q is required; Invalid user_id format",
+			wantFields: map[string]string{"q": "required", "user_id": "invalid_format"}},
+		{name: "storage failure", method: "GET", path: "/subscriptions/search?q=netflix", failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Error searching subscriptions"},
+	})
+}
+
+func TestServices(t *testing.T) {
+	servicePath := "/services/" + netflixServiceID.String()
+
+	runRouteTests(t, []routeTest{
+		{name: "create", method: "POST", path: "/services",
+			body:       `{"name":" Kinopoisk ","aliases":["KP","kinopoisk","kp"],"category":"video","website":"https://kinopoisk.ru"}`,
+			wantStatus: http.StatusCreated,
+			check: func(t *testing.T, body []byte) {
+				svc := decode[models.Service](t, body)
+				if svc.Name != "Kinopoisk" || len(svc.Aliases) != 1 || svc.Aliases[0] != "kp" {
+					t.Errorf("name/aliases = %s/%v, want Kinopoisk/[kp]", svc.Name, svc.Aliases)
+				}
+				if svc.Currency != "RUB" || svc.BillingPeriod != models.BillingMonthly {
+					t.Errorf("defaults not applied: %+v", svc)
+				}
+			}},
+		{name: "create name taken by alias", method: "POST", path: "/services", body: `{"name":"NFLX"}`,
+			wantStatus: http.StatusConflict, wantError: "Service name or alias is already taken"},
+		{name: "create without name", method: "POST", path: "/services", body: `{"aliases":["x"]}`,
+			wantStatus: http.StatusBadRequest, wantError: "name is required"},
+		{name: "create invalid website", method: "POST", path: "/services", body: `{"name":"X","website":"ftp://x"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid website, expected an http(s) URL"},
+		{name: "create negative price", method: "POST", path: "/services", body: `{"name":"X","default_price":-1}`,
+			wantStatus: http.StatusBadRequest, wantError: "default_price must not be negative"},
+		{name: "create currency without a rate", method: "POST", path: "/services", body: `{"name":"X","currency":"XYZ"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Unsupported currency"},
+		{name: "create storage failure", method: "POST", path: "/services", body: `{"name":"X"}`, failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Failed to create service"},
+
+		{name: "list by category", method: "GET", path: "/services?category=video",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				if services := decode[[]models.Service](t, body); len(services) != 1 || services[0].ID != netflixServiceID {
+					t.Errorf("services = %+v, want only Netflix", services)
+				}
+			}},
+		{name: "list other category", method: "GET", path: "/services?category=music",
+			wantStatus: http.StatusOK, wantBody: "[]"},
+		{name: "list storage failure", method: "GET", path: "/services", failing: true,
+			wantStatus: http.StatusInternalServerError, wantError: "Error fetching services"},
+
+		{name: "get", method: "GET", path: servicePath, wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				if svc := decode[models.Service](t, body); svc.Name != "Netflix" || svc.DefaultPrice == nil || *svc.DefaultPrice != 3100 {
+					t.Errorf("unexpected service %+v", svc)
+				}
+			}},
+		{name: "get not found", method: "GET", path: "/services/" + missingID.String(),
+			wantStatus: http.StatusNotFound, wantError: "Service not found"},
+		{name: "get malformed id", method: "GET", path: "/services/" + malformedID,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid service ID format"},
+
+		{name: "update", method: "PUT", path: servicePath, body: `{"name":"Netflix","aliases":["nf"],"currency":"usd"}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				if svc := decode[models.Service](t, body); svc.Currency != "USD" || len(svc.Aliases) != 1 || svc.Aliases[0] != "nf" {
+					t.Errorf("unexpected service %+v", svc)
+				}
+			}},
+		{name: "update not found", method: "PUT", path: "/services/" + missingID.String(), body: `{"name":"X"}`,
+			wantStatus: http.StatusNotFound, wantError: "Service not found"},
+		{name: "update invalid billing_period", method: "PUT", path: servicePath, body: `{"name":"Netflix","billing_period":"daily"}`,
+			wantStatus: http.StatusBadRequest, wantError: "Invalid billing_period"},
+
+		{name: "delete", method: "DELETE", path: servicePath, wantStatus: http.StatusNoContent},
+		{name: "delete not found", method: "DELETE", path: "/services/" + missingID.String(),
+			wantStatus: http.StatusNotFound, wantError: "Service not found"},
+	})
+}
+
+func TestSwaggerRoute(t *testing.T) {
+	runRouteTests(t, []routeTest{
+		{name: "index", method: "GET", path: "/subscriptions/swagger/index.html", wantStatus: http.StatusOK},
+	})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/EvgenyiK/subscription-service/internal/requestid"
+	"github.com/gorilla/mux"
+)
+
+// anonymousActor записывается в журнал, если запрос пришел без X-Actor
+const anonymousActor = "anonymous"
+
+// GetSubscriptionHistory godoc
+// @Summary Журнал изменений подписки
+// @Description Возвращает записи о создании, изменениях, отмене, удалении и восстановлении подписки в порядке времени.
+// @Description Каждая запись содержит автора (X-Actor), ID запроса (X-Request-ID) и снимки подписки до и после изменения.
+// @Description Журнал доступен и после удаления подписки.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки (UUID)"
+// @Param page query int false "Номер страницы (с 1)"
+// @Param limit query int false "Размер страницы, не больше 100"
+// @Success 200 {object} models.SubscriptionEventPage
+// @Header 200 {string} Link "Ссылки first, prev, next, last"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/history [get]
+func (h *Handler) GetSubscriptionHistory(w http.ResponseWriter, r *http.Request) {
+	subUUID, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
+		return
+	}
+
+	page, limit := parsePagination(r)
+
+	total, err := h.repo.CountEvents(r.Context(), subUUID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error fetching subscription history")
+		return
+	}
+	// Пустой журнал бывает только у подписок, созданных в обход API
+	if total == 0 {
+		if _, ok := h.getSubscription(w, r, subUUID); !ok {
+			return
+		}
+	}
+
+	events, err := h.repo.ListEvents(r.Context(), subUUID, limit, (page-1)*limit)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error fetching subscription history")
+		return
+	}
+
+	setPaginationLinks(w, r, page, limit, total)
+	respondWithJSON(w, http.StatusOK, models.SubscriptionEventPage{
+		Items: events,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// change описывает изменение подписки для журнала аудита.
+// Репозиторий записывает его вместе с изменением, поэтому при ошибке журнала
+// изменение не сохраняется и запрос завершается ошибкой.
+func change(r *http.Request, before, after *models.Subscription) *repository.Change {
+	return &repository.Change{
+		Actor:     actor(r),
+		RequestID: requestid.FromContext(r.Context()),
+		Before:    before,
+		After:     after,
+	}
+}
+
+// actor возвращает автора изменения из заголовка X-Actor
+func actor(r *http.Request) string {
+	if a := strings.TrimSpace(r.Header.Get("X-Actor")); a != "" {
+		return a
+	}
+	return anonymousActor
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// parseListFilter читает фильтры и сортировку списка подписок из query-параметров.
+// Ошибки параметров собираются в v.
+func parseListFilter(v *validator, query url.Values) repository.ListFilter {
+	filter := repository.ListFilter{Today: today()}
+
+	filter.InTrial = parseOptionalBool(v, query, "in_trial")
+	if userID := v.id("user_id", query.Get("user_id")); userID != uuid.Nil {
+		filter.UserID = &userID
+	}
+
+	filter.ServiceName = query.Get("service_name")
+	filter.ServiceNamePrefix = query.Get("service_name_prefix")
+
+	filter.MinPrice = parseOptionalPrice(v, query, "price_min")
+	filter.MaxPrice = parseOptionalPrice(v, query, "price_max")
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		v.add("price_min", codeOutOfRange, "price_min must not be greater than price_max")
+	}
+
+	filter.ActiveOn = parseOptionalDay(v, query, "active_on")
+	filter.StartFrom = parseOptionalDay(v, query, "start_from")
+	filter.StartTo = parseOptionalDay(v, query, "start_to")
+	if filter.StartFrom != nil && filter.StartTo != nil && filter.StartFrom.After(*filter.StartTo) {
+		v.add("start_from", codeOutOfRange, "start_from must not be after start_to")
+	}
+
+	if includeDeleted := parseOptionalBool(v, query, "include_deleted"); includeDeleted != nil {
+		filter.IncludeDeleted = *includeDeleted
+	}
+
+	if sortStr := query.Get("sort"); sortStr != "" {
+		sort, err := parseSort(sortStr)
+		if err != nil {
+			v.add("sort", codeInvalidValue, err.Error())
+		}
+		filter.Sort = sort
+	}
+
+	return filter
+}
+
+// parseSort разбирает сортировку вида "price:desc,service_name:asc".
+// Направление можно не указывать, по умолчанию asc.
+func parseSort(sortStr string) ([]repository.SortField, error) {
+	var fields []repository.SortField
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(sortStr, ",") {
+		name, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if !repository.SortableField(name) {
+			return nil, errors.New("Invalid sort field: " + name)
+		}
+		if seen[name] {
+			return nil, errors.New("Duplicate sort field: " + name)
+		}
+		seen[name] = true
+
+		field := repository.SortField{Field: name}
+		switch direction {
+		case "", "asc":
+		case "desc":
+			field.Desc = true
+		default:
+			return nil, errors.New("Invalid sort direction, expected asc or desc")
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func parseOptionalBool(v *validator, query url.Values, key string) *bool {
+	value := query.Get(key)
+	if value == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		v.add(key, codeInvalidFormat, "Invalid "+key+" value")
+		return nil
+	}
+	return &b
+}
+
+func parseOptionalPrice(v *validator, query url.Values, key string) *int {
+	value := query.Get(key)
+	if value == "" {
+		return nil
+	}
+	price, err := strconv.Atoi(value)
+	if err != nil {
+		v.add(key, codeInvalidFormat, "Invalid "+key+", expected a non-negative integer")
+		return nil
+	}
+	if price < 0 {
+		v.add(key, codeOutOfRange, "Invalid "+key+", expected a non-negative integer")
+		return nil
+	}
+	return &price
+}
+
+func parseOptionalDay(v *validator, query url.Values, key string) *time.Time {
+	value := query.Get(key)
+	if value == "" {
+		return nil
+	}
+	day, err := time.Parse(dateFormatDay, value)
+	if err != nil {
+		v.add(key, codeInvalidFormat, "Invalid "+key+" format, expected YYYY-MM-DD")
+		return nil
+	}
+	return &day
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageLimit = 10
+
+	// maxPageLimit ограничивает размер страницы, большие значения урезаются до него
+	maxPageLimit = 100
+)
+
+// parsePagination читает page и limit из query-параметров
+func parsePagination(r *http.Request) (page, limit int) {
+	page = 1
+	limit = defaultPageLimit
+
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return page, limit
+}
+
+// lastPage возвращает номер последней страницы; пустой список состоит из одной страницы
+func lastPage(total, limit int) int {
+	if total <= 0 {
+		return 1
+	}
+	return (total + limit - 1) / limit
+}
+
+// setPaginationLinks добавляет заголовок Link (RFC 8288) со ссылками first, prev, next и last.
+// Ссылки повторяют исходный запрос, меняются только page и limit.
+func setPaginationLinks(w http.ResponseWriter, r *http.Request, page, limit, total int) {
+	last := lastPage(total, limit)
+
+	links := []string{pageLink(r, "first", 1, limit)}
+	if page > 1 {
+		// Со страницы за пределами списка prev ведет на последнюю существующую
+		prev := page - 1
+		if prev > last {
+			prev = last
+		}
+		links = append(links, pageLink(r, "prev", prev, limit))
+	}
+	if page < last {
+		links = append(links, pageLink(r, "next", page+1, limit))
+	}
+	links = append(links, pageLink(r, "last", last, limit))
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// setCursorLinks добавляет заголовок Link для выборки по курсору: first и, если есть
+// следующая страница, next. Страницы prev и last при такой выборке не вычисляются.
+func setCursorLinks(w http.ResponseWriter, r *http.Request, limit int, nextCursor string) {
+	links := []string{pageLink(r, "first", 1, limit)}
+	if nextCursor != "" {
+		links = append(links, link(r, "next", map[string]string{
+			"cursor": nextCursor,
+			"limit":  strconv.Itoa(limit),
+		}))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+func pageLink(r *http.Request, rel string, page, limit int) string {
+	return link(r, rel, map[string]string{
+		"page":  strconv.Itoa(page),
+		"limit": strconv.Itoa(limit),
+	})
+}
+
+// link формирует ссылку на исходный запрос с заменой параметров.
+// Параметры page и cursor взаимоисключающие, поэтому не заданный из них удаляется.
+func link(r *http.Request, rel string, params map[string]string) string {
+	u := *r.URL
+	query := u.Query()
+	query.Del("page")
+	query.Del("cursor")
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	u.RawQuery = query.Encode()
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.RequestURI(), rel)
+}
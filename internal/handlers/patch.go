@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/gorilla/mux"
+)
+
+// mergePatchContentType — тип тела запроса JSON Merge Patch (RFC 7396)
+const mergePatchContentType = "application/merge-patch+json"
+
+// PatchSubscription godoc
+// @Summary Частично обновить подписку
+// @Description Применяет к подписке документ JSON Merge Patch (RFC 7396): переданные поля заменяются, null удаляет значение, остальные поля не меняются.
+// @Description Поля те же, что при создании подписки; результат проверяется по тем же правилам. Сохраняются только изменившиеся колонки.
+// @Description Заголовок If-Match должен содержать ETag текущей версии подписки (или *).
+// @Tags subscriptions
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "ID подписки (UUID)"
+// @Param If-Match header string true "ETag подписки из GET /subscriptions/{id}"
+// @Param patch body models.CreateSubscriptionInput true "Изменяемые поля подписки"
+// @Success 200 {object} models.Subscription
+// @Header 200 {string} ETag "Новая версия подписки"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 412 {object} models.Subscription "Подписку изменили, в ответе актуальная версия"
+// @Failure 415 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id} [patch]
+func (h *Handler) PatchSubscription(w http.ResponseWriter, r *http.Request) {
+	subUUID, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
+		return
+	}
+
+	if !isMergePatch(r) {
+		respondWithError(w, r, http.StatusUnsupportedMediaType, "Content-Type must be "+mergePatchContentType)
+		return
+	}
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid merge patch, expected a JSON object")
+		return
+	}
+
+	current, ok := h.getSubscription(w, r, subUUID)
+	if !ok {
+		return
+	}
+	if !checkIfMatch(w, r, current) {
+		return
+	}
+
+	// Патч накладывается на подписку в формате тела CreateSubscription
+	merged, err := json.Marshal(mergePatch(patchDocument(current), patch))
+	if err != nil {
+		log.Println("Failed to encode merged subscription:", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to update subscription")
+		return
+	}
+	var input createSubscriptionInput
+	if err := json.Unmarshal(merged, &input); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	sub, ok := h.subscriptionFromInput(w, r, input)
+	if !ok {
+		return
+	}
+
+	// Формат месяца теряет день, поэтому непереданные даты остаются прежними
+	if _, ok := patch["start_date"]; !ok {
+		sub.StartDate = current.StartDate
+	}
+	if _, ok := patch["end_date"]; !ok {
+		sub.EndDate = current.EndDate
+	}
+
+	updated := *current
+	updated.ServiceName = sub.ServiceName
+	updated.ServiceID = sub.ServiceID
+	updated.Price = sub.Price
+	updated.Currency = sub.Currency
+	updated.BillingPeriod = sub.BillingPeriod
+	updated.UserID = sub.UserID
+	updated.StartDate = sub.StartDate
+	updated.EndDate = sub.EndDate
+	updated.TrialEnd = sub.TrialEnd
+
+	updated.RefreshDerived()
+
+	// Патч без изменений не попадает в журнал
+	columns := repository.ChangedColumns(current, &updated)
+	if len(columns) > 0 {
+		if err := h.repo.Update(r.Context(), &updated, change(r, current, &updated), columns...); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				respondWithError(w, r, http.StatusNotFound, "Subscription not found")
+			case errors.Is(err, repository.ErrVersionConflict):
+				h.respondStale(w, r, subUUID)
+			default:
+				respondWithError(w, r, http.StatusInternalServerError, "Failed to update subscription")
+			}
+			return
+		}
+	}
+
+	respondWithSubscription(w, http.StatusOK, &updated)
+}
+
+// isMergePatch сообщает, передано ли тело как merge patch или обычный JSON
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == mergePatchContentType || mediaType == "application/json"
+}
+
+// patchDocument представляет подписку в формате тела запроса на создание.
+// service_id не включается: связь с каталогом заново определяется по service_name.
+func patchDocument(sub *models.Subscription) map[string]interface{} {
+	doc := map[string]interface{}{
+		"service_name":   sub.ServiceName,
+		"price":          sub.Price,
+		"currency":       sub.Currency,
+		"billing_period": string(sub.BillingPeriod),
+		"user_id":        sub.UserID.String(),
+		"start_date":     sub.StartDate.Format(dateFormatStart),
+	}
+	if sub.EndDate != nil {
+		doc["end_date"] = sub.EndDate.Format(dateFormatStart)
+	}
+	if sub.TrialEnd != nil {
+		doc["trial_end"] = sub.TrialEnd.Format(dateFormatDay)
+	}
+	return doc
+}
+
+// mergePatch применяет patch к target по правилам RFC 7396
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const dateFormatDay = "2006-01-02"
+
+// PauseSubscription godoc
+// @Summary Приостановить подписку
+// @Description Замораживает подписку с даты from (по умолчанию сегодня) до until включительно или до возобновления.
+// @Description Дни паузы не учитываются в стоимости.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки (UUID)"
+// @Param pause body models.PauseSubscriptionInput false "Интервал паузы"
+// @Success 200 {object} models.Subscription
+// @Header 200 {string} ETag "Новая версия подписки"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/pause [post]
+func (h *Handler) PauseSubscription(w http.ResponseWriter, r *http.Request) {
+	subUUID, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
+		return
+	}
+
+	var input models.PauseSubscriptionInput
+	if err := decodeOptionalBody(r, &input); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	pause := models.Pause{
+		ID:             uuid.New(),
+		SubscriptionID: subUUID,
+		StartDate:      today(),
+	}
+	if input.From != "" {
+		from, err := time.Parse(dateFormatDay, input.From)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid from format, expected YYYY-MM-DD")
+			return
+		}
+		pause.StartDate = from
+	}
+	if input.Until != "" {
+		until, err := time.Parse(dateFormatDay, input.Until)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid until format, expected YYYY-MM-DD")
+			return
+		}
+		if until.Before(pause.StartDate) {
+			respondWithError(w, r, http.StatusBadRequest, "until must not be before from")
+			return
+		}
+		pause.EndDate = &until
+	}
+
+	subscription, ok := h.getSubscription(w, r, subUUID)
+	if !ok {
+		return
+	}
+	if pause.StartDate.Before(subscription.StartDate) {
+		respondWithError(w, r, http.StatusBadRequest, "Pause cannot start before the subscription")
+		return
+	}
+
+	if err := h.repo.Pause(r.Context(), &pause); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			respondWithError(w, r, http.StatusNotFound, "Subscription not found")
+		case errors.Is(err, repository.ErrAlreadyEnded):
+			respondWithError(w, r, http.StatusConflict, "Subscription has already ended")
+		case errors.Is(err, repository.ErrAlreadyPaused):
+			respondWithError(w, r, http.StatusConflict, "Subscription is already paused in this period")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to pause subscription")
+		}
+		return
+	}
+
+	h.respondWithFreshSubscription(w, r, subUUID)
+}
+
+// ResumeSubscription godoc
+// @Summary Возобновить подписку
+// @Description Завершает текущую или запланированную паузу: подписка снова оплачивается с даты date (по умолчанию сегодня).
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки (UUID)"
+// @Param resume body models.ResumeSubscriptionInput false "Дата возобновления"
+// @Success 200 {object} models.Subscription
+// @Header 200 {string} ETag "Новая версия подписки"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/resume [post]
+func (h *Handler) ResumeSubscription(w http.ResponseWriter, r *http.Request) {
+	subUUID, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
+		return
+	}
+
+	var input models.ResumeSubscriptionInput
+	if err := decodeOptionalBody(r, &input); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	date := today()
+	if input.Date != "" {
+		date, err = time.Parse(dateFormatDay, input.Date)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid date format, expected YYYY-MM-DD")
+			return
+		}
+	}
+
+	if err := h.repo.Resume(r.Context(), subUUID, date); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			respondWithError(w, r, http.StatusNotFound, "Subscription not found")
+		case errors.Is(err, repository.ErrNotPaused):
+			respondWithError(w, r, http.StatusConflict, "Subscription is not paused")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to resume subscription")
+		}
+		return
+	}
+
+	h.respondWithFreshSubscription(w, r, subUUID)
+}
+
+// respondWithFreshSubscription перечитывает подписку после изменения и отдает её клиенту
+func (h *Handler) respondWithFreshSubscription(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	subscription, ok := h.getSubscription(w, r, id)
+	if !ok {
+		return
+	}
+	respondWithSubscription(w, http.StatusOK, subscription)
+}
+
+// decodeOptionalBody разбирает JSON-тело, допуская пустой запрос
+func decodeOptionalBody(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// today возвращает текущую дату в UTC без времени
+func today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// SchedulePriceChange godoc
+// @Summary Изменить цену подписки с указанного месяца
+// @Description Сохраняет новую цену, действующую с первого дня месяца effective_from.
+// @Description Стоимость за прошлые периоды считается по ценам, действовавшим в те дни.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки (UUID)"
+// @Param price body models.SchedulePriceChangeInput true "Новая цена"
+// @Success 201 {object} models.Subscription
+// @Header 201 {string} ETag "Новая версия подписки"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/prices [post]
+func (h *Handler) SchedulePriceChange(w http.ResponseWriter, r *http.Request) {
+	subUUID, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
+		return
+	}
+
+	var input models.SchedulePriceChangeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if input.Price <= 0 {
+		respondWithError(w, r, http.StatusBadRequest, "price must be positive")
+		return
+	}
+
+	effectiveFrom, err := parseDate(dateFormatStart, input.EffectiveFrom)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid effective_from format")
+		return
+	}
+
+	subscription, ok := h.getSubscription(w, r, subUUID)
+	if !ok {
+		return
+	}
+	if !effectiveFrom.After(subscription.StartDate) {
+		respondWithError(w, r, http.StatusBadRequest, "effective_from must be after start_date")
+		return
+	}
+	if subscription.EndDate != nil && effectiveFrom.After(*subscription.EndDate) {
+		respondWithError(w, r, http.StatusBadRequest, "effective_from must not be after end_date")
+		return
+	}
+
+	change := models.PriceChange{
+		ID:             uuid.New(),
+		SubscriptionID: subUUID,
+		Price:          input.Price,
+		EffectiveFrom:  *effectiveFrom,
+	}
+	if err := h.repo.SchedulePriceChange(r.Context(), &change); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondWithError(w, r, http.StatusNotFound, "Subscription not found")
+		} else {
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to schedule price change")
+		}
+		return
+	}
+
+	subscription, ok = h.getSubscription(w, r, subUUID)
+	if !ok {
+		return
+	}
+	respondWithSubscription(w, http.StatusCreated, subscription)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+)
+
+// problemContentType — тип тела ответа об ошибке (RFC 7807)
+const problemContentType = "application/problem+json"
+
+// problemTypeDefault означает, что смысл ошибки полностью передает HTTP-статус
+const problemTypeDefault = "about:blank"
+
+// respondWithProblem отправляет описание ошибки в формате RFC 7807.
+// instance — путь запроса, на который получена ошибка.
+func respondWithProblem(w http.ResponseWriter, r *http.Request, status int, detail string, fieldErrors []models.FieldError) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.Problem{
+		Type:     problemTypeDefault,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Errors:   fieldErrors,
+	})
+}
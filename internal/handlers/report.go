@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/rates"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	dateFormatMonth = "2006-01"
+
+	// maxReportMonths ограничивает длину периода отчета
+	maxReportMonths = 120
+)
+
+// GetCostReport godoc
+// @Summary Помесячный отчет о стоимости подписок
+// @Description Возвращает стоимость подписок по месяцам периода [from, to] и общий итог в минорных единицах валюты.
+// @Description Для каждого месяца перечислены подписки, которые в него вошли.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param from query string true "Первый месяц периода в формате YYYY-MM"
+// @Param to query string true "Последний месяц периода в формате YYYY-MM"
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса"
+// @Param currency query string false "Валюта отчета (ISO 4217), по умолчанию RUB"
+// @Success 200 {object} models.CostReport
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/view/report [get]
+func (h *Handler) GetCostReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var v validator
+	from := v.date("from", dateFormatMonth, query.Get("from"))
+	if from == nil {
+		v.add("from", codeRequired, "from is required")
+	}
+	to := v.date("to", dateFormatMonth, query.Get("to"))
+	if to == nil {
+		v.add("to", codeRequired, "to is required")
+	}
+	if from != nil && to != nil {
+		if to.Before(*from) {
+			v.add("from", codeOutOfRange, "from must not be after to")
+		} else if monthsBetween(*from, *to) > maxReportMonths {
+			v.add("to", codeOutOfRange, "Report period is too long")
+		}
+	}
+
+	var filter repository.CostFilter
+	if userUUID := v.id("user_id", query.Get("user_id")); userUUID != uuid.Nil {
+		filter.UserID = &userUUID
+	}
+	filter.ServiceName = query.Get("service_name")
+
+	currency := query.Get("currency")
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	currency = h.currency(r.Context(), &v, "currency", currency)
+
+	if !v.valid() {
+		v.respond(w, r)
+		return
+	}
+
+	// Период включает последний месяц целиком
+	periodEnd := to.AddDate(0, 1, -1)
+
+	months, err := h.repo.GetCostReport(r.Context(), *from, periodEnd, filter)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error building cost report")
+		return
+	}
+
+	report := models.CostReport{
+		From:     from.Format(dateFormatMonth),
+		To:       to.Format(dateFormatMonth),
+		Currency: currency,
+		Months:   months,
+	}
+
+	// Пересчитываем каждый месяц по курсу на его последний день
+	for i := range report.Months {
+		month := &report.Months[i]
+		monthStart, _ := time.Parse(dateFormatMonth, month.Month)
+		rateOn := monthStart.AddDate(0, 1, -1)
+
+		var rateDate *time.Time
+		for j := range month.Subscriptions {
+			item := &month.Subscriptions[j]
+			cost, publishedAt, err := h.convert(r.Context(), item.Cost, item.Currency, currency, rateOn)
+			if err != nil {
+				if errors.Is(err, rates.ErrUnknownCurrency) {
+					respondWithError(w, r, http.StatusBadRequest, "Unsupported currency")
+				} else {
+					respondWithError(w, r, http.StatusInternalServerError, "Error converting currency")
+				}
+				return
+			}
+			item.Cost = cost
+			month.Total += cost
+			rateDate = laterDate(rateDate, publishedAt)
+		}
+		if rateDate != nil {
+			month.RateDate = rateDate.Format("2006-01-02")
+		}
+		report.Total += month.Total
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// monthsBetween возвращает число месяцев в периоде [from, to] включительно
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month()) + 1
+}
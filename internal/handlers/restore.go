@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/gorilla/mux"
+)
+
+// RestoreSubscription godoc
+// @Summary Восстановить удаленную подписку
+// @Description Возвращает подписку, удаленную через DELETE /subscriptions/{id}, пока она не очищена по сроку хранения.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки (UUID)"
+// @Success 200 {object} models.Subscription
+// @Header 200 {string} ETag "Новая версия подписки"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/restore [post]
+func (h *Handler) RestoreSubscription(w http.ResponseWriter, r *http.Request) {
+	subUUID, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid subscription ID format")
+		return
+	}
+
+	// Снимок удаленной подписки попадает в журнал как состояние до восстановления
+	deleted, err := h.repo.GetDeleted(r.Context(), subUUID)
+	if err != nil {
+		respondRestoreError(w, r, err)
+		return
+	}
+	restored := *deleted
+	restored.DeletedAt = nil
+	restored.Version++
+	restored.RefreshDerived()
+
+	if err := h.repo.Restore(r.Context(), subUUID, deleted.Version, change(r, deleted, &restored)); err != nil {
+		respondRestoreError(w, r, err)
+		return
+	}
+
+	subscription, ok := h.getSubscription(w, r, subUUID)
+	if !ok {
+		return
+	}
+	respondWithSubscription(w, http.StatusOK, subscription)
+}
+
+// respondRestoreError отвечает на ошибку чтения или восстановления удаленной подписки
+func respondRestoreError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondWithError(w, r, http.StatusNotFound, "Subscription not found")
+	case errors.Is(err, repository.ErrNotDeleted):
+		respondWithError(w, r, http.StatusConflict, "Subscription is not deleted")
+	case errors.Is(err, repository.ErrVersionConflict):
+		respondWithError(w, r, http.StatusConflict, "Subscription was changed during restore")
+	default:
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to restore subscription")
+	}
+}
+
+// isAdmin сообщает, передан ли в запросе токен администратора
+func (h *Handler) isAdmin(r *http.Request) bool {
+	if h.adminToken == "" {
+		return false
+	}
+	token := r.Header.Get("X-Admin-Token")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1
+}
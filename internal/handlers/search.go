@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// maxSearchQueryLength ограничивает длину поискового запроса в символах
+const maxSearchQueryLength = 100
+
+// SearchSubscriptions godoc
+// @Summary Найти подписки по названию сервиса
+// @Description Нечеткий поиск без учета регистра: запрос "netflix" находит "Netflix", "NETFLIX" и "Netflix Premium".
+// @Description Результаты упорядочены по убыванию релевантности.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param q query string true "Поисковый запрос"
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param limit query int false "Максимум результатов, не больше 100"
+// @Success 200 {array} models.SearchResult
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/search [get]
+func (h *Handler) SearchSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var v validator
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		v.add("q", codeRequired, "q is required")
+	} else if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		v.add("q", codeOutOfRange, "q is too long")
+	}
+
+	var userID *uuid.UUID
+	if userUUID := v.id("user_id", r.URL.Query().Get("user_id")); userUUID != uuid.Nil {
+		userID = &userUUID
+	}
+
+	if !v.valid() {
+		v.respond(w, r)
+		return
+	}
+
+	_, limit := parsePagination(r)
+
+	results, err := h.repo.SearchSubscriptions(r.Context(), query, userID, limit)
+	if err != nil {
+		log.Println("Failed to search subscriptions:", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error searching subscriptions")
+		return
+	}
+
+	for i := range results {
+		results[i].RefreshDerived()
+	}
+
+	respondWithJSON(w, http.StatusOK, results)
+}
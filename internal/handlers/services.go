@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/rates"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CreateService godoc
+// @Summary Добавить сервис в каталог
+// @Description Создает запись каталога. Название и псевдонимы сравниваются без учета регистра и не должны совпадать с другими записями.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param service body models.ServiceInput true "Данные сервиса"
+// @Success 201 {object} models.Service
+// @Failure 400 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /services [post]
+func (h *Handler) CreateService(w http.ResponseWriter, r *http.Request) {
+	var input models.ServiceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	svc, err := h.serviceFromInput(r.Context(), input)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	svc.ID = uuid.New()
+
+	if err := h.repo.CreateService(r.Context(), svc); err != nil {
+		if errors.Is(err, repository.ErrServiceConflict) {
+			respondWithError(w, r, http.StatusConflict, "Service name or alias is already taken")
+		} else {
+			log.Println("Failed to create service:", err)
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to create service")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, svc)
+}
+
+// ListServices godoc
+// @Summary Список сервисов каталога
+// @Description Возвращает записи каталога по алфавиту.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param category query string false "Категория"
+// @Success 200 {array} models.Service
+// @Failure 500 {object} models.Problem
+// @Router /services [get]
+func (h *Handler) ListServices(w http.ResponseWriter, r *http.Request) {
+	services, err := h.repo.ListServices(r.Context(), r.URL.Query().Get("category"))
+	if err != nil {
+		log.Println("Failed to list services:", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error fetching services")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, services)
+}
+
+// GetService godoc
+// @Summary Вернуть сервис каталога
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса (UUID)"
+// @Success 200 {object} models.Service
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /services/{id} [get]
+func (h *Handler) GetService(w http.ResponseWriter, r *http.Request) {
+	serviceUUID, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid service ID format")
+		return
+	}
+
+	svc, err := h.repo.GetService(r.Context(), serviceUUID)
+	if err != nil {
+		if errors.Is(err, repository.ErrServiceNotFound) {
+			respondWithError(w, r, http.StatusNotFound, "Service not found")
+		} else {
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch service")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, svc)
+}
+
+// UpdateService godoc
+// @Summary Изменить сервис каталога
+// @Description Заменяет запись каталога. При смене названия связанные подписки получают новое service_name.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса (UUID)"
+// @Param service body models.ServiceInput true "Данные сервиса"
+// @Success 200 {object} models.Service
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /services/{id} [put]
+func (h *Handler) UpdateService(w http.ResponseWriter, r *http.Request) {
+	serviceUUID, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid service ID format")
+		return
+	}
+
+	var input models.ServiceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	svc, err := h.serviceFromInput(r.Context(), input)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	svc.ID = serviceUUID
+
+	if err := h.repo.UpdateService(r.Context(), svc, change(r, nil, nil)); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrServiceNotFound):
+			respondWithError(w, r, http.StatusNotFound, "Service not found")
+		case errors.Is(err, repository.ErrServiceConflict):
+			respondWithError(w, r, http.StatusConflict, "Service name or alias is already taken")
+		default:
+			log.Println("Failed to update service:", err)
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to update service")
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, svc)
+}
+
+// DeleteService godoc
+// @Summary Удалить сервис из каталога
+// @Description Подписки на сервис сохраняют service_name, но теряют service_id.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса (UUID)"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /services/{id} [delete]
+func (h *Handler) DeleteService(w http.ResponseWriter, r *http.Request) {
+	serviceUUID, err := parseUUID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid service ID format")
+		return
+	}
+
+	if err := h.repo.DeleteService(r.Context(), serviceUUID, change(r, nil, nil)); err != nil {
+		if errors.Is(err, repository.ErrServiceNotFound) {
+			respondWithError(w, r, http.StatusNotFound, "Service not found")
+		} else {
+			log.Println("Failed to delete service:", err)
+			respondWithError(w, r, http.StatusInternalServerError, "Failed to delete service")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serviceFromInput проверяет данные сервиса и приводит их к виду, в котором они хранятся.
+// Текст ошибки предназначен для ответа клиенту.
+func (h *Handler) serviceFromInput(ctx context.Context, input models.ServiceInput) (*models.Service, error) {
+	svc := &models.Service{
+		Name:          strings.TrimSpace(input.Name),
+		Category:      strings.TrimSpace(input.Category),
+		Website:       strings.TrimSpace(input.Website),
+		DefaultPrice:  input.DefaultPrice,
+		Currency:      strings.ToUpper(input.Currency),
+		BillingPeriod: input.BillingPeriod,
+		Aliases:       []string{},
+	}
+	if svc.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	// Псевдонимы храним в нижнем регистре без повторов и без самого названия
+	seen := map[string]bool{strings.ToLower(svc.Name): true}
+	for _, alias := range input.Aliases {
+		alias = strings.ToLower(strings.TrimSpace(alias))
+		if alias == "" || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		svc.Aliases = append(svc.Aliases, alias)
+	}
+
+	if svc.Website != "" {
+		u, err := url.Parse(svc.Website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.New("Invalid website, expected an http(s) URL")
+		}
+	}
+	if svc.DefaultPrice != nil && *svc.DefaultPrice < 0 {
+		return nil, errors.New("default_price must not be negative")
+	}
+
+	if svc.Currency == "" {
+		svc.Currency = models.DefaultCurrency
+	}
+	if !rates.ValidCode(svc.Currency) {
+		return nil, errors.New("Invalid currency")
+	}
+	if !h.supportedCurrency(ctx, svc.Currency) {
+		return nil, errors.New("Unsupported currency")
+	}
+	if svc.BillingPeriod == "" {
+		svc.BillingPeriod = models.BillingMonthly
+	}
+	if !svc.BillingPeriod.Valid() {
+		return nil, errors.New("Invalid billing_period")
+	}
+
+	return svc, nil
+}
+
+// lookupService находит запись каталога для подписки: по serviceID, если он задан, иначе по
+// названию или псевдониму. Неизвестный serviceID — ошибка ErrServiceNotFound, неизвестное
+// название — не ошибка: подписка просто не связывается с каталогом.
+func (h *Handler) lookupService(ctx context.Context, serviceID *uuid.UUID, name string) (*models.Service, error) {
+	if serviceID != nil {
+		svc, err := h.repo.GetService(ctx, *serviceID)
+		if err != nil && !errors.Is(err, repository.ErrServiceNotFound) {
+			log.Println("Failed to fetch service:", err)
+		}
+		return svc, err
+	}
+
+	svc, err := h.repo.ResolveService(ctx, name)
+	if errors.Is(err, repository.ErrServiceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("Failed to resolve service:", err)
+		return nil, err
+	}
+	return svc, nil
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultUpcomingWindow — окно поиска списаний, если within не указан
+	defaultUpcomingWindow = 7 * 24 * time.Hour
+	// maxUpcomingWindow ограничивает окно поиска списаний
+	maxUpcomingWindow = 366 * 24 * time.Hour
+)
+
+// ListUpcomingCharges godoc
+// @Summary Предстоящие списания
+// @Description Возвращает подписки, ближайшее списание которых попадает в окно [сегодня, сегодня + within].
+// @Description Даты списаний отсчитываются от start_date с шагом billing_period; в пробный период и на паузе списаний нет.
+// @Description Результаты упорядочены по дате списания.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param within query string false "Окно поиска: число дней с суффиксом d (7d) или длительность Go (36h), по умолчанию 7d"
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Success 200 {array} models.UpcomingCharge
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/upcoming [get]
+func (h *Handler) ListUpcomingCharges(w http.ResponseWriter, r *http.Request) {
+	var v validator
+	within := defaultUpcomingWindow
+	if s := r.URL.Query().Get("within"); s != "" {
+		var ok bool
+		if within, ok = parseWindow(s); !ok {
+			v.add("within", codeInvalidFormat, "Invalid within, expected a duration like 7d")
+		} else if within > maxUpcomingWindow {
+			v.add("within", codeOutOfRange, "within must not exceed 366d")
+		}
+	}
+
+	var userID *uuid.UUID
+	if userUUID := v.id("user_id", r.URL.Query().Get("user_id")); userUUID != uuid.Nil {
+		userID = &userUUID
+	}
+
+	if !v.valid() {
+		v.respond(w, r)
+		return
+	}
+
+	from := today()
+	charges, err := h.repo.ListUpcomingCharges(r.Context(), from, from.Add(within), userID)
+	if err != nil {
+		log.Println("Failed to list upcoming charges:", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error fetching upcoming charges")
+		return
+	}
+
+	for i := range charges {
+		charges[i].RefreshDerived()
+	}
+
+	respondWithJSON(w, http.StatusOK, charges)
+}
+
+// parseWindow разбирает длительность окна: "7d" или строку для time.ParseDuration
+func parseWindow(s string) (time.Duration, bool) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		// Слишком большое число дней переполнило бы time.Duration
+		if err != nil || n < 0 || int64(n) > math.MaxInt64/int64(24*time.Hour) {
+			return 0, false
+		}
+		return time.Duration(n) * 24 * time.Hour, true
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return d, true
+}
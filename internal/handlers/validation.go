@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/rates"
+	"github.com/google/uuid"
+)
+
+// Коды ошибок проверки полей в ответе application/problem+json
+const (
+	codeRequired      = "required"
+	codeInvalidFormat = "invalid_format"
+	codeInvalidValue  = "invalid_value"
+	codeOutOfRange    = "out_of_range"
+	codeUnknown       = "unknown"
+)
+
+// validator собирает ошибки проверки входных данных по полям,
+// чтобы вернуть клиенту их все в одном ответе.
+// Для каждого поля запоминается только первая ошибка.
+type validator struct {
+	errors []models.FieldError
+}
+
+// add запоминает ошибку поля, если для него ошибки еще нет
+func (v *validator) add(field, code, message string) {
+	if v.has(field) {
+		return
+	}
+	v.errors = append(v.errors, models.FieldError{Field: field, Code: code, Message: message})
+}
+
+// has сообщает, есть ли уже ошибка у поля
+func (v *validator) has(field string) bool {
+	for _, e := range v.errors {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) valid() bool {
+	return len(v.errors) == 0
+}
+
+// respond отвечает 400 со списком ошибок; detail перечисляет их сообщения
+func (v *validator) respond(w http.ResponseWriter, r *http.Request) {
+	messages := make([]string, len(v.errors))
+	for i, e := range v.errors {
+		messages[i] = e.Message
+	}
+	detail := strings.Join(messages, "; ")
+
+	log.Printf("Validation error: %s", detail)
+	respondWithProblem(w, r, http.StatusBadRequest, detail, v.errors)
+}
+
+// id разбирает UUID из поля; пустое значение не проверяется
+func (v *validator) id(field, value string) uuid.UUID {
+	if value == "" {
+		return uuid.Nil
+	}
+	id, err := parseUUID(value)
+	if err != nil {
+		v.add(field, codeInvalidFormat, "Invalid "+field+" format")
+	}
+	return id
+}
+
+// date разбирает дату из поля по layout; пустое значение не проверяется
+func (v *validator) date(field, layout, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := parseDate(layout, value)
+	if err != nil {
+		v.add(field, codeInvalidFormat, "Invalid "+field+" format")
+		return nil
+	}
+	return t
+}
+
+// currency приводит код валюты к верхнему регистру и проверяет его
+func (v *validator) currency(field, code string) string {
+	code = strings.ToUpper(code)
+	if !rates.ValidCode(code) {
+		v.add(field, codeInvalidValue, "Invalid "+field)
+	}
+	return code
+}
+
+// currency дополнительно к формату проверяет, что у провайдера есть курс валюты.
+// Суммы в валюте без курса нельзя сохранять и запрашивать: пересчет итогов,
+// в которые они попадают, всегда завершался бы ошибкой.
+func (h *Handler) currency(ctx context.Context, v *validator, field, code string) string {
+	code = v.currency(field, code)
+	if !v.has(field) && !h.supportedCurrency(ctx, code) {
+		v.add(field, codeInvalidValue, "Unsupported "+field)
+	}
+	return code
+}
+
+// supportedCurrency сообщает, можно ли пересчитать сумму в валюте code в базовую
+func (h *Handler) supportedCurrency(ctx context.Context, code string) bool {
+	_, _, err := h.convert(ctx, 0, code, models.DefaultCurrency, today())
+	return err == nil
+}
+
+// billingPeriod проверяет периодичность списаний
+func (v *validator) billingPeriod(field string, period models.BillingPeriod) {
+	if !period.Valid() {
+		v.add(field, codeInvalidValue, "Invalid "+field)
+	}
+}
+
+// validateSubscription проверяет подписку, собранную из запроса на создание или изменение.
+// Поля, в которых уже найдена ошибка формата, повторно не проверяются.
+func validateSubscription(v *validator, sub *models.Subscription) {
+	// Название не определено, потому что service_id уже отклонен
+	if sub.ServiceName == "" && !v.has("service_id") {
+		v.add("service_name", codeRequired, "service_name is required")
+	}
+	if sub.Price <= 0 {
+		v.add("price", codeOutOfRange, "price must be positive")
+	}
+	if sub.UserID == uuid.Nil {
+		v.add("user_id", codeRequired, "user_id is required")
+	}
+	if sub.StartDate.IsZero() {
+		v.add("start_date", codeRequired, "start_date is required")
+		return
+	}
+	if sub.EndDate != nil && sub.EndDate.Before(sub.StartDate) {
+		v.add("end_date", codeOutOfRange, "end_date must not be before start_date")
+	}
+	if sub.TrialEnd != nil && sub.TrialEnd.Before(sub.StartDate) {
+		v.add("trial_end", codeOutOfRange, "trial_end must not be before start_date")
+	}
+}
@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/budgets"
+	"github.com/EvgenyiK/subscription-service/internal/events"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+)
+
+// BudgetAlertJob периодически сравнивает бюджеты пользователей с расходами
+// и публикует событие, когда расходы за месяц достигают порога бюджета.
+// О каждом пороге событие публикуется не чаще раза в месяц: отправленные
+// уведомления хранятся в репозитории и переживают перезапуск.
+type BudgetAlertJob struct {
+	repo      repository.BudgetRepository
+	evaluator *budgets.Evaluator
+	publisher events.Publisher
+	interval  time.Duration
+}
+
+// NewBudgetAlertJob создает задачу, которая проверяет бюджеты раз в interval
+func NewBudgetAlertJob(repo repository.BudgetRepository, evaluator *budgets.Evaluator, publisher events.Publisher, interval time.Duration) *BudgetAlertJob {
+	return &BudgetAlertJob{
+		repo:      repo,
+		evaluator: evaluator,
+		publisher: publisher,
+		interval:  interval,
+	}
+}
+
+// Run выполняет проверку сразу и затем по расписанию, пока не отменен ctx
+func (j *BudgetAlertJob) Run(ctx context.Context) {
+	runEvery(ctx, j.interval, "BudgetAlertJob: ошибка проверки бюджетов", j.RunOnce)
+}
+
+// RunOnce публикует события о порогах, достигнутых в месяце now и еще не отправленных
+func (j *BudgetAlertJob) RunOnce(ctx context.Context, now time.Time) error {
+	list, err := j.repo.ListBudgets(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range list {
+		b := &list[i]
+		status, err := j.evaluator.Status(ctx, b, now)
+		if err != nil {
+			log.Printf("BudgetAlertJob: ошибка расчета расходов пользователя %s: %v", b.UserID, err)
+			continue
+		}
+
+		for _, threshold := range status.ReachedThresholds {
+			sent, err := j.repo.BudgetAlertSent(ctx, b.UserID, now, threshold)
+			if err != nil {
+				return err
+			}
+			if sent {
+				continue
+			}
+
+			err = j.publisher.Publish(ctx, events.Event{
+				Type:       events.TypeBudgetThresholdReached,
+				OccurredAt: now,
+				UserID:     b.UserID,
+				Data: map[string]interface{}{
+					"month":        status.Month,
+					"threshold":    threshold,
+					"limit":        status.Limit,
+					"spent":        status.Spent,
+					"percent_used": status.PercentUsed,
+					"currency":     status.Currency,
+				},
+			})
+			if err != nil {
+				log.Printf("BudgetAlertJob: ошибка публикации события для %s: %v", b.UserID, err)
+				continue
+			}
+			if err := j.repo.RecordBudgetAlert(ctx, b.UserID, now, threshold, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/budgets"
+	"github.com/EvgenyiK/subscription-service/internal/events"
+	"github.com/EvgenyiK/subscription-service/internal/jobs"
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/rates"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// recordingPublisher запоминает опубликованные события
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, e events.Event) error {
+	p.events = append(p.events, e)
+	return nil
+}
+
+func TestBudgetAlertJobNotifiesOncePerMonth(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	provider, err := rates.NewFileProvider("")
+	if err != nil {
+		t.Fatalf("rates: %v", err)
+	}
+
+	user := uuid.New()
+	create := func(price int) {
+		t.Helper()
+		sub := &models.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: price, Currency: "RUB",
+			BillingPeriod: models.BillingMonthly, UserID: user, StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+		if err := repo.Create(ctx, sub, nil); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	create(3000)
+	if err := repo.SetBudget(ctx, &models.Budget{UserID: user, MonthlyLimit: 3500, Currency: "RUB", Thresholds: []int{80, 100}}); err != nil {
+		t.Fatalf("SetBudget: %v", err)
+	}
+
+	publisher := &recordingPublisher{}
+	job := jobs.NewBudgetAlertJob(repo, budgets.NewEvaluator(repo, provider), publisher, time.Hour)
+	run := func(now time.Time, want ...int) {
+		t.Helper()
+		publisher.events = nil
+		if err := job.RunOnce(ctx, now); err != nil {
+			t.Fatalf("RunOnce: %v", err)
+		}
+		if len(publisher.events) != len(want) {
+			t.Fatalf("RunOnce(%s) published %d events, want %d", now.Format("2006-01-02"), len(publisher.events), len(want))
+		}
+		for i, threshold := range want {
+			e := publisher.events[i]
+			if e.Type != events.TypeBudgetThresholdReached || e.UserID != user || e.Data["threshold"] != threshold {
+				t.Errorf("event %d = %+v, want threshold %d", i, e, threshold)
+			}
+		}
+	}
+
+	// 3000 из 3500 — 85%: только порог 80, и только один раз
+	run(time.Date(2025, 7, 10, 9, 0, 0, 0, time.UTC), 80)
+	run(time.Date(2025, 7, 10, 10, 0, 0, 0, time.UTC))
+
+	// Новая подписка переводит расходы за 100%
+	create(1000)
+	run(time.Date(2025, 7, 11, 9, 0, 0, 0, time.UTC), 100)
+
+	// В следующем месяце уведомления отправляются заново
+	run(time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC), 80, 100)
+}
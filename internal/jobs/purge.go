@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+)
+
+// PurgeDeletedJob периодически окончательно удаляет подписки,
+// которые пролежали удаленными дольше срока хранения.
+type PurgeDeletedJob struct {
+	repo      repository.SubscriptionRepository
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewPurgeDeletedJob создает задачу, которая раз в interval очищает подписки,
+// удаленные больше retention назад
+func NewPurgeDeletedJob(repo repository.SubscriptionRepository, retention, interval time.Duration) *PurgeDeletedJob {
+	return &PurgeDeletedJob{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run выполняет очистку сразу и затем по расписанию, пока не отменен ctx
+func (j *PurgeDeletedJob) Run(ctx context.Context) {
+	runEvery(ctx, j.interval, "PurgeDeletedJob: ошибка очистки удаленных подписок", j.RunOnce)
+}
+
+// RunOnce окончательно удаляет подписки, удаленные раньше now - retention
+func (j *PurgeDeletedJob) RunOnce(ctx context.Context, now time.Time) error {
+	// Без срока хранения очистка удалила бы все подписки, которые еще можно восстановить
+	if j.retention <= 0 {
+		return fmt.Errorf("invalid retention %s, expected a positive duration", j.retention)
+	}
+	purged, err := j.repo.PurgeDeleted(ctx, now.Add(-j.retention))
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("PurgeDeletedJob: окончательно удалено подписок: %d", purged)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/jobs"
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestPurgeDeletedJobKeepsDeletedUntilRetentionEnds(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+
+	sub := &models.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 3100, Currency: "RUB",
+		BillingPeriod: models.BillingMonthly, UserID: uuid.New(), StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := repo.Create(ctx, sub, nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.Delete(ctx, sub.ID, sub.Version, nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	stored := func() int {
+		t.Helper()
+		count, err := repo.CountSubscriptions(ctx, repository.ListFilter{IncludeDeleted: true})
+		if err != nil {
+			t.Fatalf("CountSubscriptions: %v", err)
+		}
+		return count
+	}
+
+	const retention = 30 * 24 * time.Hour
+	job := jobs.NewPurgeDeletedJob(repo, retention, time.Hour)
+
+	// До конца срока хранения подписку еще можно восстановить
+	if err := job.RunOnce(ctx, time.Now().Add(retention-time.Hour)); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if got := stored(); got != 1 {
+		t.Fatalf("after RunOnce within retention stored %d subscriptions, want 1", got)
+	}
+
+	if err := job.RunOnce(ctx, time.Now().Add(retention+time.Hour)); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if got := stored(); got != 0 {
+		t.Errorf("after RunOnce past retention stored %d subscriptions, want 0", got)
+	}
+	if _, err := repo.GetDeleted(ctx, sub.ID); err == nil {
+		t.Error("GetDeleted of a purged subscription succeeded")
+	}
+}
+
+func TestPurgeDeletedJobRejectsNonPositiveRetention(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+
+	sub := &models.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 3100, Currency: "RUB",
+		BillingPeriod: models.BillingMonthly, UserID: uuid.New(), StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := repo.Create(ctx, sub, nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.Delete(ctx, sub.ID, sub.Version, nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	for _, retention := range []time.Duration{0, -time.Hour} {
+		job := jobs.NewPurgeDeletedJob(repo, retention, time.Hour)
+		if err := job.RunOnce(ctx, time.Now().Add(time.Hour)); err == nil {
+			t.Errorf("RunOnce with retention %s: expected error", retention)
+		}
+	}
+	if _, err := repo.GetDeleted(ctx, sub.ID); err != nil {
+		t.Errorf("GetDeleted after rejected purge: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/notify"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+)
+
+// RenewalReminderJob периодически ищет подписки, которые скоро спишутся,
+// и отправляет пользователю напоминание. О каждом списании напоминание
+// отправляется один раз: отправленные хранятся в репозитории и переживают перезапуск.
+type RenewalReminderJob struct {
+	repo      repository.Storage
+	notifier  notify.Notifier
+	lookahead time.Duration
+	interval  time.Duration
+}
+
+// NewRenewalReminderJob создает задачу, которая раз в interval напоминает
+// о списаниях в ближайшие lookahead
+func NewRenewalReminderJob(repo repository.Storage, notifier notify.Notifier, lookahead, interval time.Duration) *RenewalReminderJob {
+	return &RenewalReminderJob{
+		repo:      repo,
+		notifier:  notifier,
+		lookahead: lookahead,
+		interval:  interval,
+	}
+}
+
+// Run выполняет проверку сразу и затем по расписанию, пока не отменен ctx
+func (j *RenewalReminderJob) Run(ctx context.Context) {
+	runEvery(ctx, j.interval, "RenewalReminderJob: ошибка проверки списаний", j.RunOnce)
+}
+
+// RunOnce отправляет напоминания о списаниях в [now, now+lookahead], о которых еще не напоминали
+func (j *RenewalReminderJob) RunOnce(ctx context.Context, now time.Time) error {
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.Add(j.lookahead)
+
+	charges, err := j.repo.ListUpcomingCharges(ctx, from, to, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, charge := range charges {
+		sent, err := j.repo.ReminderSent(ctx, charge.ID, charge.ChargeDate)
+		if err != nil {
+			return err
+		}
+		if sent {
+			continue
+		}
+
+		err = j.notifier.Notify(ctx, notify.Reminder{
+			SubscriptionID: charge.ID,
+			UserID:         charge.UserID,
+			ServiceName:    charge.ServiceName,
+			ChargeDate:     charge.ChargeDate.Format("2006-01-02"),
+			Amount:         charge.Amount,
+			Currency:       charge.Currency,
+			SentAt:         now,
+		})
+		if err != nil {
+			log.Printf("RenewalReminderJob: ошибка отправки напоминания для %s: %v", charge.ID, err)
+			continue
+		}
+		if err := j.repo.RecordReminder(ctx, charge.ID, charge.ChargeDate, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/jobs"
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/EvgenyiK/subscription-service/internal/notify"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// recordingNotifier запоминает отправленные напоминания
+type recordingNotifier struct {
+	reminders []notify.Reminder
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, r notify.Reminder) error {
+	n.reminders = append(n.reminders, r)
+	return nil
+}
+
+func TestRenewalReminderJobRemindsOncePerCharge(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+
+	sub := &models.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 3100, Currency: "RUB",
+		BillingPeriod: models.BillingMonthly, UserID: uuid.New(), StartDate: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)}
+	if err := repo.Create(ctx, sub, nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	notifier := &recordingNotifier{}
+	job := jobs.NewRenewalReminderJob(repo, notifier, 72*time.Hour, time.Hour)
+	run := func(now time.Time, want ...string) {
+		t.Helper()
+		notifier.reminders = nil
+		if err := job.RunOnce(ctx, now); err != nil {
+			t.Fatalf("RunOnce: %v", err)
+		}
+		if len(notifier.reminders) != len(want) {
+			t.Fatalf("RunOnce(%s) sent %d reminders, want %d", now.Format("2006-01-02"), len(notifier.reminders), len(want))
+		}
+		for i, chargeDate := range want {
+			if r := notifier.reminders[i]; r.SubscriptionID != sub.ID || r.ChargeDate != chargeDate || r.Amount != 3100 {
+				t.Errorf("reminder %d = %+v, want charge on %s", i, r, chargeDate)
+			}
+		}
+	}
+
+	run(time.Date(2025, 7, 6, 9, 0, 0, 0, time.UTC))
+	run(time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC), "2025-07-10")
+	run(time.Date(2025, 7, 8, 9, 0, 0, 0, time.UTC))
+	run(time.Date(2025, 8, 8, 9, 0, 0, 0, time.UTC), "2025-08-10")
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runEvery вызывает runOnce сразу и затем раз в interval, пока не отменен ctx.
+// Ошибки runOnce логируются с префиксом errPrefix и не останавливают расписание.
+func runEvery(ctx context.Context, interval time.Duration, errPrefix string, runOnce func(ctx context.Context, now time.Time) error) {
+	// time.NewTicker паникует при неположительном интервале
+	if interval <= 0 {
+		log.Printf("%s: некорректный интервал %s, задача не запущена", errPrefix, interval)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := runOnce(ctx, time.Now()); err != nil {
+			log.Printf("%s: %v", errPrefix, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/events"
+	"github.com/EvgenyiK/subscription-service/internal/jobs"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+)
+
+func TestRunWithNonPositiveIntervalReturns(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Hour} {
+		job := jobs.NewTrialConversionJob(repository.NewMemoryRepository(), events.LogPublisher{}, time.Hour, interval)
+
+		// Контекст не отменяется: Run должен вернуться сам, а не паниковать в time.NewTicker
+		done := make(chan struct{})
+		go func() {
+			job.Run(context.Background())
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Run with interval %s did not return", interval)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/events"
+	"github.com/EvgenyiK/subscription-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// TrialConversionJob периодически ищет подписки, у которых скоро
+// закончится пробный период, и публикует о них событие.
+// О каждом окончании пробного периода событие публикуется один раз за время работы процесса.
+type TrialConversionJob struct {
+	repo      repository.SubscriptionRepository
+	publisher events.Publisher
+	lookahead time.Duration
+	interval  time.Duration
+
+	mu       sync.Mutex
+	notified map[uuid.UUID]time.Time // подписка -> trial_end, о котором уже сообщили
+}
+
+// NewTrialConversionJob создает задачу, которая раз в interval сообщает
+// о пробных периодах, заканчивающихся в ближайшие lookahead
+func NewTrialConversionJob(repo repository.SubscriptionRepository, publisher events.Publisher, lookahead, interval time.Duration) *TrialConversionJob {
+	return &TrialConversionJob{
+		repo:      repo,
+		publisher: publisher,
+		lookahead: lookahead,
+		interval:  interval,
+		notified:  make(map[uuid.UUID]time.Time),
+	}
+}
+
+// Run выполняет проверку сразу и затем по расписанию, пока не отменен ctx
+func (j *TrialConversionJob) Run(ctx context.Context) {
+	runEvery(ctx, j.interval, "TrialConversionJob: ошибка проверки пробных периодов", j.RunOnce)
+}
+
+// RunOnce публикует события о пробных периодах, заканчивающихся в [now, now+lookahead]
+func (j *TrialConversionJob) RunOnce(ctx context.Context, now time.Time) error {
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.Add(j.lookahead)
+
+	subs, err := j.repo.ListTrialsEndingBetween(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, sub := range subs {
+		if sent, ok := j.notified[sub.ID]; ok && sent.Equal(*sub.TrialEnd) {
+			continue
+		}
+
+		err := j.publisher.Publish(ctx, events.Event{
+			Type:           events.TypeTrialConverting,
+			OccurredAt:     now,
+			SubscriptionID: sub.ID,
+			UserID:         sub.UserID,
+			Data: map[string]interface{}{
+				"service_name": sub.ServiceName,
+				"trial_end":    sub.TrialEnd.Format("2006-01-02"),
+				"price":        sub.PriceOn(*sub.TrialEnd),
+				"currency":     sub.Currency,
+			},
+		})
+		if err != nil {
+			log.Printf("TrialConversionJob: ошибка публикации события для %s: %v", sub.ID, err)
+			continue
+		}
+		j.notified[sub.ID] = *sub.TrialEnd
+	}
+
+	return nil
+}
@@ -1,35 +1,454 @@
 package models
 
 import (
-	"github.com/google/uuid"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
+)
+
+// BillingPeriod — периодичность списания цены подписки
+type BillingPeriod string
+
+const (
+	BillingWeekly    BillingPeriod = "weekly"
+	BillingMonthly   BillingPeriod = "monthly"
+	BillingQuarterly BillingPeriod = "quarterly"
+	BillingYearly    BillingPeriod = "yearly"
+)
+
+// Valid сообщает, поддерживается ли периодичность
+func (p BillingPeriod) Valid() bool {
+	switch p {
+	case BillingWeekly, BillingMonthly, BillingQuarterly, BillingYearly:
+		return true
+	}
+	return false
+}
+
+// AddPeriods сдвигает дату t на n периодов списания
+func (p BillingPeriod) AddPeriods(t time.Time, n int) time.Time {
+	switch p {
+	case BillingWeekly:
+		return t.AddDate(0, 0, 7*n)
+	case BillingQuarterly:
+		return t.AddDate(0, 3*n, 0)
+	case BillingYearly:
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, n, 0)
+	}
+}
+
+// DefaultCurrency — валюта подписок и отчетов по умолчанию
+const DefaultCurrency = "RUB"
+
+// SubscriptionStatus — состояние подписки, вычисляемое для отображения
+type SubscriptionStatus string
+
+const (
+	StatusActive SubscriptionStatus = "active"
+	StatusTrial  SubscriptionStatus = "trial"
+	StatusPaused SubscriptionStatus = "paused"
+	StatusEnded  SubscriptionStatus = "ended"
 )
 
 type Subscription struct {
-	ID          uuid.UUID  `json:"id"`
-	ServiceName string     `json:"service_name"`
-	Price       int        `json:"price"` // в рублях, целое число
-	UserID      uuid.UUID  `json:"user_id"`
-	StartDate   time.Time  `json:"start_date"`         // месяц и год, например 07-2025
-	EndDate     *time.Time `json:"end_date,omitempty"` // опционально
+	ID            uuid.UUID     `json:"id"`
+	ServiceName   string        `json:"service_name"`
+	Price         int           `json:"price"`          // начальная цена в минорных единицах валюты (копейки, центы) за один billing_period
+	Currency      string        `json:"currency"`       // код валюты ISO 4217, например RUB
+	BillingPeriod BillingPeriod `json:"billing_period"` // weekly, monthly, quarterly или yearly
+	UserID        uuid.UUID     `json:"user_id"`
+	StartDate     time.Time     `json:"start_date"`           // месяц и год, например 07-2025
+	EndDate       *time.Time    `json:"end_date,omitempty"`   // пусто — подписка действует до отмены
+	TrialEnd      *time.Time    `json:"trial_end,omitempty"`  // первый оплачиваемый день после пробного периода
+	ServiceID     *uuid.UUID    `json:"service_id,omitempty"` // запись каталога сервисов; пусто — service_name не найден в каталоге
+	Version       int           `json:"version"`              // растет при каждом изменении подписки, передается в ETag
+	DeletedAt     *time.Time    `json:"deleted_at,omitempty"` // момент удаления; удаленные подписки видны только администратору
+
+	Pauses       []Pause       `json:"pauses,omitempty"`        // история пауз, по возрастанию start_date
+	PriceChanges []PriceChange `json:"price_changes,omitempty"` // изменения цены, по возрастанию effective_from
+
+	// Вычисляемые поля, в базе не хранятся
+	Status       SubscriptionStatus `json:"status" enums:"active,trial,paused,ended"`
+	CurrentPrice int                `json:"current_price"` // цена, действующая сегодня
+}
+
+// PriceChange — новая цена подписки, действующая с effective_from
+type PriceChange struct {
+	ID             uuid.UUID `json:"id"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	Price          int       `json:"price"` // в минорных единицах валюты подписки
+	EffectiveFrom  time.Time `json:"effective_from"`
+}
+
+// Pause — интервал, в который подписка заморожена и не оплачивается
+type Pause struct {
+	ID             uuid.UUID  `json:"id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        *time.Time `json:"end_date,omitempty"` // последний день паузы; пусто — до возобновления
+}
+
+// Covers сообщает, приходится ли день на паузу
+func (p *Pause) Covers(day time.Time) bool {
+	return !day.Before(p.StartDate) && (p.EndDate == nil || !day.After(*p.EndDate))
+}
+
+// StatusAt вычисляет состояние подписки на момент t.
+// День end_date подписка еще считается активной.
+func (s *Subscription) StatusAt(t time.Time) SubscriptionStatus {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if s.EndDate != nil && s.EndDate.Before(day) {
+		return StatusEnded
+	}
+	if s.PausedOn(day) {
+		return StatusPaused
+	}
+	if s.InTrialOn(day) {
+		return StatusTrial
+	}
+	return StatusActive
+}
+
+// InTrialOn сообщает, приходится ли день day на бесплатный пробный период
+func (s *Subscription) InTrialOn(day time.Time) bool {
+	return s.TrialEnd != nil && day.Before(*s.TrialEnd)
+}
+
+// PausedOn сообщает, заморожена ли подписка в день day
+func (s *Subscription) PausedOn(day time.Time) bool {
+	for i := range s.Pauses {
+		if s.Pauses[i].Covers(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextChargeDate возвращает ближайшую дату списания не раньше дня t.
+// Списания происходят в start_date и далее через каждый billing_period.
+func (s *Subscription) NextChargeDate(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	start := time.Date(s.StartDate.Year(), s.StartDate.Month(), s.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	charge := start
+	for n := 1; charge.Before(day); n++ {
+		charge = s.BillingPeriod.AddPeriods(start, n)
+	}
+	return charge
+}
+
+// CurrentPeriodEnd возвращает последний день оплаченного периода, в который попадает день t
+func (s *Subscription) CurrentPeriodEnd(t time.Time) time.Time {
+	return s.NextChargeDate(t.AddDate(0, 0, 1)).AddDate(0, 0, -1)
+}
+
+// ChargeBetween возвращает ближайшее списание в [from, to], если оно есть.
+// Списаний нет после end_date, в пробный период и во время паузы.
+func (s *Subscription) ChargeBetween(from, to time.Time) (time.Time, bool) {
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	for charge := s.NextChargeDate(from); !charge.After(last); charge = s.NextChargeDate(charge.AddDate(0, 0, 1)) {
+		if s.EndDate != nil && charge.After(*s.EndDate) {
+			break
+		}
+		if s.InTrialOn(charge) || s.PausedOn(charge) {
+			continue
+		}
+		return charge, true
+	}
+	return time.Time{}, false
+}
+
+// PriceOn возвращает цену, действовавшую в день day.
+// До первого изменения действует Price.
+func (s *Subscription) PriceOn(day time.Time) int {
+	price := s.Price
+	for i := range s.PriceChanges {
+		if s.PriceChanges[i].EffectiveFrom.After(day) {
+			break
+		}
+		price = s.PriceChanges[i].Price
+	}
+	return price
+}
+
+// RefreshDerived заполняет вычисляемые поля на текущий момент
+func (s *Subscription) RefreshDerived() {
+	now := time.Now()
+	s.Status = s.StatusAt(now)
+	s.CurrentPrice = s.PriceOn(now)
 }
 
 // CreateSubscriptionInput представляет входные данные для создания подписки.
 // swagger:model
 type CreateSubscriptionInput struct {
-	ServiceName string    `json:"service_name" example:"Netflix"`
-	Price       int       `json:"price" example:"10"`
-	UserID      uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	StartDate   string    `json:"start_date" example:"07-2025"` // формат "01-2006"
-	EndDate     *string   `json:"end_date,omitempty" example:"08-2025"`
+	ServiceName   string        `json:"service_name" example:"Netflix"`
+	Price         int           `json:"price,omitempty" example:"1099"`                                                     // в минорных единицах валюты; по умолчанию цена из каталога, тогда currency и billing_period берутся из каталога
+	Currency      string        `json:"currency,omitempty" example:"USD"`                                                   // ISO 4217, по умолчанию RUB
+	BillingPeriod BillingPeriod `json:"billing_period,omitempty" example:"monthly" enums:"weekly,monthly,quarterly,yearly"` // по умолчанию monthly
+	UserID        uuid.UUID     `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	StartDate     string        `json:"start_date" example:"07-2025"`             // формат "01-2006"
+	EndDate       *string       `json:"end_date,omitempty" example:"08-2025"`     // не указывается для бессрочной подписки
+	TrialEnd      *string       `json:"trial_end,omitempty" example:"2025-07-15"` // первый оплачиваемый день, формат "2006-01-02"
+	ServiceID     *uuid.UUID    `json:"service_id,omitempty"`                     // запись каталога; если не указана, ищется по service_name и псевдонимам
 }
 
 // UpdateSubscriptionInput представляет данные для обновления подписки.
 // swagger:model
 type UpdateSubscriptionInput struct {
-	ServiceName string     `json:"service_name" example:"Netflix"`
-	Price       int        `json:"price" example:"15"`
-	UserID      uuid.UUID  `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	StartDate   time.Time  `json:"start_date" example:"2025-07-01"` // формат ISO8601
-	EndDate     *time.Time `json:"end_date,omitempty" example:"2025-08-01"`
+	ServiceName   string        `json:"service_name" example:"Netflix"`
+	Price         int           `json:"price" example:"1599"`                                                              // в минорных единицах валюты
+	Currency      string        `json:"currency,omitempty" example:"USD"`                                                  // если не указана, не меняется
+	BillingPeriod BillingPeriod `json:"billing_period,omitempty" example:"yearly" enums:"weekly,monthly,quarterly,yearly"` // если не указан, не меняется
+	UserID        uuid.UUID     `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	StartDate     time.Time     `json:"start_date" example:"2025-07-01"` // формат ISO8601
+	EndDate       *time.Time    `json:"end_date,omitempty" example:"2025-08-01"`
+	TrialEnd      *time.Time    `json:"trial_end,omitempty" example:"2025-07-15"`
+}
+
+// SubscriptionPage — страница списка подписок
+type SubscriptionPage struct {
+	Items []Subscription `json:"items"`
+	Total int            `json:"total" example:"42"`         // число подписок, подходящих под фильтр
+	Page  int            `json:"page,omitempty" example:"1"` // не заполняется при выборке по курсору
+	Limit int            `json:"limit" example:"10"`
+
+	// NextCursor — курсор следующей страницы; пусто, если страница последняя
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// UpcomingCharge — подписка и её ближайшее списание
+type UpcomingCharge struct {
+	Subscription
+	ChargeDate time.Time `json:"charge_date"`
+	Amount     int       `json:"amount"` // цена, действующая в день списания
+}
+
+// SearchResult — подписка, найденная поиском по названию сервиса
+type SearchResult struct {
+	Subscription
+	Relevance float64 `json:"relevance" example:"0.83"` // от 0 до 1, чем больше, тем ближе название к запросу
+}
+
+// Service — запись каталога сервисов.
+// Подписки ссылаются на неё через service_id, а service_name подписки совпадает с Name.
+type Service struct {
+	ID            uuid.UUID     `json:"id"`
+	Name          string        `json:"name"`    // каноническое название
+	Aliases       []string      `json:"aliases"` // другие написания в нижнем регистре
+	Category      string        `json:"category,omitempty"`
+	Website       string        `json:"website,omitempty"`
+	DefaultPrice  *int          `json:"default_price,omitempty"` // в минорных единицах Currency
+	Currency      string        `json:"currency"`
+	BillingPeriod BillingPeriod `json:"billing_period"`
+}
+
+// ServiceInput — данные для создания и изменения записи каталога.
+// swagger:model
+type ServiceInput struct {
+	Name          string        `json:"name" example:"Netflix"`
+	Aliases       []string      `json:"aliases,omitempty" example:"netflix premium,нетфликс"`
+	Category      string        `json:"category,omitempty" example:"video"`
+	Website       string        `json:"website,omitempty" example:"https://www.netflix.com"`
+	DefaultPrice  *int          `json:"default_price,omitempty" example:"79900"`                                            // в минорных единицах валюты
+	Currency      string        `json:"currency,omitempty" example:"RUB"`                                                   // по умолчанию RUB
+	BillingPeriod BillingPeriod `json:"billing_period,omitempty" example:"monthly" enums:"weekly,monthly,quarterly,yearly"` // по умолчанию monthly
+}
+
+// CostReportItem — вклад одной подписки в стоимость месяца
+type CostReportItem struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	ServiceName    string    `json:"service_name"`
+	UserID         uuid.UUID `json:"user_id"`
+	Currency       string    `json:"currency"` // исходная валюта подписки
+	Cost           float64   `json:"cost"`     // в минорных единицах валюты отчета
+}
+
+// CostReportMonth — стоимость подписок за один календарный месяц
+type CostReportMonth struct {
+	Month         string           `json:"month" example:"2025-07"`
+	Total         float64          `json:"total"`
+	RateDate      string           `json:"rate_date,omitempty" example:"2025-07-01"` // дата курса, если был пересчет валют
+	Subscriptions []CostReportItem `json:"subscriptions"`
+}
+
+// CostReport — помесячный отчет о стоимости подписок за период
+type CostReport struct {
+	From     string            `json:"from" example:"2025-01"`
+	To       string            `json:"to" example:"2025-12"`
+	Currency string            `json:"currency" example:"RUB"`
+	Total    float64           `json:"total"`
+	Months   []CostReportMonth `json:"months"`
+}
+
+// CostBreakdownGroup — доля одной группы в стоимости подписок
+type CostBreakdownGroup struct {
+	Key        string  `json:"key" example:"video"` // название сервиса, категория или ID пользователя
+	Total      float64 `json:"total"`               // в минорных единицах валюты разбивки
+	Percentage float64 `json:"percentage" example:"42.5"`
+}
+
+// CostBreakdown — стоимость подписок за день, разбитая по сервисам, категориям или пользователям
+type CostBreakdown struct {
+	Date     string               `json:"date" example:"2025-07-15"`
+	GroupBy  string               `json:"group_by" example:"category" enums:"service,category,user"`
+	Currency string               `json:"currency" example:"RUB"`
+	Total    float64              `json:"total"`
+	RateDate string               `json:"rate_date,omitempty" example:"2025-07-01"` // дата курса, если был пересчет валют
+	Groups   []CostBreakdownGroup `json:"groups"`
+}
+
+// DefaultBudgetThresholds — пороги бюджета в процентах, если пользователь их не указал
+var DefaultBudgetThresholds = []int{80, 100}
+
+// Budget — месячный лимит расходов пользователя на подписки
+type Budget struct {
+	UserID       uuid.UUID `json:"user_id"`
+	MonthlyLimit int       `json:"monthly_limit" example:"500000"` // в минорных единицах currency
+	Currency     string    `json:"currency" example:"RUB"`
+	Thresholds   []int     `json:"thresholds" example:"80,100"` // проценты лимита по возрастанию
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// BudgetInput представляет данные для установки бюджета.
+// swagger:model
+type BudgetInput struct {
+	MonthlyLimit int    `json:"monthly_limit" example:"500000"`        // в минорных единицах currency
+	Currency     string `json:"currency,omitempty" example:"RUB"`      // ISO 4217, по умолчанию RUB
+	Thresholds   []int  `json:"thresholds,omitempty" example:"80,100"` // по умолчанию 80 и 100
+}
+
+// BudgetStatus — расходы пользователя за месяц в сравнении с бюджетом
+type BudgetStatus struct {
+	UserID            uuid.UUID `json:"user_id"`
+	Month             string    `json:"month" example:"2025-07"`
+	Currency          string    `json:"currency" example:"RUB"`
+	Limit             int       `json:"limit"`
+	Spent             float64   `json:"spent"` // сумма дневных итогов за все дни месяца
+	Remaining         float64   `json:"remaining"`
+	PercentUsed       float64   `json:"percent_used" example:"83.4"`
+	ReachedThresholds []int     `json:"reached_thresholds"`
+	RateDate          string    `json:"rate_date,omitempty" example:"2025-07-01"` // дата курса, если был пересчет валют
+}
+
+// CancellationReason — причина отмены подписки
+type CancellationReason string
+
+const (
+	ReasonTooExpensive    CancellationReason = "too_expensive"
+	ReasonNotUsed         CancellationReason = "not_used"
+	ReasonSwitchedService CancellationReason = "switched_service"
+	ReasonOther           CancellationReason = "other"
+)
+
+// Valid сообщает, поддерживается ли причина отмены
+func (r CancellationReason) Valid() bool {
+	switch r {
+	case ReasonTooExpensive, ReasonNotUsed, ReasonSwitchedService, ReasonOther:
+		return true
+	}
+	return false
+}
+
+// CancelEffective — когда отмена вступает в силу
+type CancelEffective string
+
+const (
+	CancelImmediate   CancelEffective = "immediate"
+	CancelEndOfPeriod CancelEffective = "end_of_period"
+)
+
+// Cancellation — запись об отмене подписки
+type Cancellation struct {
+	ID             uuid.UUID          `json:"id"`
+	SubscriptionID uuid.UUID          `json:"subscription_id"`
+	EffectiveDate  time.Time          `json:"effective_date"` // новый end_date подписки
+	Reason         CancellationReason `json:"reason"`
+	Comment        string             `json:"comment,omitempty"`
+	CancelledAt    time.Time          `json:"cancelled_at"`
+}
+
+// CancelSubscriptionInput представляет данные для отмены подписки.
+// swagger:model
+type CancelSubscriptionInput struct {
+	Effective CancelEffective    `json:"effective" example:"end_of_period" enums:"immediate,end_of_period"` // по умолчанию immediate
+	Reason    CancellationReason `json:"reason" example:"too_expensive" enums:"too_expensive,not_used,switched_service,other"`
+	Comment   string             `json:"comment,omitempty" example:"Нашли тариф дешевле"`
+}
+
+// CancelSubscriptionResult — подписка после отмены и запись об отмене
+type CancelSubscriptionResult struct {
+	Subscription Subscription `json:"subscription"`
+	Cancellation Cancellation `json:"cancellation"`
+}
+
+// PauseSubscriptionInput представляет данные для приостановки подписки.
+// swagger:model
+type PauseSubscriptionInput struct {
+	From  string `json:"from,omitempty" example:"2025-09-01"`  // первый день паузы, по умолчанию сегодня
+	Until string `json:"until,omitempty" example:"2025-11-30"` // последний день паузы; пусто — до возобновления
+}
+
+// ResumeSubscriptionInput представляет данные для возобновления подписки.
+// swagger:model
+type ResumeSubscriptionInput struct {
+	Date string `json:"date,omitempty" example:"2025-10-15"` // день возобновления, по умолчанию сегодня
+}
+
+// SchedulePriceChangeInput представляет данные для изменения цены подписки.
+// swagger:model
+type SchedulePriceChangeInput struct {
+	Price         int    `json:"price" example:"1299"`             // в минорных единицах валюты подписки
+	EffectiveFrom string `json:"effective_from" example:"09-2025"` // месяц, с которого действует цена, формат "01-2006"
+}
+
+// SubscriptionAction — вид изменения подписки в журнале
+type SubscriptionAction string
+
+const (
+	ActionCreate  SubscriptionAction = "create"
+	ActionUpdate  SubscriptionAction = "update"
+	ActionDelete  SubscriptionAction = "delete"
+	ActionCancel  SubscriptionAction = "cancel"
+	ActionRestore SubscriptionAction = "restore"
+)
+
+// SubscriptionEvent — запись журнала изменений подписки.
+// Журнал только дополняется: записи не меняются и не удаляются.
+type SubscriptionEvent struct {
+	ID             int64              `json:"id"`
+	SubscriptionID uuid.UUID          `json:"subscription_id"`
+	Action         SubscriptionAction `json:"action" enums:"create,update,delete,cancel,restore"`
+	Actor          string             `json:"actor" example:"alice@example.com"` // из заголовка X-Actor
+	RequestID      string             `json:"request_id"`                        // из заголовка X-Request-ID
+	OccurredAt     time.Time          `json:"occurred_at"`
+	Before         json.RawMessage    `json:"before" swaggertype:"object"` // подписка до изменения; null для create, для restore — удаленная подписка
+	After          json.RawMessage    `json:"after" swaggertype:"object"`  // подписка после изменения; null для delete
+}
+
+// SubscriptionEventPage — страница журнала изменений подписки
+type SubscriptionEventPage struct {
+	Items []SubscriptionEvent `json:"items"`
+	Total int                 `json:"total" example:"12"`
+	Page  int                 `json:"page" example:"1"`
+	Limit int                 `json:"limit" example:"10"`
+}
+
+// Problem — описание ошибки API в формате application/problem+json (RFC 7807)
+type Problem struct {
+	Type     string       `json:"type" example:"about:blank"`
+	Title    string       `json:"title" example:"Bad Request"`
+	Status   int          `json:"status" example:"400"`
+	Detail   string       `json:"detail,omitempty" example:"Invalid currency"`
+	Instance string       `json:"instance,omitempty" example:"/subscriptions"`
+	Errors   []FieldError `json:"errors,omitempty"` // ошибки проверки отдельных полей
+}
+
+// FieldError — ошибка проверки одного поля запроса
+type FieldError struct {
+	Field   string `json:"field" example:"currency"`
+	Code    string `json:"code" example:"invalid_value" enums:"required,invalid_format,invalid_value,out_of_range,unknown"`
+	Message string `json:"message" example:"Invalid currency"`
 }
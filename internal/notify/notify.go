@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reminder — напоминание пользователю о предстоящем списании
+type Reminder struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	ServiceName    string    `json:"service_name"`
+	ChargeDate     string    `json:"charge_date"` // формат "2006-01-02"
+	Amount         int       `json:"amount"`      // в минорных единицах валюты
+	Currency       string    `json:"currency"`
+	SentAt         time.Time `json:"sent_at"`
+}
+
+// Notifier доставляет напоминания пользователям
+type Notifier interface {
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// LogNotifier пишет напоминания в лог в формате JSON
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(_ context.Context, r Reminder) error {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	log.Printf("reminder: %s", payload)
+	return nil
+}
+
+// FileNotifier дописывает напоминания в файл, по одному JSON на строку.
+// Не требует сети, поэтому подходит для демо и тестовых стендов.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileNotifier создает FileNotifier, пишущий в path; файл создается при первой записи
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Notify(_ context.Context, r Reminder) error {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(payload, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var (
+	_ Notifier = LogNotifier{}
+	_ Notifier = (*FileNotifier)(nil)
+)
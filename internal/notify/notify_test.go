@@ -0,0 +1,42 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EvgenyiK/subscription-service/internal/notify"
+	"github.com/google/uuid"
+)
+
+func TestFileNotifierAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reminders.jsonl")
+	n := notify.NewFileNotifier(path)
+
+	first := notify.Reminder{SubscriptionID: uuid.New(), ServiceName: "Netflix", ChargeDate: "2025-07-10", Amount: 3100, Currency: "RUB"}
+	second := notify.Reminder{SubscriptionID: uuid.New(), ServiceName: "Spotify", ChargeDate: "2025-07-11", Amount: 200, Currency: "RUB"}
+	for _, r := range []notify.Reminder{first, second} {
+		if err := n.Notify(context.Background(), r); err != nil {
+			t.Fatalf("Notify: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("file has %d lines, want 2:\n%s", len(lines), data)
+	}
+	var got notify.Reminder
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+		t.Fatalf("decode %s: %v", lines[1], err)
+	}
+	if got.SubscriptionID != second.SubscriptionID || got.ChargeDate != "2025-07-11" {
+		t.Errorf("second line = %+v, want %+v", got, second)
+	}
+}
@@ -0,0 +1,161 @@
+package rates
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrUnknownCurrency возвращается, если для валюты нет курса
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// RateProvider отдает курсы валют на дату
+type RateProvider interface {
+	// Rate возвращает, сколько единиц валюты to стоит одна единица from
+	// на дату date, и дату публикации использованного курса
+	Rate(ctx context.Context, from, to string, date time.Time) (float64, time.Time, error)
+}
+
+//go:embed default_rates.json
+var defaultRates []byte
+
+// snapshot — курсы валют к базовой валюте на одну дату
+type snapshot struct {
+	date  time.Time
+	rates map[string]float64
+}
+
+// FileProvider читает курсы из JSON-файла и не требует сети.
+// Формат файла:
+//
+//	{"base": "RUB", "rates": {"2025-07-01": {"USD": 78.5, "EUR": 91.6}}}
+//
+// где значение — цена одной единицы валюты в базовой валюте.
+type FileProvider struct {
+	base      string
+	snapshots []snapshot // отсортированы по дате
+}
+
+// NewFileProvider загружает курсы из файла path.
+// Если path пустой, используются курсы, встроенные в бинарник.
+func NewFileProvider(path string) (*FileProvider, error) {
+	data := defaultRates
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parseRates(data)
+}
+
+func parseRates(data []byte) (*FileProvider, error) {
+	var raw struct {
+		Base  string                        `json:"base"`
+		Rates map[string]map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("rates: invalid file: %w", err)
+	}
+	if raw.Base == "" {
+		return nil, errors.New("rates: base currency is required")
+	}
+
+	p := &FileProvider{base: strings.ToUpper(raw.Base)}
+	for dateStr, values := range raw.Rates {
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			return nil, fmt.Errorf("rates: invalid date %q: %w", dateStr, err)
+		}
+		s := snapshot{date: date, rates: map[string]float64{p.base: 1}}
+		for code, v := range values {
+			if v <= 0 {
+				return nil, fmt.Errorf("rates: non-positive rate for %s on %s", code, dateStr)
+			}
+			s.rates[strings.ToUpper(code)] = v
+		}
+		p.snapshots = append(p.snapshots, s)
+	}
+	if len(p.snapshots) == 0 {
+		return nil, errors.New("rates: file contains no rates")
+	}
+
+	sort.Slice(p.snapshots, func(i, j int) bool {
+		return p.snapshots[i].date.Before(p.snapshots[j].date)
+	})
+	return p, nil
+}
+
+// Rate берет последний курс, опубликованный не позже date.
+// Для дат раньше первой публикации используется самый ранний курс.
+func (p *FileProvider) Rate(_ context.Context, from, to string, date time.Time) (float64, time.Time, error) {
+	s := p.snapshots[0]
+	for _, candidate := range p.snapshots {
+		if candidate.date.After(date) {
+			break
+		}
+		s = candidate
+	}
+
+	fromRate, ok := s.rates[strings.ToUpper(from)]
+	if !ok {
+		return 0, time.Time{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, from)
+	}
+	toRate, ok := s.rates[strings.ToUpper(to)]
+	if !ok {
+		return 0, time.Time{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+
+	return fromRate / toRate, s.date, nil
+}
+
+// minorUnits — число знаков после запятой для валют, где оно не равно 2
+var minorUnits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"CLP": 0,
+	"ISK": 0,
+	"KWD": 3,
+	"BHD": 3,
+	"OMR": 3,
+	"JOD": 3,
+	"TND": 3,
+}
+
+// MinorUnits возвращает число минорных разрядов валюты по ISO 4217
+func MinorUnits(currency string) int {
+	if n, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return n
+	}
+	return 2
+}
+
+// ConvertMinor переводит сумму в минорных единицах from в минорные единицы to
+func ConvertMinor(amount float64, from, to string, rate float64) float64 {
+	major := amount / math.Pow10(MinorUnits(from))
+	return major * rate * math.Pow10(MinorUnits(to))
+}
+
+// ValidCode проверяет, что строка похожа на код валюты ISO 4217
+func ValidCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+var _ RateProvider = (*FileProvider)(nil)
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/Masterminds/squirrel"
+)
+
+// BreakdownGroupBy — признак, по которому группируется стоимость подписок
+type BreakdownGroupBy string
+
+const (
+	GroupByService  BreakdownGroupBy = "service"
+	GroupByCategory BreakdownGroupBy = "category"
+	GroupByUser     BreakdownGroupBy = "user"
+)
+
+// Uncategorized — категория подписок вне каталога и сервисов без категории
+const Uncategorized = "uncategorized"
+
+// Valid сообщает, поддерживается ли группировка
+func (g BreakdownGroupBy) Valid() bool {
+	switch g {
+	case GroupByService, GroupByCategory, GroupByUser:
+		return true
+	}
+	return false
+}
+
+// CostGroup — стоимость группы подписок в одной валюте
+type CostGroup struct {
+	Key      string
+	Currency string
+	Total    float64 // в минорных единицах Currency
+}
+
+// breakdownKeys — выражение ключа группы для каждой группировки
+var breakdownKeys = map[BreakdownGroupBy]string{
+	GroupByService:  "s.service_name",
+	GroupByCategory: "COALESCE(NULLIF(sv.category, ''), '" + Uncategorized + "')",
+	GroupByUser:     "s.user_id::text",
+}
+
+// dailyCostSQL повторяет dailyRate для цены, действующей в день расчета.
+// Число дней месяца передается параметром.
+const dailyCostSQL = `CASE s.billing_period
+		WHEN 'weekly' THEN COALESCE(pc.price, s.price)::float8 / 7
+		WHEN 'quarterly' THEN COALESCE(pc.price, s.price)::float8 / 3 / ?
+		WHEN 'yearly' THEN COALESCE(pc.price, s.price)::float8 / 12 / ?
+		ELSE COALESCE(pc.price, s.price)::float8 / ?
+	END`
+
+// GetCostBreakdown считает стоимость подписок за день date по группам groupBy.
+// Правила те же, что в GetTotalSubscriptionCost: не оплачиваются дни паузы и
+// пробного периода, цена берется действующая в этот день. Суммирование идет в
+// базе, результат разбит по валютам подписок и упорядочен по ключу и валюте.
+func (r *Repository) GetCostBreakdown(ctx context.Context, date time.Time, groupBy BreakdownGroupBy, filter CostFilter) ([]CostGroup, error) {
+	key, ok := breakdownKeys[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported group_by %q", groupBy)
+	}
+
+	day := truncateDay(date)
+	days := daysInMonth(day)
+
+	queryBuilder := squirrel.Select(key+" AS group_key", "s.currency").
+		Column("SUM("+dailyCostSQL+") AS total", days, days, days).
+		From("subscriptions s").
+		LeftJoin("services sv ON sv.id = s.service_id").
+		JoinClause(`LEFT JOIN LATERAL (
+			SELECT p.price FROM subscription_prices p
+			WHERE p.subscription_id = s.id AND p.effective_from <= ?
+			ORDER BY p.effective_from DESC LIMIT 1
+		) pc ON true`, day).
+		Where(squirrel.Eq{"s.deleted_at": nil}).
+		Where(squirrel.LtOrEq{"s.start_date": day}).
+		Where(squirrel.Or{squirrel.Eq{"s.end_date": nil}, squirrel.GtOrEq{"s.end_date": day}}).
+		Where(squirrel.Or{squirrel.Eq{"s.trial_end": nil}, squirrel.LtOrEq{"s.trial_end": day}}).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM subscription_pauses pa
+			WHERE pa.subscription_id = s.id AND pa.start_date <= ? AND (pa.end_date IS NULL OR pa.end_date >= ?)
+		)`, day, day).
+		GroupBy("group_key", "s.currency").
+		OrderBy("group_key", "s.currency").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if filter.UserID != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"s.user_id": *filter.UserID})
+	}
+	if filter.ServiceName != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"s.service_name": filter.ServiceName})
+	}
+
+	sqlStr, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Printf("GetCostBreakdown: ошибка формирования SQL: %v", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, sqlStr, args...)
+	if err != nil {
+		log.Printf("GetCostBreakdown: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []CostGroup
+	for rows.Next() {
+		var g CostGroup
+		if err := rows.Scan(&g.Key, &g.Currency, &g.Total); err != nil {
+			log.Printf("GetCostBreakdown: ошибка сканирования строки: %v", err)
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("GetCostBreakdown: ошибка чтения результата: %v", err)
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// breakdownKey возвращает ключ группы подписки; category — категория её записи каталога
+func breakdownKey(sub *models.Subscription, groupBy BreakdownGroupBy, category string) string {
+	switch groupBy {
+	case GroupByCategory:
+		if category == "" {
+			return Uncategorized
+		}
+		return category
+	case GroupByUser:
+		return sub.UserID.String()
+	default:
+		return sub.ServiceName
+	}
+}
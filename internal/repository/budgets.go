@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// ErrBudgetNotFound возвращается, если у пользователя нет бюджета
+var ErrBudgetNotFound = errors.New("budget not found")
+
+// BudgetRepository хранит бюджеты пользователей и отправленные по ним уведомления
+type BudgetRepository interface {
+	// SetBudget создает бюджет пользователя или заменяет существующий
+	SetBudget(ctx context.Context, b *models.Budget) error
+	GetBudget(ctx context.Context, userID uuid.UUID) (*models.Budget, error)
+	DeleteBudget(ctx context.Context, userID uuid.UUID) error
+	ListBudgets(ctx context.Context) ([]models.Budget, error)
+	// BudgetAlertSent сообщает, уведомляли ли пользователя о пороге threshold в месяце month
+	BudgetAlertSent(ctx context.Context, userID uuid.UUID, month time.Time, threshold int) (bool, error)
+	// RecordBudgetAlert запоминает уведомление; повторная запись не ошибка
+	RecordBudgetAlert(ctx context.Context, userID uuid.UUID, month time.Time, threshold int, sentAt time.Time) error
+}
+
+// budgetColumns — порядок колонок, который ожидает scanBudget
+var budgetColumns = []string{"user_id", "monthly_limit", "currency", "thresholds", "updated_at"}
+
+func scanBudget(row pgx.Row, b *models.Budget) error {
+	return row.Scan(&b.UserID, &b.MonthlyLimit, &b.Currency, &b.Thresholds, &b.UpdatedAt)
+}
+
+// SetBudget создает бюджет пользователя или заменяет существующий
+func (r *Repository) SetBudget(ctx context.Context, b *models.Budget) error {
+	queryBuilder := squirrel.Insert("budgets").
+		Columns(budgetColumns...).
+		Values(b.UserID, b.MonthlyLimit, b.Currency, b.Thresholds, b.UpdatedAt).
+		Suffix(`ON CONFLICT (user_id) DO UPDATE SET
+			monthly_limit = EXCLUDED.monthly_limit,
+			currency = EXCLUDED.currency,
+			thresholds = EXCLUDED.thresholds,
+			updated_at = EXCLUDED.updated_at`).
+		PlaceholderFormat(squirrel.Dollar)
+
+	sqlStr, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Printf("SetBudget: ошибка формирования SQL: %v", err)
+		return err
+	}
+	if _, err := r.db.Exec(ctx, sqlStr, args...); err != nil {
+		log.Printf("SetBudget: ошибка выполнения SQL: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetBudget возвращает бюджет пользователя
+func (r *Repository) GetBudget(ctx context.Context, userID uuid.UUID) (*models.Budget, error) {
+	sqlStr, args, err := squirrel.Select(budgetColumns...).
+		From("budgets").
+		Where(squirrel.Eq{"user_id": userID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Printf("GetBudget: ошибка формирования SQL: %v", err)
+		return nil, err
+	}
+
+	var b models.Budget
+	err = scanBudget(r.db.QueryRow(ctx, sqlStr, args...), &b)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBudgetNotFound
+	}
+	if err != nil {
+		log.Printf("GetBudget: ошибка при сканировании результата: %v", err)
+		return nil, err
+	}
+	return &b, nil
+}
+
+// DeleteBudget удаляет бюджет пользователя вместе с историей уведомлений
+func (r *Repository) DeleteBudget(ctx context.Context, userID uuid.UUID) error {
+	cmdTag, err := r.db.Exec(ctx, "DELETE FROM budgets WHERE user_id = $1", userID)
+	if err != nil {
+		log.Printf("DeleteBudget: ошибка выполнения SQL: %v", err)
+		return err
+	}
+	if cmdTag.RowsAffected() != 1 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// ListBudgets возвращает все бюджеты, упорядоченные по пользователю
+func (r *Repository) ListBudgets(ctx context.Context) ([]models.Budget, error) {
+	sqlStr, args, err := squirrel.Select(budgetColumns...).
+		From("budgets").
+		OrderBy("user_id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Printf("ListBudgets: ошибка формирования SQL: %v", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, sqlStr, args...)
+	if err != nil {
+		log.Printf("ListBudgets: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []models.Budget
+	for rows.Next() {
+		var b models.Budget
+		if err := scanBudget(rows, &b); err != nil {
+			log.Printf("ListBudgets: ошибка сканирования строки: %v", err)
+			return nil, err
+		}
+		budgets = append(budgets, b)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("ListBudgets: ошибка чтения результата: %v", err)
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// BudgetAlertSent сообщает, уведомляли ли пользователя о пороге threshold в месяце month
+func (r *Repository) BudgetAlertSent(ctx context.Context, userID uuid.UUID, month time.Time, threshold int) (bool, error) {
+	var sent bool
+	err := r.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM budget_alerts WHERE user_id = $1 AND month = $2 AND threshold = $3)",
+		userID, firstOfMonth(month), threshold,
+	).Scan(&sent)
+	if err != nil {
+		log.Printf("BudgetAlertSent: ошибка выполнения запроса: %v", err)
+		return false, err
+	}
+	return sent, nil
+}
+
+// RecordBudgetAlert запоминает уведомление о пороге threshold в месяце month
+func (r *Repository) RecordBudgetAlert(ctx context.Context, userID uuid.UUID, month time.Time, threshold int, sentAt time.Time) error {
+	queryBuilder := squirrel.Insert("budget_alerts").
+		Columns("user_id", "month", "threshold", "sent_at").
+		Values(userID, firstOfMonth(month), threshold, sentAt).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar)
+
+	sqlStr, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Printf("RecordBudgetAlert: ошибка формирования SQL: %v", err)
+		return err
+	}
+	if _, err := r.db.Exec(ctx, sqlStr, args...); err != nil {
+		log.Printf("RecordBudgetAlert: ошибка выполнения SQL: %v", err)
+		return err
+	}
+	return nil
+}
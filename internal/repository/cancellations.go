@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+)
+
+// ErrAlreadyEnded возвращается при попытке отменить уже завершившуюся подписку
+var ErrAlreadyEnded = errors.New("subscription has already ended")
+
+// Cancel завершает подписку датой c.EffectiveDate и сохраняет запись об отмене,
+// если версия подписки равна version. Проверка состояния, обновление end_date,
+// запись об отмене и событие журнала выполняются в одной транзакции.
+func (r *Repository) Cancel(ctx context.Context, c *models.Cancellation, version int, change *Change) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("Cancel: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		endDate *time.Time
+		current int
+	)
+	err = tx.QueryRow(ctx,
+		"SELECT end_date, version FROM subscriptions WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", c.SubscriptionID,
+	).Scan(&endDate, &current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Cancel: ошибка чтения подписки: %v", err)
+		return err
+	}
+	if current != version {
+		return ErrVersionConflict
+	}
+	if endDate != nil && endDate.Before(truncateDay(c.CancelledAt)) {
+		return ErrAlreadyEnded
+	}
+
+	updateSQL, args, err := squirrel.Update("subscriptions").
+		Set("end_date", c.EffectiveDate).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": c.SubscriptionID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Printf("Cancel: ошибка формирования SQL: %v", err)
+		return err
+	}
+	if _, err := tx.Exec(ctx, updateSQL, args...); err != nil {
+		log.Printf("Cancel: ошибка обновления подписки: %v", err)
+		return err
+	}
+
+	insertSQL, args, err := squirrel.Insert("subscription_cancellations").
+		Columns("id", "subscription_id", "effective_date", "reason", "comment", "cancelled_at").
+		Values(c.ID, c.SubscriptionID, c.EffectiveDate, c.Reason, c.Comment, c.CancelledAt).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Printf("Cancel: ошибка формирования SQL: %v", err)
+		return err
+	}
+	if _, err := tx.Exec(ctx, insertSQL, args...); err != nil {
+		log.Printf("Cancel: ошибка записи отмены: %v", err)
+		return err
+	}
+	if err := recordChange(ctx, tx, change, models.ActionCancel, c.SubscriptionID, "Cancel"); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,1530 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// runConformance проверяет, что реализация Storage
+// ведет себя так же, как остальные. newRepo должен возвращать пустое хранилище.
+func runConformance(t *testing.T, newRepo func(t *testing.T) Storage) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo Storage)
+	}{
+		{"CreateAndGetByID", testCreateAndGetByID},
+		{"GetByIDNotFound", testGetByIDNotFound},
+		{"Update", testUpdate},
+		{"UpdateColumns", testUpdateColumns},
+		{"Versioning", testVersioning},
+		{"VersionBumps", testVersionBumps},
+		{"Delete", testDelete},
+		{"SoftDelete", testSoftDelete},
+		{"ListByUser", testListByUser},
+		{"ListSubscriptionsAfter", testListSubscriptionsAfter},
+		{"GetAllSubscriptionsFilters", testGetAllSubscriptionsFilters},
+		{"GetAllSubscriptionsSort", testGetAllSubscriptionsSort},
+		{"GetAllSubscriptionsInTrial", testGetAllSubscriptionsInTrial},
+		{"TotalCostProration", testTotalCostProration},
+		{"TotalCostFilters", testTotalCostFilters},
+		{"CostReport", testCostReport},
+		{"CostBreakdown", testCostBreakdown},
+		{"Cancel", testCancel},
+		{"PauseAndResume", testPauseAndResume},
+		{"PriceChanges", testPriceChanges},
+		{"TrialsEndingBetween", testTrialsEndingBetween},
+		{"SearchSubscriptions", testSearchSubscriptions},
+		{"ServiceCatalog", testServiceCatalog},
+		{"ServiceLinks", testServiceLinks},
+		{"Budgets", testBudgets},
+		{"UpcomingCharges", testUpcomingCharges},
+		{"Reminders", testReminders},
+		{"Events", testEvents},
+		{"ChangeEvents", testChangeEvents},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+func TestMemoryRepositoryConformance(t *testing.T) {
+	runConformance(t, func(t *testing.T) Storage {
+		return NewMemoryRepository()
+	})
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func datePtr(year int, month time.Month, day int) *time.Time {
+	d := date(year, month, day)
+	return &d
+}
+
+func newSub(userID uuid.UUID, service string, price int, start time.Time, end *time.Time) *models.Subscription {
+	return &models.Subscription{
+		ID:            uuid.New(),
+		ServiceName:   service,
+		Price:         price,
+		Currency:      models.DefaultCurrency,
+		BillingPeriod: models.BillingMonthly,
+		UserID:        userID,
+		StartDate:     start,
+		EndDate:       end,
+	}
+}
+
+func mustCreate(t *testing.T, repo Storage, sub *models.Subscription) {
+	t.Helper()
+	if err := repo.Create(context.Background(), sub, nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+}
+
+func assertClose(t *testing.T, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-6 {
+		t.Errorf("got %.6f, want %.6f", got, want)
+	}
+}
+
+func testCreateAndGetByID(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	sub := newSub(uuid.New(), "Netflix", 79900, date(2025, 7, 1), datePtr(2025, 12, 1))
+	sub.Currency = "USD"
+	sub.BillingPeriod = models.BillingYearly
+	sub.TrialEnd = datePtr(2025, 7, 15)
+	mustCreate(t, repo, sub)
+
+	got, err := repo.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ServiceName != sub.ServiceName || got.Price != sub.Price || got.Currency != sub.Currency ||
+		got.BillingPeriod != sub.BillingPeriod || got.UserID != sub.UserID {
+		t.Errorf("GetByID = %+v, want %+v", got, sub)
+	}
+	if !got.StartDate.Equal(sub.StartDate) || got.EndDate == nil || !got.EndDate.Equal(*sub.EndDate) {
+		t.Errorf("dates = %v..%v, want %v..%v", got.StartDate, got.EndDate, sub.StartDate, sub.EndDate)
+	}
+	if got.TrialEnd == nil || !got.TrialEnd.Equal(*sub.TrialEnd) {
+		t.Errorf("trial_end = %v, want %v", got.TrialEnd, sub.TrialEnd)
+	}
+
+	if err := repo.Create(ctx, sub, nil); err == nil {
+		t.Error("Create with duplicate id: expected error")
+	}
+}
+
+func testGetByIDNotFound(t *testing.T, repo Storage) {
+	if _, err := repo.GetByID(context.Background(), uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID = %v, want ErrNotFound", err)
+	}
+}
+
+func testUpdate(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	sub := newSub(uuid.New(), "Spotify", 16900, date(2025, 1, 1), nil)
+	mustCreate(t, repo, sub)
+
+	sub.ServiceName = "Spotify Family"
+	sub.Price = 26900
+	sub.EndDate = datePtr(2025, 6, 1)
+	if err := repo.Update(ctx, sub, nil); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ServiceName != "Spotify Family" || got.Price != 26900 || got.EndDate == nil || !got.EndDate.Equal(date(2025, 6, 1)) {
+		t.Errorf("after Update got %+v", got)
+	}
+
+	missing := newSub(uuid.New(), "Missing", 100, date(2025, 1, 1), nil)
+	if err := repo.Update(ctx, missing, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update missing = %v, want ErrNotFound", err)
+	}
+}
+
+func testUpdateColumns(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	sub := newSub(uuid.New(), "Spotify", 16900, date(2025, 1, 1), nil)
+	mustCreate(t, repo, sub)
+
+	changed := *sub
+	changed.ServiceName = "Spotify Family"
+	changed.Price = 26900
+	changed.EndDate = datePtr(2025, 6, 1)
+
+	columns := ChangedColumns(sub, &changed)
+	if want := []string{"service_name", "price", "end_date"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("ChangedColumns = %v, want %v", columns, want)
+	}
+	if columns := ChangedColumns(sub, sub); len(columns) != 0 {
+		t.Errorf("ChangedColumns of equal subscriptions = %v, want none", columns)
+	}
+
+	// Меняется только цена, остальные поля changed не сохраняются
+	if err := repo.Update(ctx, &changed, nil, "price"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := repo.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Price != 26900 || got.ServiceName != "Spotify" || got.EndDate != nil {
+		t.Errorf("after Update(price) got %+v", got)
+	}
+
+	if err := repo.Update(ctx, &changed, nil, "id"); err == nil {
+		t.Error("Update of unknown column succeeded")
+	}
+}
+
+func testVersioning(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	sub := newSub(uuid.New(), "Spotify", 16900, date(2025, 1, 1), nil)
+	mustCreate(t, repo, sub)
+	if sub.Version != 1 {
+		t.Fatalf("Version after Create = %d, want 1", sub.Version)
+	}
+
+	stale := *sub
+	sub.Price = 26900
+	if err := repo.Update(ctx, sub, nil, "price"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if sub.Version != 2 {
+		t.Errorf("Version after Update = %d, want 2", sub.Version)
+	}
+
+	// Запись по устаревшей версии не проходит и ничего не меняет
+	stale.Price = 100
+	if err := repo.Update(ctx, &stale, nil, "price"); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Update with stale version = %v, want ErrVersionConflict", err)
+	}
+	if err := repo.Delete(ctx, sub.ID, stale.Version, nil); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Delete with stale version = %v, want ErrVersionConflict", err)
+	}
+	got, err := repo.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Price != 26900 || got.Version != 2 {
+		t.Errorf("after stale writes got price %d, version %d; want 26900, 2", got.Price, got.Version)
+	}
+
+	// Отмена тоже меняет подписку и увеличивает версию
+	c := &models.Cancellation{
+		ID:             uuid.New(),
+		SubscriptionID: sub.ID,
+		EffectiveDate:  date(2025, 6, 30),
+		Reason:         models.ReasonNotUsed,
+		CancelledAt:    date(2025, 6, 1),
+	}
+	if err := repo.Cancel(ctx, c, 1, nil); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Cancel of stale version = %v, want ErrVersionConflict", err)
+	}
+	if err := repo.Cancel(ctx, c, 2, nil); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, sub.ID); got == nil || got.Version != 3 {
+		t.Errorf("Version after Cancel = %+v, want 3", got)
+	}
+}
+
+func testVersionBumps(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	svc := newService("Netflix", "nflx")
+	if err := repo.CreateService(ctx, svc); err != nil {
+		t.Fatalf("CreateService: %v", err)
+	}
+	sub := newSub(uuid.New(), "Netflix", 100, date(2025, 1, 1), nil)
+	sub.ServiceID = &svc.ID
+	mustCreate(t, repo, sub)
+
+	// Каждая запись, меняющая представление подписки, меняет и её версию
+	version := 1
+	steps := []struct {
+		name  string
+		write func() error
+	}{
+		{"Pause", func() error {
+			return repo.Pause(ctx, &models.Pause{ID: uuid.New(), SubscriptionID: sub.ID, StartDate: date(2025, 3, 1)})
+		}},
+		{"Resume", func() error {
+			return repo.Resume(ctx, sub.ID, date(2025, 3, 15))
+		}},
+		{"SchedulePriceChange", func() error {
+			return repo.SchedulePriceChange(ctx, &models.PriceChange{ID: uuid.New(), SubscriptionID: sub.ID, Price: 200, EffectiveFrom: date(2025, 6, 1)})
+		}},
+		{"UpdateService", func() error {
+			svc.Name = "Netflix Premium"
+			return repo.UpdateService(ctx, svc, nil)
+		}},
+		{"DeleteService", func() error {
+			return repo.DeleteService(ctx, svc.ID, nil)
+		}},
+	}
+	for _, step := range steps {
+		if err := step.write(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got, err := repo.GetByID(ctx, sub.ID)
+		if err != nil {
+			t.Fatalf("GetByID after %s: %v", step.name, err)
+		}
+		if got.Version <= version {
+			t.Errorf("version after %s = %d, want more than %d", step.name, got.Version, version)
+		}
+		version = got.Version
+	}
+}
+
+func testDelete(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	sub := newSub(uuid.New(), "YouTube", 29900, date(2025, 1, 1), nil)
+	mustCreate(t, repo, sub)
+
+	if err := repo.Delete(ctx, sub.ID, sub.Version, nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, sub.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID after Delete = %v, want ErrNotFound", err)
+	}
+	if err := repo.Delete(ctx, sub.ID, sub.Version, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func testSoftDelete(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user := uuid.New()
+	deleted := newSub(user, "YouTube", 29900, date(2025, 1, 1), nil)
+	kept := newSub(user, "Netflix", 59900, date(2025, 2, 1), nil)
+	mustCreate(t, repo, deleted)
+	mustCreate(t, repo, kept)
+
+	if err := repo.Delete(ctx, deleted.ID, deleted.Version, nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// Удаленная подписка не попадает ни в выборки, ни в расчеты
+	filter := ListFilter{UserID: &user}
+	subs, err := repo.GetAllSubscriptions(ctx, 10, 0, filter)
+	if err != nil {
+		t.Fatalf("GetAllSubscriptions: %v", err)
+	}
+	if len(subs) != 1 || subs[0].ID != kept.ID {
+		t.Errorf("GetAllSubscriptions = %+v, want only %s", subs, kept.ID)
+	}
+	if count, _ := repo.CountSubscriptions(ctx, filter); count != 1 {
+		t.Errorf("CountSubscriptions = %d, want 1", count)
+	}
+	if subs, _ := repo.ListByUser(ctx, user, 10, 0); len(subs) != 1 {
+		t.Errorf("ListByUser returned %d subscriptions, want 1", len(subs))
+	}
+	totals, err := repo.GetTotalSubscriptionCost(ctx, date(2025, 6, 1), true, user, "")
+	if err != nil {
+		t.Fatalf("GetTotalSubscriptionCost: %v", err)
+	}
+	assertClose(t, totals["RUB"], 59900.0/30)
+	if results, _ := repo.SearchSubscriptions(ctx, "YouTube", &user, 10); len(results) != 0 {
+		t.Errorf("SearchSubscriptions found deleted subscription: %+v", results)
+	}
+	pause := &models.Pause{ID: uuid.New(), SubscriptionID: deleted.ID, StartDate: date(2025, 6, 1)}
+	if err := repo.Pause(ctx, pause); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Pause of deleted subscription = %v, want ErrNotFound", err)
+	}
+
+	// С IncludeDeleted удаленная подписка видна вместе с моментом удаления
+	filter.IncludeDeleted = true
+	subs, err = repo.GetAllSubscriptions(ctx, 10, 0, filter)
+	if err != nil {
+		t.Fatalf("GetAllSubscriptions: %v", err)
+	}
+	if len(subs) != 2 || subs[0].ID != deleted.ID || subs[0].DeletedAt == nil {
+		t.Errorf("GetAllSubscriptions(IncludeDeleted) = %+v", subs)
+	}
+
+	// Удаленную подписку можно прочитать только через GetDeleted
+	if _, err := repo.GetDeleted(ctx, kept.ID); !errors.Is(err, ErrNotDeleted) {
+		t.Errorf("GetDeleted of live subscription = %v, want ErrNotDeleted", err)
+	}
+	if _, err := repo.GetDeleted(ctx, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetDeleted of missing subscription = %v, want ErrNotFound", err)
+	}
+	gone, err := repo.GetDeleted(ctx, deleted.ID)
+	if err != nil {
+		t.Fatalf("GetDeleted: %v", err)
+	}
+	if gone.DeletedAt == nil || gone.Version != 2 {
+		t.Errorf("GetDeleted = %+v, want version 2 with deleted_at", gone)
+	}
+
+	// Восстановление возвращает подписку и увеличивает версию
+	if err := repo.Restore(ctx, kept.ID, kept.Version, nil); !errors.Is(err, ErrNotDeleted) {
+		t.Errorf("Restore of live subscription = %v, want ErrNotDeleted", err)
+	}
+	if err := repo.Restore(ctx, uuid.New(), 1, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Restore of missing subscription = %v, want ErrNotFound", err)
+	}
+	if err := repo.Restore(ctx, deleted.ID, gone.Version-1, nil); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Restore of stale version = %v, want ErrVersionConflict", err)
+	}
+	if err := repo.Restore(ctx, deleted.ID, gone.Version, nil); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	got, err := repo.GetByID(ctx, deleted.ID)
+	if err != nil {
+		t.Fatalf("GetByID after Restore: %v", err)
+	}
+	if got.DeletedAt != nil || got.Version != 3 {
+		t.Errorf("after Restore got %+v, want version 3 without deleted_at", got)
+	}
+
+	// Очищаются только подписки, удаленные раньше границы
+	if err := repo.Delete(ctx, got.ID, got.Version, nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n, err := repo.PurgeDeleted(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("PurgeDeleted(hour ago) = %d, %v; want 0", n, err)
+	}
+	if n, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Hour)); err != nil || n != 1 {
+		t.Errorf("PurgeDeleted(in an hour) = %d, %v; want 1", n, err)
+	}
+	if err := repo.Restore(ctx, deleted.ID, got.Version+1, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Restore of purged subscription = %v, want ErrNotFound", err)
+	}
+}
+
+func testListByUser(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user, other := uuid.New(), uuid.New()
+
+	third := newSub(user, "C", 100, date(2025, 3, 1), nil)
+	first := newSub(user, "A", 100, date(2025, 1, 1), nil)
+	second := newSub(user, "B", 100, date(2025, 2, 1), nil)
+	for _, s := range []*models.Subscription{third, first, second, newSub(other, "X", 100, date(2025, 1, 1), nil)} {
+		mustCreate(t, repo, s)
+	}
+
+	all, err := repo.ListByUser(ctx, user, 0, 0)
+	if err != nil {
+		t.Fatalf("ListByUser: %v", err)
+	}
+	wantOrder := []uuid.UUID{first.ID, second.ID, third.ID}
+	if len(all) != len(wantOrder) {
+		t.Fatalf("ListByUser returned %d subscriptions, want %d", len(all), len(wantOrder))
+	}
+	for i, id := range wantOrder {
+		if all[i].ID != id {
+			t.Errorf("ListByUser[%d] = %s, want %s", i, all[i].ServiceName, []string{"A", "B", "C"}[i])
+		}
+	}
+
+	page, err := repo.ListByUser(ctx, user, 1, 1)
+	if err != nil {
+		t.Fatalf("ListByUser page: %v", err)
+	}
+	if len(page) != 1 || page[0].ID != second.ID {
+		t.Errorf("ListByUser(limit=1, offset=1) = %v, want [B]", page)
+	}
+
+	empty, err := repo.ListByUser(ctx, uuid.New(), 10, 0)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("ListByUser unknown user = %v, %v; want empty", empty, err)
+	}
+}
+
+func testListSubscriptionsAfter(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user := uuid.New()
+
+	// Две подписки с одной датой начала упорядочиваются по id
+	sameDayA := newSub(user, "A", 100, date(2025, 2, 1), nil)
+	sameDayA.ID = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	sameDayB := newSub(user, "B", 100, date(2025, 2, 1), nil)
+	sameDayB.ID = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	first := newSub(user, "First", 100, date(2025, 1, 1), nil)
+	last := newSub(user, "Last", 100, date(2025, 3, 1), nil)
+	last.TrialEnd = datePtr(2025, 12, 1)
+	for _, s := range []*models.Subscription{sameDayB, last, first, sameDayA} {
+		mustCreate(t, repo, s)
+	}
+
+	page, err := repo.ListSubscriptionsAfter(ctx, nil, 2, ListFilter{})
+	if err != nil {
+		t.Fatalf("ListSubscriptionsAfter: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != first.ID || page[1].ID != sameDayA.ID {
+		t.Fatalf("first page = %v, want [First A]", page)
+	}
+
+	cursor := &ListCursor{StartDate: page[1].StartDate, ID: page[1].ID}
+	page, err = repo.ListSubscriptionsAfter(ctx, cursor, 10, ListFilter{})
+	if err != nil {
+		t.Fatalf("ListSubscriptionsAfter: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != sameDayB.ID || page[1].ID != last.ID {
+		t.Errorf("after A = %v, want [B Last]", page)
+	}
+
+	yes := true
+	page, err = repo.ListSubscriptionsAfter(ctx, cursor, 10, ListFilter{InTrial: &yes, Today: date(2025, 6, 1)})
+	if err != nil {
+		t.Fatalf("ListSubscriptionsAfter: %v", err)
+	}
+	if len(page) != 1 || page[0].ID != last.ID {
+		t.Errorf("after A in trial = %v, want [Last]", page)
+	}
+
+	cursor = &ListCursor{StartDate: last.StartDate, ID: last.ID}
+	page, err = repo.ListSubscriptionsAfter(ctx, cursor, 10, ListFilter{})
+	if err != nil || len(page) != 0 {
+		t.Errorf("after Last = %v, %v; want empty", page, err)
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func testGetAllSubscriptionsFilters(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user, other := uuid.New(), uuid.New()
+
+	netflix := newSub(user, "Netflix", 3100, date(2025, 1, 1), nil)
+	netflixFamily := newSub(other, "Netflix Family", 6200, date(2025, 3, 1), datePtr(2025, 5, 31))
+	underscore := newSub(user, "Net_flix", 1000, date(2025, 6, 1), nil)
+	spotify := newSub(other, "Spotify", 500, date(2025, 2, 1), nil)
+	for _, s := range []*models.Subscription{netflix, netflixFamily, underscore, spotify} {
+		mustCreate(t, repo, s)
+	}
+
+	tests := []struct {
+		name   string
+		filter ListFilter
+		want   []uuid.UUID
+	}{
+		{"user", ListFilter{UserID: &user}, []uuid.UUID{netflix.ID, underscore.ID}},
+		{"service exact", ListFilter{ServiceName: "Netflix"}, []uuid.UUID{netflix.ID}},
+		{"service prefix", ListFilter{ServiceNamePrefix: "Netflix"}, []uuid.UUID{netflix.ID, netflixFamily.ID}},
+		{"prefix escapes wildcards", ListFilter{ServiceNamePrefix: "Net_"}, []uuid.UUID{underscore.ID}},
+		{"min price", ListFilter{MinPrice: intPtr(3100)}, []uuid.UUID{netflix.ID, netflixFamily.ID}},
+		{"price range", ListFilter{MinPrice: intPtr(500), MaxPrice: intPtr(1000)}, []uuid.UUID{spotify.ID, underscore.ID}},
+		{"active on", ListFilter{ActiveOn: datePtr(2025, 4, 15)}, []uuid.UUID{netflix.ID, spotify.ID, netflixFamily.ID}},
+		{"active on after end", ListFilter{ActiveOn: datePtr(2025, 6, 1)}, []uuid.UUID{netflix.ID, spotify.ID, underscore.ID}},
+		{"start range", ListFilter{StartFrom: datePtr(2025, 2, 1), StartTo: datePtr(2025, 3, 1)}, []uuid.UUID{spotify.ID, netflixFamily.ID}},
+		{"combined", ListFilter{UserID: &other, ServiceNamePrefix: "Net", ActiveOn: datePtr(2025, 3, 1)}, []uuid.UUID{netflixFamily.ID}},
+	}
+
+	for _, tt := range tests {
+		subs, err := repo.GetAllSubscriptions(ctx, 0, 0, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: GetAllSubscriptions: %v", tt.name, err)
+		}
+		if len(subs) != len(tt.want) {
+			t.Errorf("%s: got %d subscriptions, want %d", tt.name, len(subs), len(tt.want))
+			continue
+		}
+		for i, id := range tt.want {
+			if subs[i].ID != id {
+				t.Errorf("%s: subscriptions[%d] = %s, want another", tt.name, i, subs[i].ServiceName)
+			}
+		}
+
+		count, err := repo.CountSubscriptions(ctx, tt.filter)
+		if err != nil || count != len(tt.want) {
+			t.Errorf("%s: CountSubscriptions = %d, %v; want %d", tt.name, count, err, len(tt.want))
+		}
+	}
+}
+
+func testGetAllSubscriptionsSort(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user := uuid.New()
+
+	a := newSub(user, "A", 300, date(2025, 1, 1), datePtr(2025, 6, 30))
+	b := newSub(user, "B", 100, date(2025, 2, 1), nil)
+	c := newSub(user, "C", 300, date(2025, 3, 1), datePtr(2025, 4, 30))
+	for _, s := range []*models.Subscription{a, b, c} {
+		mustCreate(t, repo, s)
+	}
+
+	tests := []struct {
+		name string
+		sort []SortField
+		want []string
+	}{
+		{"default", nil, []string{"A", "B", "C"}},
+		{"price then start_date", []SortField{{Field: "price"}, {Field: "start_date"}}, []string{"B", "A", "C"}},
+		{"price desc then name desc", []SortField{{Field: "price", Desc: true}, {Field: "service_name", Desc: true}}, []string{"C", "A", "B"}},
+		// Пустой end_date идет последним при возрастании и первым при убывании
+		{"end_date asc", []SortField{{Field: "end_date"}}, []string{"C", "A", "B"}},
+		{"end_date desc", []SortField{{Field: "end_date", Desc: true}}, []string{"B", "A", "C"}},
+	}
+
+	for _, tt := range tests {
+		subs, err := repo.GetAllSubscriptions(ctx, 0, 0, ListFilter{Sort: tt.sort})
+		if err != nil {
+			t.Fatalf("%s: GetAllSubscriptions: %v", tt.name, err)
+		}
+		got := make([]string, len(subs))
+		for i := range subs {
+			got[i] = subs[i].ServiceName
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: order = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	page, err := repo.GetAllSubscriptions(ctx, 1, 1, ListFilter{Sort: []SortField{{Field: "price", Desc: true}}})
+	if err != nil || len(page) != 1 {
+		t.Fatalf("GetAllSubscriptions page = %v, %v", page, err)
+	}
+	// При равной цене порядок задает id, поэтому вторая страница детерминирована
+	wantSecond := a
+	if bytes.Compare(c.ID[:], a.ID[:]) > 0 {
+		wantSecond = c
+	}
+	if page[0].ID != wantSecond.ID {
+		t.Errorf("second page = %s, want %s", page[0].ServiceName, wantSecond.ServiceName)
+	}
+}
+
+func testGetAllSubscriptionsInTrial(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	today := date(2025, 7, 10)
+
+	trial := newSub(uuid.New(), "Trial", 100, date(2025, 7, 1), nil)
+	trial.TrialEnd = datePtr(2025, 7, 20)
+	converted := newSub(uuid.New(), "Converted", 100, date(2025, 6, 1), nil)
+	converted.TrialEnd = datePtr(2025, 7, 10)
+	paid := newSub(uuid.New(), "Paid", 100, date(2025, 5, 1), nil)
+	for _, s := range []*models.Subscription{trial, converted, paid} {
+		mustCreate(t, repo, s)
+	}
+
+	yes, no := true, false
+	inTrial, err := repo.GetAllSubscriptions(ctx, 0, 0, ListFilter{InTrial: &yes, Today: today})
+	if err != nil {
+		t.Fatalf("GetAllSubscriptions: %v", err)
+	}
+	if len(inTrial) != 1 || inTrial[0].ID != trial.ID {
+		t.Errorf("in_trial=true returned %d subscriptions, want only Trial", len(inTrial))
+	}
+
+	notInTrial, err := repo.GetAllSubscriptions(ctx, 0, 0, ListFilter{InTrial: &no, Today: today})
+	if err != nil {
+		t.Fatalf("GetAllSubscriptions: %v", err)
+	}
+	if len(notInTrial) != 2 {
+		t.Errorf("in_trial=false returned %d subscriptions, want 2", len(notInTrial))
+	}
+
+	all, err := repo.GetAllSubscriptions(ctx, 0, 0, ListFilter{})
+	if err != nil || len(all) != 3 {
+		t.Errorf("GetAllSubscriptions without filter = %d, %v; want 3", len(all), err)
+	}
+
+	for _, tc := range []struct {
+		filter ListFilter
+		want   int
+	}{
+		{ListFilter{}, 3},
+		{ListFilter{InTrial: &yes, Today: today}, 1},
+		{ListFilter{InTrial: &no, Today: today}, 2},
+	} {
+		count, err := repo.CountSubscriptions(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("CountSubscriptions: %v", err)
+		}
+		if count != tc.want {
+			t.Errorf("CountSubscriptions(%+v) = %d, want %d", tc.filter, count, tc.want)
+		}
+	}
+}
+
+func testTotalCostProration(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user := uuid.New()
+
+	// Июль — 31 день: месячная цена 3100 дает 100 в день
+	monthly := newSub(user, "Monthly", 3100, date(2025, 1, 1), nil)
+	yearly := newSub(user, "Yearly", 12*3100, date(2025, 1, 1), nil)
+	yearly.BillingPeriod = models.BillingYearly
+	weekly := newSub(user, "Weekly", 700, date(2025, 1, 1), datePtr(2025, 12, 31))
+	weekly.BillingPeriod = models.BillingWeekly
+	quarterly := newSub(user, "Quarterly", 3*3100, date(2025, 1, 1), nil)
+	quarterly.BillingPeriod = models.BillingQuarterly
+	ended := newSub(user, "Ended", 3100, date(2025, 1, 1), datePtr(2025, 6, 30))
+	usd := newSub(user, "USD", 3100, date(2025, 1, 1), nil)
+	usd.Currency = "USD"
+	for _, s := range []*models.Subscription{monthly, yearly, weekly, quarterly, ended, usd} {
+		mustCreate(t, repo, s)
+	}
+
+	totals, err := repo.GetTotalSubscriptionCost(ctx, date(2025, 7, 15), false, uuid.Nil, "")
+	if err != nil {
+		t.Fatalf("GetTotalSubscriptionCost: %v", err)
+	}
+	assertClose(t, totals["RUB"], 400)
+	assertClose(t, totals["USD"], 100)
+}
+
+func testTotalCostFilters(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user, other := uuid.New(), uuid.New()
+	for _, s := range []*models.Subscription{
+		newSub(user, "Netflix", 3100, date(2025, 1, 1), nil),
+		newSub(user, "Spotify", 6200, date(2025, 1, 1), nil),
+		newSub(other, "Netflix", 9300, date(2025, 1, 1), nil),
+	} {
+		mustCreate(t, repo, s)
+	}
+
+	day := date(2025, 7, 1)
+	byUser, err := repo.GetTotalSubscriptionCost(ctx, day, true, user, "")
+	if err != nil {
+		t.Fatalf("GetTotalSubscriptionCost: %v", err)
+	}
+	assertClose(t, byUser["RUB"], 300)
+
+	byService, err := repo.GetTotalSubscriptionCost(ctx, day, false, uuid.Nil, "Netflix")
+	if err != nil {
+		t.Fatalf("GetTotalSubscriptionCost: %v", err)
+	}
+	assertClose(t, byService["RUB"], 400)
+
+	both, err := repo.GetTotalSubscriptionCost(ctx, day, true, user, "Netflix")
+	if err != nil {
+		t.Fatalf("GetTotalSubscriptionCost: %v", err)
+	}
+	assertClose(t, both["RUB"], 100)
+}
+
+func testCostReport(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user := uuid.New()
+	full := newSub(user, "Full", 3000, date(2025, 1, 1), nil)
+	// Начинается 16 июня: в июне 15 дней из 30
+	half := newSub(user, "Half", 3000, date(2025, 6, 16), datePtr(2025, 7, 31))
+	mustCreate(t, repo, full)
+	mustCreate(t, repo, half)
+	mustCreate(t, repo, newSub(uuid.New(), "Other user", 3000, date(2025, 1, 1), nil))
+
+	months, err := repo.GetCostReport(ctx, date(2025, 6, 1), date(2025, 8, 31), CostFilter{UserID: &user})
+	if err != nil {
+		t.Fatalf("GetCostReport: %v", err)
+	}
+	if len(months) != 3 {
+		t.Fatalf("GetCostReport returned %d months, want 3", len(months))
+	}
+
+	want := []struct {
+		month string
+		items int
+		total float64
+	}{
+		{"2025-06", 2, 4500},
+		{"2025-07", 2, 6000},
+		{"2025-08", 1, 3000},
+	}
+	for i, w := range want {
+		m := months[i]
+		if m.Month != w.month || len(m.Subscriptions) != w.items {
+			t.Errorf("month %d = %s with %d items, want %s with %d", i, m.Month, len(m.Subscriptions), w.month, w.items)
+			continue
+		}
+		var total float64
+		for _, item := range m.Subscriptions {
+			total += item.Cost
+		}
+		assertClose(t, total, w.total)
+	}
+}
+
+func testCancel(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 10, 12, 0, 0, 0, time.UTC)
+	sub := newSub(uuid.New(), "Netflix", 3100, date(2025, 1, 1), nil)
+	mustCreate(t, repo, sub)
+
+	c := &models.Cancellation{
+		ID:             uuid.New(),
+		SubscriptionID: sub.ID,
+		EffectiveDate:  date(2025, 7, 31),
+		Reason:         models.ReasonNotUsed,
+		CancelledAt:    now,
+	}
+	if err := repo.Cancel(ctx, c, sub.Version, nil); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	got, err := repo.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.EndDate == nil || !got.EndDate.Equal(date(2025, 7, 31)) {
+		t.Errorf("end_date after Cancel = %v, want 2025-07-31", got.EndDate)
+	}
+
+	ended := newSub(uuid.New(), "Old", 3100, date(2024, 1, 1), datePtr(2025, 1, 1))
+	mustCreate(t, repo, ended)
+	c = &models.Cancellation{ID: uuid.New(), SubscriptionID: ended.ID, EffectiveDate: now, Reason: models.ReasonOther, CancelledAt: now}
+	if err := repo.Cancel(ctx, c, ended.Version, nil); !errors.Is(err, ErrAlreadyEnded) {
+		t.Errorf("Cancel ended = %v, want ErrAlreadyEnded", err)
+	}
+
+	c = &models.Cancellation{ID: uuid.New(), SubscriptionID: uuid.New(), EffectiveDate: now, Reason: models.ReasonOther, CancelledAt: now}
+	if err := repo.Cancel(ctx, c, 1, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Cancel missing = %v, want ErrNotFound", err)
+	}
+}
+
+func testPauseAndResume(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	sub := newSub(uuid.New(), "Gym", 3100, date(2025, 1, 1), nil)
+	mustCreate(t, repo, sub)
+
+	pause := &models.Pause{ID: uuid.New(), SubscriptionID: sub.ID, StartDate: date(2025, 7, 11)}
+	if err := repo.Pause(ctx, pause); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	overlapping := &models.Pause{ID: uuid.New(), SubscriptionID: sub.ID, StartDate: date(2025, 8, 1), EndDate: datePtr(2025, 8, 5)}
+	if err := repo.Pause(ctx, overlapping); !errors.Is(err, ErrAlreadyPaused) {
+		t.Errorf("overlapping Pause = %v, want ErrAlreadyPaused", err)
+	}
+
+	// Возобновляем 21 июля: на паузе 11–20 июля, 10 дней из 31
+	if err := repo.Resume(ctx, sub.ID, date(2025, 7, 21)); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if err := repo.Resume(ctx, sub.ID, date(2025, 7, 22)); !errors.Is(err, ErrNotPaused) {
+		t.Errorf("second Resume = %v, want ErrNotPaused", err)
+	}
+
+	got, err := repo.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if len(got.Pauses) != 1 || got.Pauses[0].EndDate == nil || !got.Pauses[0].EndDate.Equal(date(2025, 7, 20)) {
+		t.Fatalf("pauses after Resume = %+v, want one pause ending 2025-07-20", got.Pauses)
+	}
+
+	paused, err := repo.GetTotalSubscriptionCost(ctx, date(2025, 7, 15), false, uuid.Nil, "")
+	if err != nil {
+		t.Fatalf("GetTotalSubscriptionCost: %v", err)
+	}
+	assertClose(t, paused["RUB"], 0)
+
+	months, err := repo.GetCostReport(ctx, date(2025, 7, 1), date(2025, 7, 31), CostFilter{})
+	if err != nil {
+		t.Fatalf("GetCostReport: %v", err)
+	}
+	assertClose(t, months[0].Subscriptions[0].Cost, 2100)
+
+	// Пауза, которая не успела начаться, при возобновлении удаляется
+	future := &models.Pause{ID: uuid.New(), SubscriptionID: sub.ID, StartDate: date(2025, 9, 1)}
+	if err := repo.Pause(ctx, future); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if err := repo.Resume(ctx, sub.ID, date(2025, 9, 1)); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	got, _ = repo.GetByID(ctx, sub.ID)
+	if len(got.Pauses) != 1 {
+		t.Errorf("pauses after resuming unstarted pause = %d, want 1", len(got.Pauses))
+	}
+
+	if err := repo.Resume(ctx, uuid.New(), date(2025, 9, 1)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resume missing = %v, want ErrNotFound", err)
+	}
+}
+
+func testPriceChanges(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	sub := newSub(uuid.New(), "Netflix", 3100, date(2025, 1, 1), nil)
+	mustCreate(t, repo, sub)
+
+	change := &models.PriceChange{ID: uuid.New(), SubscriptionID: sub.ID, Price: 9300, EffectiveFrom: date(2025, 7, 1)}
+	if err := repo.SchedulePriceChange(ctx, change); err != nil {
+		t.Fatalf("SchedulePriceChange: %v", err)
+	}
+	// Повторное изменение с той же даты заменяет цену
+	change = &models.PriceChange{ID: uuid.New(), SubscriptionID: sub.ID, Price: 6200, EffectiveFrom: date(2025, 7, 1)}
+	if err := repo.SchedulePriceChange(ctx, change); err != nil {
+		t.Fatalf("SchedulePriceChange: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if len(got.PriceChanges) != 1 || got.PriceChanges[0].Price != 6200 {
+		t.Errorf("price changes = %+v, want one change to 6200", got.PriceChanges)
+	}
+
+	before, _ := repo.GetTotalSubscriptionCost(ctx, date(2025, 6, 15), false, uuid.Nil, "")
+	after, _ := repo.GetTotalSubscriptionCost(ctx, date(2025, 7, 15), false, uuid.Nil, "")
+	assertClose(t, before["RUB"], 3100.0/30)
+	assertClose(t, after["RUB"], 200)
+
+	missing := &models.PriceChange{ID: uuid.New(), SubscriptionID: uuid.New(), Price: 100, EffectiveFrom: date(2025, 7, 1)}
+	if err := repo.SchedulePriceChange(ctx, missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SchedulePriceChange missing = %v, want ErrNotFound", err)
+	}
+}
+
+func testTrialsEndingBetween(t *testing.T, repo Storage) {
+	ctx := context.Background()
+
+	soon := newSub(uuid.New(), "Soon", 100, date(2025, 7, 1), nil)
+	soon.TrialEnd = datePtr(2025, 7, 12)
+	later := newSub(uuid.New(), "Later", 100, date(2025, 7, 1), nil)
+	later.TrialEnd = datePtr(2025, 8, 1)
+	cancelled := newSub(uuid.New(), "Cancelled", 100, date(2025, 7, 1), datePtr(2025, 7, 5))
+	cancelled.TrialEnd = datePtr(2025, 7, 11)
+	for _, s := range []*models.Subscription{soon, later, cancelled} {
+		mustCreate(t, repo, s)
+	}
+
+	subs, err := repo.ListTrialsEndingBetween(ctx, date(2025, 7, 10), date(2025, 7, 13))
+	if err != nil {
+		t.Fatalf("ListTrialsEndingBetween: %v", err)
+	}
+	if len(subs) != 1 || subs[0].ID != soon.ID {
+		t.Errorf("ListTrialsEndingBetween returned %d subscriptions, want only Soon", len(subs))
+	}
+}
+
+func testSearchSubscriptions(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user, other := uuid.New(), uuid.New()
+
+	exact := newSub(user, "Netflix", 100, date(2025, 1, 1), nil)
+	premium := newSub(other, "netflix premium", 100, date(2025, 1, 1), nil)
+	typo := newSub(user, "Netfix", 100, date(2025, 1, 1), nil)
+	for _, s := range []*models.Subscription{
+		exact, premium, typo,
+		newSub(user, "Spotify", 100, date(2025, 1, 1), nil),
+		newSub(user, "Kinopoisk", 100, date(2025, 1, 1), nil),
+	} {
+		mustCreate(t, repo, s)
+	}
+
+	results, err := repo.SearchSubscriptions(ctx, "NETFLIX", nil, 0)
+	if err != nil {
+		t.Fatalf("SearchSubscriptions: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("SearchSubscriptions returned %d results, want 3", len(results))
+	}
+	if results[0].ID != exact.ID {
+		t.Errorf("best match = %s, want Netflix", results[0].ServiceName)
+	}
+	assertClose(t, results[0].Relevance, 1)
+	for i := 1; i < len(results); i++ {
+		if results[i].Relevance > results[i-1].Relevance {
+			t.Errorf("results are not ordered by relevance: %v", results)
+		}
+	}
+
+	scoped, err := repo.SearchSubscriptions(ctx, "netflix", &other, 0)
+	if err != nil {
+		t.Fatalf("SearchSubscriptions: %v", err)
+	}
+	if len(scoped) != 1 || scoped[0].ID != premium.ID {
+		t.Errorf("scoped search = %v, want only netflix premium", scoped)
+	}
+
+	limited, err := repo.SearchSubscriptions(ctx, "netflix", nil, 1)
+	if err != nil || len(limited) != 1 {
+		t.Errorf("limited search = %d results, %v; want 1", len(limited), err)
+	}
+
+	none, err := repo.SearchSubscriptions(ctx, "youtube", nil, 0)
+	if err != nil || len(none) != 0 {
+		t.Errorf("search without matches = %v, %v; want empty", none, err)
+	}
+}
+
+func newService(name string, aliases ...string) *models.Service {
+	return &models.Service{
+		ID:            uuid.New(),
+		Name:          name,
+		Aliases:       aliases,
+		Currency:      models.DefaultCurrency,
+		BillingPeriod: models.BillingMonthly,
+	}
+}
+
+func testServiceCatalog(t *testing.T, repo Storage) {
+	ctx := context.Background()
+
+	netflix := newService("Netflix", "nflx", "netflix.com")
+	netflix.Category = "video"
+	netflix.DefaultPrice = intPtr(79900)
+	spotify := newService("Spotify")
+	spotify.Category = "music"
+	for _, svc := range []*models.Service{netflix, spotify} {
+		if err := repo.CreateService(ctx, svc); err != nil {
+			t.Fatalf("CreateService: %v", err)
+		}
+	}
+
+	got, err := repo.GetService(ctx, netflix.ID)
+	if err != nil {
+		t.Fatalf("GetService: %v", err)
+	}
+	if got.Name != "Netflix" || len(got.Aliases) != 2 || got.DefaultPrice == nil || *got.DefaultPrice != 79900 {
+		t.Errorf("GetService = %+v", got)
+	}
+	if _, err := repo.GetService(ctx, uuid.New()); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("GetService(missing) = %v, want ErrServiceNotFound", err)
+	}
+
+	for _, name := range []string{"NETFLIX", "nflx", " Netflix.com "} {
+		resolved, err := repo.ResolveService(ctx, name)
+		if err != nil || resolved.ID != netflix.ID {
+			t.Errorf("ResolveService(%q) = %v, %v; want Netflix", name, resolved, err)
+		}
+	}
+	if _, err := repo.ResolveService(ctx, "youtube"); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("ResolveService(unknown) = %v, want ErrServiceNotFound", err)
+	}
+
+	// Название и псевдонимы уникальны во всем каталоге
+	for _, dup := range []*models.Service{newService("netflix"), newService("NFLX"), newService("Music", "spotify")} {
+		if err := repo.CreateService(ctx, dup); !errors.Is(err, ErrServiceConflict) {
+			t.Errorf("CreateService(%s) = %v, want ErrServiceConflict", dup.Name, err)
+		}
+	}
+	spotify.Aliases = []string{"nflx"}
+	if err := repo.UpdateService(ctx, spotify, nil); !errors.Is(err, ErrServiceConflict) {
+		t.Errorf("UpdateService with taken alias = %v, want ErrServiceConflict", err)
+	}
+
+	music, err := repo.ListServices(ctx, "music")
+	if err != nil || len(music) != 1 || music[0].ID != spotify.ID {
+		t.Errorf("ListServices(music) = %v, %v; want only Spotify", music, err)
+	}
+	all, err := repo.ListServices(ctx, "")
+	if err != nil || len(all) != 2 || all[0].ID != netflix.ID {
+		t.Errorf("ListServices() = %v, %v; want Netflix, Spotify", all, err)
+	}
+
+	if err := repo.DeleteService(ctx, spotify.ID, nil); err != nil {
+		t.Fatalf("DeleteService: %v", err)
+	}
+	if err := repo.DeleteService(ctx, spotify.ID, nil); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("second DeleteService = %v, want ErrServiceNotFound", err)
+	}
+	spotify.Aliases = nil
+	if err := repo.UpdateService(ctx, spotify, nil); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("UpdateService(deleted) = %v, want ErrServiceNotFound", err)
+	}
+}
+
+func testServiceLinks(t *testing.T, repo Storage) {
+	ctx := context.Background()
+
+	svc := newService("Netflix", "nflx")
+	if err := repo.CreateService(ctx, svc); err != nil {
+		t.Fatalf("CreateService: %v", err)
+	}
+	linked := newSub(uuid.New(), "Netflix", 100, date(2025, 1, 1), nil)
+	linked.ServiceID = &svc.ID
+	mustCreate(t, repo, linked)
+	deleted := newSub(uuid.New(), "Netflix", 100, date(2025, 1, 1), nil)
+	deleted.ServiceID = &svc.ID
+	mustCreate(t, repo, deleted)
+	if err := repo.Delete(ctx, deleted.ID, deleted.Version, nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	change := &Change{Actor: "alice", RequestID: "req-1"}
+
+	unknown := newSub(uuid.New(), "Other", 100, date(2025, 1, 1), nil)
+	missing := uuid.New()
+	unknown.ServiceID = &missing
+	if err := repo.Create(ctx, unknown, nil); err == nil {
+		t.Error("Create with unknown service_id: expected error")
+	}
+
+	// Переименование записи каталога меняет service_name связанных подписок
+	svc.Name = "Netflix Premium"
+	if err := repo.UpdateService(ctx, svc, change); err != nil {
+		t.Fatalf("UpdateService: %v", err)
+	}
+	got, err := repo.GetByID(ctx, linked.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ServiceName != "Netflix Premium" || got.ServiceID == nil || *got.ServiceID != svc.ID {
+		t.Errorf("after rename: service = %s/%v, want Netflix Premium/%s", got.ServiceName, got.ServiceID, svc.ID)
+	}
+
+	// Удаление записи каталога только отвязывает подписки
+	if err := repo.DeleteService(ctx, svc.ID, change); err != nil {
+		t.Fatalf("DeleteService: %v", err)
+	}
+	got, err = repo.GetByID(ctx, linked.ID)
+	if err != nil {
+		t.Fatalf("GetByID after DeleteService: %v", err)
+	}
+	if got.ServiceID != nil || got.ServiceName != "Netflix Premium" {
+		t.Errorf("after delete: service = %s/%v, want Netflix Premium/nil", got.ServiceName, got.ServiceID)
+	}
+
+	// Каждое изменение связанной подписки попадает в журнал со снимками
+	events, err := repo.ListEvents(ctx, linked.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("ListEvents returned %d events, want 2", len(events))
+	}
+	for i, want := range []struct {
+		before, after string
+		version       int
+		linked        bool
+	}{
+		{"Netflix", "Netflix Premium", 2, true},
+		{"Netflix Premium", "Netflix Premium", 3, false},
+	} {
+		e := events[i]
+		var before, after models.Subscription
+		if err := json.Unmarshal(e.Before, &before); err != nil {
+			t.Fatalf("event %d before: %v", i, err)
+		}
+		if err := json.Unmarshal(e.After, &after); err != nil {
+			t.Fatalf("event %d after: %v", i, err)
+		}
+		if e.Action != models.ActionUpdate || e.Actor != "alice" || e.RequestID != "req-1" {
+			t.Errorf("event %d = %+v, want an update by alice", i, e)
+		}
+		if before.ServiceName != want.before || after.ServiceName != want.after || after.Version != want.version ||
+			(after.ServiceID != nil) != want.linked {
+			t.Errorf("event %d: before %+v, after %+v", i, before, after)
+		}
+	}
+
+	// Удаленные подписки каталог не трогает: ни название, ни версия, ни журнал
+	gone, err := repo.GetDeleted(ctx, deleted.ID)
+	if err != nil {
+		t.Fatalf("GetDeleted: %v", err)
+	}
+	if gone.ServiceName != "Netflix" || gone.Version != 2 {
+		t.Errorf("deleted subscription after catalog changes = %s v%d, want Netflix v2", gone.ServiceName, gone.Version)
+	}
+	if count, _ := repo.CountEvents(ctx, deleted.ID); count != 0 {
+		t.Errorf("deleted subscription has %d events, want 0", count)
+	}
+}
+
+func testCostBreakdown(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	day := date(2025, 7, 15)
+	user, other := uuid.New(), uuid.New()
+
+	netflix := newService("Netflix")
+	netflix.Category = "video"
+	spotify := newService("Spotify")
+	spotify.Category = "music"
+	for _, svc := range []*models.Service{netflix, spotify} {
+		if err := repo.CreateService(ctx, svc); err != nil {
+			t.Fatalf("CreateService: %v", err)
+		}
+	}
+
+	// 15 июля 2025: Netflix 100 RUB и 200 USD, Spotify 20 RUB по новой цене, Other 1 RUB
+	netflixRUB := newSub(user, "Netflix", 3100, date(2025, 1, 1), nil)
+	netflixRUB.ServiceID = &netflix.ID
+	netflixUSD := newSub(other, "Netflix", 6200, date(2025, 1, 1), nil)
+	netflixUSD.Currency = "USD"
+	netflixUSD.ServiceID = &netflix.ID
+	spotifySub := newSub(user, "Spotify", 310, date(2025, 1, 1), nil)
+	spotifySub.ServiceID = &spotify.ID
+	unlinked := newSub(other, "Other", 31, date(2025, 1, 1), nil)
+	paused := newSub(user, "Netflix", 3100, date(2025, 1, 1), nil)
+	paused.ServiceID = &netflix.ID
+	trial := newSub(user, "Spotify", 3100, date(2025, 7, 1), nil)
+	trial.TrialEnd = datePtr(2025, 7, 16)
+	ended := newSub(user, "Netflix", 3100, date(2025, 1, 1), datePtr(2025, 7, 14))
+	for _, s := range []*models.Subscription{netflixRUB, netflixUSD, spotifySub, unlinked, paused, trial, ended} {
+		mustCreate(t, repo, s)
+	}
+	if err := repo.SchedulePriceChange(ctx, &models.PriceChange{
+		ID: uuid.New(), SubscriptionID: spotifySub.ID, Price: 620, EffectiveFrom: date(2025, 7, 1),
+	}); err != nil {
+		t.Fatalf("SchedulePriceChange: %v", err)
+	}
+	if err := repo.Pause(ctx, &models.Pause{ID: uuid.New(), SubscriptionID: paused.ID, StartDate: date(2025, 7, 10)}); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+
+	assertGroups := func(groupBy BreakdownGroupBy, filter CostFilter, want []CostGroup) {
+		t.Helper()
+		got, err := repo.GetCostBreakdown(ctx, day, groupBy, filter)
+		if err != nil {
+			t.Fatalf("GetCostBreakdown(%s): %v", groupBy, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("GetCostBreakdown(%s) = %+v, want %+v", groupBy, got, want)
+		}
+		for i := range want {
+			if got[i].Key != want[i].Key || got[i].Currency != want[i].Currency {
+				t.Errorf("group %d = %s/%s, want %s/%s", i, got[i].Key, got[i].Currency, want[i].Key, want[i].Currency)
+			}
+			assertClose(t, got[i].Total, want[i].Total)
+		}
+	}
+
+	assertGroups(GroupByService, CostFilter{}, []CostGroup{
+		{Key: "Netflix", Currency: "RUB", Total: 100},
+		{Key: "Netflix", Currency: "USD", Total: 200},
+		{Key: "Other", Currency: "RUB", Total: 1},
+		{Key: "Spotify", Currency: "RUB", Total: 20},
+	})
+	assertGroups(GroupByCategory, CostFilter{}, []CostGroup{
+		{Key: "music", Currency: "RUB", Total: 20},
+		{Key: Uncategorized, Currency: "RUB", Total: 1},
+		{Key: "video", Currency: "RUB", Total: 100},
+		{Key: "video", Currency: "USD", Total: 200},
+	})
+	assertGroups(GroupByUser, CostFilter{UserID: &user}, []CostGroup{
+		{Key: user.String(), Currency: "RUB", Total: 120},
+	})
+	assertGroups(GroupByCategory, CostFilter{ServiceName: "Other"}, []CostGroup{
+		{Key: Uncategorized, Currency: "RUB", Total: 1},
+	})
+
+	if _, err := repo.GetCostBreakdown(ctx, day, "currency", CostFilter{}); err == nil {
+		t.Error("GetCostBreakdown with unsupported group_by: expected error")
+	}
+}
+
+func testBudgets(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user, other := uuid.New(), uuid.New()
+	updatedAt := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := repo.GetBudget(ctx, user); !errors.Is(err, ErrBudgetNotFound) {
+		t.Errorf("GetBudget(missing) = %v, want ErrBudgetNotFound", err)
+	}
+
+	for _, b := range []*models.Budget{
+		{UserID: user, MonthlyLimit: 100000, Currency: "RUB", Thresholds: []int{80, 100}, UpdatedAt: updatedAt},
+		{UserID: other, MonthlyLimit: 5000, Currency: "USD", Thresholds: []int{50}, UpdatedAt: updatedAt},
+		// Повторная установка заменяет бюджет
+		{UserID: user, MonthlyLimit: 200000, Currency: "EUR", Thresholds: []int{90}, UpdatedAt: updatedAt},
+	} {
+		if err := repo.SetBudget(ctx, b); err != nil {
+			t.Fatalf("SetBudget: %v", err)
+		}
+	}
+
+	got, err := repo.GetBudget(ctx, user)
+	if err != nil {
+		t.Fatalf("GetBudget: %v", err)
+	}
+	if got.MonthlyLimit != 200000 || got.Currency != "EUR" || len(got.Thresholds) != 1 || got.Thresholds[0] != 90 || !got.UpdatedAt.Equal(updatedAt) {
+		t.Errorf("GetBudget = %+v", got)
+	}
+
+	all, err := repo.ListBudgets(ctx)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListBudgets = %v, %v; want 2 budgets", all, err)
+	}
+
+	// Уведомление о пороге хранится один раз на месяц
+	july, august := date(2025, 7, 10), date(2025, 8, 1)
+	for i := 0; i < 2; i++ {
+		if err := repo.RecordBudgetAlert(ctx, user, july, 90, updatedAt); err != nil {
+			t.Fatalf("RecordBudgetAlert: %v", err)
+		}
+	}
+	checks := []struct {
+		month     time.Time
+		threshold int
+		want      bool
+	}{
+		{date(2025, 7, 31), 90, true},
+		{date(2025, 7, 31), 100, false},
+		{august, 90, false},
+	}
+	for _, c := range checks {
+		sent, err := repo.BudgetAlertSent(ctx, user, c.month, c.threshold)
+		if err != nil || sent != c.want {
+			t.Errorf("BudgetAlertSent(%s, %d) = %v, %v; want %v", c.month.Format("2006-01-02"), c.threshold, sent, err, c.want)
+		}
+	}
+
+	if err := repo.DeleteBudget(ctx, user); err != nil {
+		t.Fatalf("DeleteBudget: %v", err)
+	}
+	if err := repo.DeleteBudget(ctx, user); !errors.Is(err, ErrBudgetNotFound) {
+		t.Errorf("second DeleteBudget = %v, want ErrBudgetNotFound", err)
+	}
+	if sent, _ := repo.BudgetAlertSent(ctx, user, july, 90); sent {
+		t.Error("alerts of a deleted budget are still recorded")
+	}
+}
+
+func testUpcomingCharges(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	user, other := uuid.New(), uuid.New()
+
+	monthly := newSub(user, "Monthly", 3100, date(2025, 1, 10), nil)
+	weekly := newSub(other, "Weekly", 700, date(2025, 7, 1), nil)
+	weekly.BillingPeriod = models.BillingWeekly
+	yearly := newSub(user, "Yearly", 12000, date(2024, 3, 1), nil)
+	yearly.BillingPeriod = models.BillingYearly
+	ended := newSub(user, "Ended", 100, date(2025, 1, 10), datePtr(2025, 7, 9))
+	trial := newSub(user, "Trial", 100, date(2025, 6, 12), nil)
+	trial.TrialEnd = datePtr(2025, 7, 20)
+	paused := newSub(user, "Paused", 100, date(2025, 1, 12), nil)
+	for _, s := range []*models.Subscription{monthly, weekly, yearly, ended, trial, paused} {
+		mustCreate(t, repo, s)
+	}
+	if err := repo.SchedulePriceChange(ctx, &models.PriceChange{
+		ID: uuid.New(), SubscriptionID: monthly.ID, Price: 3500, EffectiveFrom: date(2025, 7, 10),
+	}); err != nil {
+		t.Fatalf("SchedulePriceChange: %v", err)
+	}
+	if err := repo.Pause(ctx, &models.Pause{ID: uuid.New(), SubscriptionID: paused.ID, StartDate: date(2025, 7, 1)}); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+
+	charges, err := repo.ListUpcomingCharges(ctx, date(2025, 7, 8), date(2025, 7, 15), nil)
+	if err != nil {
+		t.Fatalf("ListUpcomingCharges: %v", err)
+	}
+	if len(charges) != 2 {
+		t.Fatalf("ListUpcomingCharges returned %d charges, want Weekly and Monthly: %+v", len(charges), charges)
+	}
+	if charges[0].ID != weekly.ID || !charges[0].ChargeDate.Equal(date(2025, 7, 8)) || charges[0].Amount != 700 {
+		t.Errorf("first charge = %s on %v for %d, want Weekly on 2025-07-08 for 700",
+			charges[0].ServiceName, charges[0].ChargeDate, charges[0].Amount)
+	}
+	if charges[1].ID != monthly.ID || !charges[1].ChargeDate.Equal(date(2025, 7, 10)) || charges[1].Amount != 3500 {
+		t.Errorf("second charge = %s on %v for %d, want Monthly on 2025-07-10 for 3500",
+			charges[1].ServiceName, charges[1].ChargeDate, charges[1].Amount)
+	}
+
+	scoped, err := repo.ListUpcomingCharges(ctx, date(2025, 7, 8), date(2025, 7, 15), &other)
+	if err != nil || len(scoped) != 1 || scoped[0].ID != weekly.ID {
+		t.Errorf("scoped ListUpcomingCharges = %v, %v; want only Weekly", scoped, err)
+	}
+
+	// Первое списание после пробного периода
+	later, err := repo.ListUpcomingCharges(ctx, date(2025, 8, 10), date(2025, 8, 12), &user)
+	if err != nil || len(later) != 2 || later[0].ID != monthly.ID || later[1].ID != trial.ID {
+		t.Errorf("ListUpcomingCharges in August = %v, %v; want Monthly and Trial", later, err)
+	}
+}
+
+func testReminders(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	sub := newSub(uuid.New(), "Netflix", 100, date(2025, 1, 10), nil)
+	mustCreate(t, repo, sub)
+	sentAt := time.Date(2025, 7, 8, 9, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if err := repo.RecordReminder(ctx, sub.ID, date(2025, 7, 10), sentAt); err != nil {
+			t.Fatalf("RecordReminder: %v", err)
+		}
+	}
+	if sent, err := repo.ReminderSent(ctx, sub.ID, date(2025, 7, 10)); err != nil || !sent {
+		t.Errorf("ReminderSent(2025-07-10) = %v, %v; want true", sent, err)
+	}
+	if sent, err := repo.ReminderSent(ctx, sub.ID, date(2025, 8, 10)); err != nil || sent {
+		t.Errorf("ReminderSent(2025-08-10) = %v, %v; want false", sent, err)
+	}
+
+	if err := repo.Delete(ctx, sub.ID, sub.Version, nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if sent, _ := repo.ReminderSent(ctx, sub.ID, date(2025, 7, 10)); sent {
+		t.Error("reminders of a purged subscription are still recorded")
+	}
+}
+
+func testEvents(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	sub := newSub(uuid.New(), "Netflix", 100, date(2025, 1, 10), nil)
+	other := newSub(uuid.New(), "Spotify", 200, date(2025, 1, 10), nil)
+
+	if err := repo.Create(ctx, sub, &Change{Actor: "alice", RequestID: "req-1", After: sub}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	created := *sub
+	if err := repo.Create(ctx, other, &Change{Actor: "alice", After: other}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	updated := *sub
+	updated.Price = 150
+	if err := repo.Update(ctx, &updated, &Change{Actor: "bob", Before: &created, After: &updated}, "price"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := repo.Delete(ctx, sub.ID, updated.Version, &Change{Actor: "bob", Before: &updated}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if count, err := repo.CountEvents(ctx, sub.ID); err != nil || count != 3 {
+		t.Errorf("CountEvents = %d, %v; want 3", count, err)
+	}
+
+	// Журнал подписки упорядочен по времени события и не содержит чужих записей
+	got, err := repo.ListEvents(ctx, sub.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	wantActions := []models.SubscriptionAction{models.ActionCreate, models.ActionUpdate, models.ActionDelete}
+	if len(got) != len(wantActions) {
+		t.Fatalf("ListEvents returned %d events, want %d", len(got), len(wantActions))
+	}
+	for i, action := range wantActions {
+		if got[i].Action != action {
+			t.Errorf("event %d action = %s, want %s", i, got[i].Action, action)
+		}
+		if got[i].ID == 0 || got[i].SubscriptionID != sub.ID {
+			t.Errorf("event %d = %+v, want an ID for subscription %s", i, got[i], sub.ID)
+		}
+	}
+
+	first := got[0]
+	if first.Actor != "alice" || first.RequestID != "req-1" || first.OccurredAt.IsZero() {
+		t.Errorf("create event = %+v", first)
+	}
+	if first.Before != nil {
+		t.Errorf("create event before = %s, want empty snapshot", first.Before)
+	}
+	var after map[string]interface{}
+	if err := json.Unmarshal(first.After, &after); err != nil || after["service_name"] != "Netflix" {
+		t.Errorf("create event after = %s, %v", first.After, err)
+	}
+	if got[2].After != nil {
+		t.Errorf("delete event after = %s, want empty snapshot", got[2].After)
+	}
+
+	page, err := repo.ListEvents(ctx, sub.ID, 1, 1)
+	if err != nil || len(page) != 1 || page[0].Action != models.ActionUpdate {
+		t.Errorf("ListEvents(limit 1, offset 1) = %+v, %v; want the update event", page, err)
+	}
+
+	// Журнал переживает окончательное удаление подписки
+	if _, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+	if count, _ := repo.CountEvents(ctx, sub.ID); count != 3 {
+		t.Errorf("CountEvents after purge = %d, want 3", count)
+	}
+}
+
+func testChangeEvents(t *testing.T, repo Storage) {
+	ctx := context.Background()
+	sub := newSub(uuid.New(), "Netflix", 100, date(2025, 1, 10), nil)
+	change := func(before, after *models.Subscription) *Change {
+		return &Change{Actor: "alice", RequestID: "req-1", Before: before, After: after}
+	}
+
+	if err := repo.Create(ctx, sub, change(nil, sub)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	created := *sub
+
+	// Неудавшееся изменение не оставляет записи в журнале
+	stale := *sub
+	stale.Version = 0
+	if err := repo.Update(ctx, &stale, change(&created, &stale), "price"); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Update of stale version = %v, want ErrVersionConflict", err)
+	}
+
+	updated := *sub
+	updated.Price = 200
+	if err := repo.Update(ctx, &updated, change(&created, &updated), "price"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	cancelled := updated
+	cancelled.EndDate = datePtr(2025, 6, 1)
+	cancelled.Version++
+	c := &models.Cancellation{ID: uuid.New(), SubscriptionID: sub.ID, EffectiveDate: *cancelled.EndDate,
+		Reason: models.ReasonOther, CancelledAt: date(2025, 5, 1)}
+	if err := repo.Cancel(ctx, c, updated.Version, change(&updated, &cancelled)); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if err := repo.Delete(ctx, sub.ID, cancelled.Version, change(&cancelled, nil)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	deleted, err := repo.GetDeleted(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetDeleted: %v", err)
+	}
+	restored := *deleted
+	restored.DeletedAt = nil
+	restored.Version++
+	if err := repo.Restore(ctx, sub.ID, deleted.Version, change(deleted, &restored)); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	events, err := repo.ListEvents(ctx, sub.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	want := []struct {
+		action        models.SubscriptionAction
+		before, after int
+	}{
+		{models.ActionCreate, 0, 1},
+		{models.ActionUpdate, 1, 2},
+		{models.ActionCancel, 2, 3},
+		{models.ActionDelete, 3, 0},
+		{models.ActionRestore, 4, 5},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("ListEvents returned %d events, want %d", len(events), len(want))
+	}
+	// Снимки снимаются после изменения, поэтому содержат сохраненные версии
+	version := func(snapshot json.RawMessage) int {
+		if snapshot == nil {
+			return 0
+		}
+		var s models.Subscription
+		if err := json.Unmarshal(snapshot, &s); err != nil {
+			t.Fatalf("snapshot %s: %v", snapshot, err)
+		}
+		return s.Version
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.Action != w.action || e.Actor != "alice" || e.RequestID != "req-1" {
+			t.Errorf("event %d = %+v, want %s by alice", i, e, w.action)
+		}
+		if got := version(e.Before); got != w.before {
+			t.Errorf("%s event before version = %d, want %d", e.Action, got, w.before)
+		}
+		if got := version(e.After); got != w.after {
+			t.Errorf("%s event after version = %d, want %d", e.Action, got, w.after)
+		}
+	}
+}
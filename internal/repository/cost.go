@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+)
+
+// dailyRate возвращает стоимость одного дня подписки.
+// Цена сначала приводится к месячной, а затем делится на число дней
+// в месяце day, поэтому полный календарный месяц стоит ровно месячную цену.
+// Недельные подписки считаются равномерно: price / 7 за день.
+func dailyRate(price float64, period models.BillingPeriod, day time.Time) float64 {
+	switch period {
+	case models.BillingWeekly:
+		return price / 7
+	case models.BillingQuarterly:
+		return price / 3 / float64(daysInMonth(day))
+	case models.BillingYearly:
+		return price / 12 / float64(daysInMonth(day))
+	default:
+		return price / float64(daysInMonth(day))
+	}
+}
+
+// subscriptionCost считает стоимость подписки за дни с from по to включительно.
+// Учитываются только дни, когда подписка была активна, не стояла на паузе
+// и не приходилась на пробный период,
+// каждый день оплачивается по цене, действовавшей в этот день.
+func subscriptionCost(sub *models.Subscription, from, to time.Time) float64 {
+	// Определяем пересечение периода подписки с [from, to]
+	start := truncateDay(sub.StartDate)
+	if from := truncateDay(from); start.Before(from) {
+		start = from
+	}
+
+	end := truncateDay(to)
+	if sub.EndDate != nil {
+		if subEnd := truncateDay(*sub.EndDate); subEnd.Before(end) {
+			end = subEnd
+		}
+	}
+
+	var total float64
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		// Дни паузы и пробного периода не оплачиваются
+		if sub.PausedOn(day) || sub.InTrialOn(day) {
+			continue
+		}
+		// Цена берется та, что действовала в этот день
+		total += dailyRate(float64(sub.PriceOn(day)), sub.BillingPeriod, day)
+	}
+	return total
+}
+
+// truncateDay отбрасывает время, оставляя дату в UTC
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// helper функции:
+func daysInMonth(t time.Time) int {
+	firstOfMonth := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	firstOfNextMonth := firstOfMonth.AddDate(0, 1, 0)
+	return int(firstOfNextMonth.Sub(firstOfMonth).Hours() / 24)
+}
+
+// monthlyBreakdown раскладывает стоимость подписок по календарным месяцам
+// периода [from, to]. Стоимость каждой подписки остается в её валюте.
+func monthlyBreakdown(subs []models.Subscription, from, to time.Time) []models.CostReportMonth {
+	from, to = truncateDay(from), truncateDay(to)
+
+	var months []models.CostReportMonth
+	for monthStart := firstOfMonth(from); !monthStart.After(to); monthStart = monthStart.AddDate(0, 1, 0) {
+		start := monthStart
+		if start.Before(from) {
+			start = from
+		}
+		end := monthStart.AddDate(0, 1, -1)
+		if end.After(to) {
+			end = to
+		}
+
+		month := models.CostReportMonth{
+			Month:         monthStart.Format("2006-01"),
+			Subscriptions: []models.CostReportItem{},
+		}
+		for i := range subs {
+			cost := subscriptionCost(&subs[i], start, end)
+			if cost <= 0 {
+				continue
+			}
+			month.Subscriptions = append(month.Subscriptions, models.CostReportItem{
+				SubscriptionID: subs[i].ID,
+				ServiceName:    subs[i].ServiceName,
+				UserID:         subs[i].UserID,
+				Currency:       subs[i].Currency,
+				Cost:           cost,
+			})
+		}
+		months = append(months, month)
+	}
+
+	return months
+}
+
+func firstOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// ErrNotDeleted возвращается при попытке восстановить подписку, которая не удалена
+var ErrNotDeleted = errors.New("subscription is not deleted")
+
+// notDeleted исключает из выборки удаленные подписки
+var notDeleted = squirrel.Eq{"deleted_at": nil}
+
+// GetDeleted возвращает удаленную подписку вместе с историей.
+// Для существующей неудаленной подписки возвращается ErrNotDeleted.
+func (r *Repository) GetDeleted(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	sub, err := r.getByID(ctx, id, squirrel.NotEq{"deleted_at": nil}, "GetDeleted")
+	if errors.Is(err, ErrNotFound) {
+		return nil, r.restoreMismatch(ctx, id)
+	}
+	return sub, err
+}
+
+// Restore снимает с подписки отметку об удалении и увеличивает её версию,
+// если подписка удалена и её версия равна version
+func (r *Repository) Restore(ctx context.Context, id uuid.UUID, version int, change *Change) error {
+	sqlStr, args, err := squirrel.Update("subscriptions").
+		Set("deleted_at", nil).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": id, "version": version}).
+		Where(squirrel.NotEq{"deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Printf("Restore: ошибка формирования SQL: %v", err)
+		return err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("Restore: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmdTag, err := tx.Exec(ctx, sqlStr, args...)
+	if err != nil {
+		log.Printf("Restore: ошибка выполнения SQL: %v", err)
+		return err
+	}
+	if cmdTag.RowsAffected() != 1 {
+		return r.restoreMismatch(ctx, id)
+	}
+	if err := recordChange(ctx, tx, change, models.ActionRestore, id, "Restore"); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// restoreMismatch объясняет, почему восстановление не затронуло строку:
+// ErrNotFound, если подписки нет, ErrNotDeleted, если она не удалена,
+// иначе ErrVersionConflict
+func (r *Repository) restoreMismatch(ctx context.Context, id uuid.UUID) error {
+	var deletedAt *time.Time
+	err := r.db.QueryRow(ctx,
+		"SELECT deleted_at FROM subscriptions WHERE id = $1", id,
+	).Scan(&deletedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Printf("restoreMismatch: ошибка чтения подписки: %v", err)
+		return err
+	}
+	if deletedAt == nil {
+		return ErrNotDeleted
+	}
+	return ErrVersionConflict
+}
+
+// PurgeDeleted окончательно удаляет подписки, удаленные раньше before,
+// вместе с историей отмен, пауз, цен и напоминаний. Возвращает число удаленных подписок.
+func (r *Repository) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	sqlStr, args, err := squirrel.Delete("subscriptions").
+		Where(squirrel.Lt{"deleted_at": before}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Printf("PurgeDeleted: ошибка формирования SQL: %v", err)
+		return 0, err
+	}
+
+	cmdTag, err := r.db.Exec(ctx, sqlStr, args...)
+	if err != nil {
+		log.Printf("PurgeDeleted: ошибка выполнения SQL: %v", err)
+		return 0, err
+	}
+	return int(cmdTag.RowsAffected()), nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// EventRepository хранит журнал изменений подписок.
+// Журнал только дополняется; записи остаются и после окончательного удаления подписки.
+type EventRepository interface {
+	// ListEvents возвращает страницу журнала подписки в порядке occurred_at
+	ListEvents(ctx context.Context, subscriptionID uuid.UUID, limit, offset int) ([]models.SubscriptionEvent, error)
+	CountEvents(ctx context.Context, subscriptionID uuid.UUID) (int, error)
+}
+
+// Change описывает изменение подписки для журнала: кто его сделал, в каком запросе
+// и состояние подписки до и после. Методы, принимающие Change, записывают событие
+// в той же транзакции, что и само изменение: если журнал записать не удалось,
+// изменение тоже не сохраняется. nil означает изменение без записи в журнал.
+// Снимки сериализуются после применения изменения, поэтому After, совпадающий с
+// сохраняемой подпиской, содержит её новую версию.
+type Change struct {
+	Actor     string
+	RequestID string
+	Before    *models.Subscription
+	After     *models.Subscription
+}
+
+// forSubscription возвращает change с другими снимками, сохраняя автора и ID запроса.
+// Нужен изменениям, которые затрагивают сразу несколько подписок.
+func (c *Change) forSubscription(before, after *models.Subscription) *Change {
+	if c == nil {
+		return nil
+	}
+	return &Change{Actor: c.Actor, RequestID: c.RequestID, Before: before, After: after}
+}
+
+// event собирает запись журнала о действии action над подпиской id
+func (c *Change) event(action models.SubscriptionAction, id uuid.UUID) (*models.SubscriptionEvent, error) {
+	before, err := snapshot(c.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := snapshot(c.After)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SubscriptionEvent{
+		SubscriptionID: id,
+		Action:         action,
+		Actor:          c.Actor,
+		RequestID:      c.RequestID,
+		OccurredAt:     time.Now().UTC(),
+		Before:         before,
+		After:          after,
+	}, nil
+}
+
+// snapshot сериализует подписку для журнала; nil остается пустым снимком
+func snapshot(sub *models.Subscription) (json.RawMessage, error) {
+	if sub == nil {
+		return nil, nil
+	}
+	return json.Marshal(sub)
+}
+
+// recordChange записывает в транзакции tx событие об изменении подписки id
+func recordChange(ctx context.Context, tx pgx.Tx, change *Change, action models.SubscriptionAction, id uuid.UUID, op string) error {
+	if change == nil {
+		return nil
+	}
+	e, err := change.event(action, id)
+	if err != nil {
+		log.Printf("%s: ошибка сериализации снимка подписки: %v", op, err)
+		return err
+	}
+	return insertEvent(ctx, tx, e, op)
+}
+
+// insertEvent вставляет запись журнала в транзакции tx и заполняет e.ID
+func insertEvent(ctx context.Context, tx pgx.Tx, e *models.SubscriptionEvent, op string) error {
+	// nil-снимок сохраняется как NULL, а не как JSON null
+	sqlStr, args, err := squirrel.Insert("subscription_events").
+		Columns("subscription_id", "action", "actor", "request_id", "occurred_at", "before", "after").
+		Values(e.SubscriptionID, e.Action, e.Actor, e.RequestID, e.OccurredAt, []byte(e.Before), []byte(e.After)).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Printf("%s: ошибка формирования SQL события: %v", op, err)
+		return err
+	}
+
+	if err := tx.QueryRow(ctx, sqlStr, args...).Scan(&e.ID); err != nil {
+		log.Printf("%s: ошибка записи события: %v", op, err)
+		return err
+	}
+	return nil
+}
+
+// ListEvents возвращает страницу журнала подписки в порядке occurred_at
+func (r *Repository) ListEvents(ctx context.Context, subscriptionID uuid.UUID, limit, offset int) ([]models.SubscriptionEvent, error) {
+	queryBuilder := squirrel.Select("id", "subscription_id", "action", "actor", "request_id", "occurred_at", "before", "after").
+		From("subscription_events").
+		Where(squirrel.Eq{"subscription_id": subscriptionID}).
+		OrderBy("occurred_at", "id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if limit > 0 {
+		queryBuilder = queryBuilder.Limit(uint64(limit))
+	}
+	if offset > 0 {
+		queryBuilder = queryBuilder.Offset(uint64(offset))
+	}
+
+	sqlStr, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Printf("ListEvents: ошибка формирования SQL: %v", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, sqlStr, args...)
+	if err != nil {
+		log.Printf("ListEvents: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.SubscriptionEvent{}
+	for rows.Next() {
+		var e models.SubscriptionEvent
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.SubscriptionID, &e.Action, &e.Actor, &e.RequestID, &e.OccurredAt, &before, &after); err != nil {
+			log.Printf("ListEvents: ошибка сканирования строки: %v", err)
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("ListEvents: ошибка чтения результата: %v", err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// CountEvents возвращает число записей журнала подписки
+func (r *Repository) CountEvents(ctx context.Context, subscriptionID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx,
+		"SELECT COUNT(*) FROM subscription_events WHERE subscription_id = $1", subscriptionID,
+	).Scan(&count)
+	if err != nil {
+		log.Printf("CountEvents: ошибка выполнения SQL: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
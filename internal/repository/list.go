@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+// ListFilter ограничивает выборку списка подписок.
+// Пустые поля не ограничивают выборку, границы диапазонов включаются.
+type ListFilter struct {
+	// InTrial: true — только подписки в пробном периоде на дату Today,
+	// false — только вне пробного периода, nil — без фильтра
+	InTrial *bool
+	Today   time.Time
+
+	UserID            *uuid.UUID
+	ServiceName       string // точное совпадение
+	ServiceNamePrefix string // название начинается с префикса, с учетом регистра
+
+	// Диапазон начальной цены Price в минорных единицах валюты подписки
+	MinPrice *int
+	MaxPrice *int
+
+	// ActiveOn оставляет подписки, действующие в этот день
+	ActiveOn *time.Time
+
+	// Диапазон start_date
+	StartFrom *time.Time
+	StartTo   *time.Time
+
+	// IncludeDeleted добавляет в выборку удаленные подписки
+	IncludeDeleted bool
+
+	// Sort задает порядок выборки; по умолчанию start_date по возрастанию.
+	// Подписки с равными значениями всегда упорядочиваются по id.
+	Sort []SortField
+}
+
+// SortField — поле сортировки списка и её направление
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// sortableFields — поля, по которым можно сортировать список
+var sortableFields = map[string]bool{
+	"service_name":   true,
+	"price":          true,
+	"currency":       true,
+	"billing_period": true,
+	"user_id":        true,
+	"start_date":     true,
+	"end_date":       true,
+	"trial_end":      true,
+}
+
+// SortableField сообщает, можно ли сортировать список по полю
+func SortableField(field string) bool {
+	return sortableFields[field]
+}
+
+// ListCursor — позиция в списке подписок, упорядоченном по (start_date, id).
+// Выборка после курсора начинается со следующей за ним подписки.
+type ListCursor struct {
+	StartDate time.Time
+	ID        uuid.UUID
+}
+
+// likeEscaper экранирует спецсимволы шаблона LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// applyListFilter добавляет к запросу условия ListFilter
+func applyListFilter(queryBuilder squirrel.SelectBuilder, filter ListFilter) squirrel.SelectBuilder {
+	if !filter.IncludeDeleted {
+		queryBuilder = queryBuilder.Where(notDeleted)
+	}
+	if filter.InTrial != nil {
+		inTrial := squirrel.Gt{"trial_end": filter.Today}
+		if *filter.InTrial {
+			queryBuilder = queryBuilder.Where(inTrial)
+		} else {
+			queryBuilder = queryBuilder.Where(squirrel.Or{squirrel.Eq{"trial_end": nil}, squirrel.LtOrEq{"trial_end": filter.Today}})
+		}
+	}
+	if filter.UserID != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"user_id": *filter.UserID})
+	}
+	if filter.ServiceName != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"service_name": filter.ServiceName})
+	}
+	if filter.ServiceNamePrefix != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Like{"service_name": likeEscaper.Replace(filter.ServiceNamePrefix) + "%"})
+	}
+	if filter.MinPrice != nil {
+		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"price": *filter.MinPrice})
+	}
+	if filter.MaxPrice != nil {
+		queryBuilder = queryBuilder.Where(squirrel.LtOrEq{"price": *filter.MaxPrice})
+	}
+	if filter.ActiveOn != nil {
+		queryBuilder = queryBuilder.Where(activeBetween(*filter.ActiveOn, *filter.ActiveOn))
+	}
+	if filter.StartFrom != nil {
+		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"start_date": *filter.StartFrom})
+	}
+	if filter.StartTo != nil {
+		queryBuilder = queryBuilder.Where(squirrel.LtOrEq{"start_date": *filter.StartTo})
+	}
+	return queryBuilder
+}
+
+// listOrder возвращает выражения ORDER BY для сортировки списка.
+// Поля не из sortableFields пропускаются, id замыкает порядок.
+func listOrder(sort []SortField) []string {
+	if len(sort) == 0 {
+		return []string{"start_date", "id"}
+	}
+
+	order := make([]string, 0, len(sort)+1)
+	for _, field := range sort {
+		if !sortableFields[field.Field] {
+			continue
+		}
+		if field.Desc {
+			order = append(order, field.Field+" DESC")
+		} else {
+			order = append(order, field.Field+" ASC")
+		}
+	}
+	return append(order, "id")
+}
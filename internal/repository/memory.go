@@ -0,0 +1,680 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// MemoryRepository хранит подписки в памяти процесса.
+// Используется в тестах и в демо-режиме (STORAGE=memory) и повторяет
+// поведение Repository, включая расчет стоимости.
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[uuid.UUID]models.Subscription
+	cancellations []models.Cancellation
+	services      map[uuid.UUID]models.Service
+	budgets       map[uuid.UUID]models.Budget
+	budgetAlerts  map[budgetAlertKey]time.Time
+	reminders     map[reminderKey]time.Time
+	events        []models.SubscriptionEvent
+}
+
+// NewMemoryRepository создает пустое хранилище в памяти
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		subscriptions: make(map[uuid.UUID]models.Subscription),
+		services:      make(map[uuid.UUID]models.Service),
+		budgets:       make(map[uuid.UUID]models.Budget),
+		budgetAlerts:  make(map[budgetAlertKey]time.Time),
+		reminders:     make(map[reminderKey]time.Time),
+	}
+}
+
+// Create добавляет новую подписку
+func (m *MemoryRepository) Create(_ context.Context, sub *models.Subscription, change *Change) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.subscriptions[sub.ID]; ok {
+		return fmt.Errorf("subscription %s already exists", sub.ID)
+	}
+
+	if err := m.checkServiceRef(sub); err != nil {
+		return err
+	}
+
+	sub.Version = 1
+	sub.DeletedAt = nil
+	if err := m.recordChange(change, models.ActionCreate, sub.ID); err != nil {
+		return err
+	}
+	stored := normalizeSubscription(*sub)
+	stored.Pauses = nil
+	stored.PriceChanges = nil
+	m.subscriptions[sub.ID] = stored
+	return nil
+}
+
+// GetByID возвращает подписку по её id
+func (m *MemoryRepository) GetByID(_ context.Context, id uuid.UUID) (*models.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sub, ok := m.live(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	sub = cloneSubscription(sub)
+	return &sub, nil
+}
+
+// Update обновляет поля подписки, не затрагивая историю пауз и цен.
+// Если columns заданы, меняются только соответствующие поля.
+func (m *MemoryRepository) Update(_ context.Context, sub *models.Subscription, change *Change, columns ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.live(sub.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	if current.Version != sub.Version {
+		return ErrVersionConflict
+	}
+
+	if len(columns) == 0 {
+		columns = updatableColumns
+	}
+	updated := current
+	for _, column := range columns {
+		if err := copyColumn(&updated, sub, column); err != nil {
+			return err
+		}
+	}
+
+	if err := m.checkServiceRef(&updated); err != nil {
+		return err
+	}
+
+	updated.Version++
+	sub.Version = updated.Version
+	if err := m.recordChange(change, models.ActionUpdate, sub.ID); err != nil {
+		return err
+	}
+	m.subscriptions[sub.ID] = normalizeSubscription(updated)
+	return nil
+}
+
+// copyColumn переносит в dst значение поля src, соответствующего колонке column
+func copyColumn(dst, src *models.Subscription, column string) error {
+	switch column {
+	case "service_name":
+		dst.ServiceName = src.ServiceName
+	case "price":
+		dst.Price = src.Price
+	case "currency":
+		dst.Currency = src.Currency
+	case "billing_period":
+		dst.BillingPeriod = src.BillingPeriod
+	case "user_id":
+		dst.UserID = src.UserID
+	case "start_date":
+		dst.StartDate = src.StartDate
+	case "end_date":
+		dst.EndDate = src.EndDate
+	case "trial_end":
+		dst.TrialEnd = src.TrialEnd
+	case "service_id":
+		dst.ServiceID = src.ServiceID
+	default:
+		return fmt.Errorf("column %q cannot be updated", column)
+	}
+	return nil
+}
+
+// Delete помечает подписку версии version удаленной; история сохраняется до PurgeDeleted
+func (m *MemoryRepository) Delete(_ context.Context, id uuid.UUID, version int, change *Change) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.live(id)
+	if !ok {
+		return ErrNotFound
+	}
+	if sub.Version != version {
+		return ErrVersionConflict
+	}
+
+	if err := m.recordChange(change, models.ActionDelete, id); err != nil {
+		return err
+	}
+	deletedAt := time.Now().UTC()
+	sub.DeletedAt = &deletedAt
+	sub.Version++
+	m.subscriptions[id] = sub
+	return nil
+}
+
+// GetAllSubscriptions возвращает страницу всех подписок
+func (m *MemoryRepository) GetAllSubscriptions(_ context.Context, limit, offset int, filter ListFilter) ([]models.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := m.filterDeleted(filter.IncludeDeleted, filter.matches)
+	if len(filter.Sort) > 0 {
+		sort.SliceStable(subs, func(i, j int) bool {
+			return lessBySort(&subs[i], &subs[j], filter.Sort)
+		})
+	}
+	return paginate(subs, limit, offset), nil
+}
+
+// ListSubscriptionsAfter возвращает до limit подписок, идущих после курсора after
+func (m *MemoryRepository) ListSubscriptionsAfter(_ context.Context, after *ListCursor, limit int, filter ListFilter) ([]models.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := m.filterDeleted(filter.IncludeDeleted, func(s *models.Subscription) bool {
+		return filter.matches(s) && (after == nil || after.before(s))
+	})
+	return paginate(subs, limit, 0), nil
+}
+
+// CountSubscriptions возвращает число подписок, подходящих под фильтр
+func (m *MemoryRepository) CountSubscriptions(_ context.Context, filter ListFilter) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.filterDeleted(filter.IncludeDeleted, filter.matches)), nil
+}
+
+// ListByUser возвращает подписки одного пользователя
+func (m *MemoryRepository) ListByUser(_ context.Context, userID uuid.UUID, limit, offset int) ([]models.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := m.filter(func(s *models.Subscription) bool {
+		return s.UserID == userID
+	})
+	return paginate(subs, limit, offset), nil
+}
+
+// GetTotalSubscriptionCost считает стоимость подписок за день date по валютам
+func (m *MemoryRepository) GetTotalSubscriptionCost(
+	_ context.Context,
+	date time.Time,
+	filterByUser bool,
+	userID uuid.UUID,
+	serviceName string,
+) (map[string]float64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := m.filter(func(s *models.Subscription) bool {
+		if filterByUser && s.UserID != userID {
+			return false
+		}
+		if serviceName != "" && s.ServiceName != serviceName {
+			return false
+		}
+		return isActiveBetween(s, date, date)
+	})
+
+	totals := map[string]float64{}
+	for i := range subs {
+		totals[subs[i].Currency] += subscriptionCost(&subs[i], date, date)
+	}
+	return totals, nil
+}
+
+// GetCostReport считает помесячную стоимость подписок за период [from, to]
+func (m *MemoryRepository) GetCostReport(_ context.Context, from, to time.Time, filter CostFilter) ([]models.CostReportMonth, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := m.filter(func(s *models.Subscription) bool {
+		if filter.UserID != nil && s.UserID != *filter.UserID {
+			return false
+		}
+		if filter.ServiceName != "" && s.ServiceName != filter.ServiceName {
+			return false
+		}
+		return isActiveBetween(s, from, to)
+	})
+	return monthlyBreakdown(subs, from, to), nil
+}
+
+// GetCostBreakdown считает стоимость подписок за день date по группам groupBy
+func (m *MemoryRepository) GetCostBreakdown(_ context.Context, date time.Time, groupBy BreakdownGroupBy, filter CostFilter) ([]CostGroup, error) {
+	if !groupBy.Valid() {
+		return nil, fmt.Errorf("unsupported group_by %q", groupBy)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := m.filter(func(s *models.Subscription) bool {
+		if filter.UserID != nil && s.UserID != *filter.UserID {
+			return false
+		}
+		if filter.ServiceName != "" && s.ServiceName != filter.ServiceName {
+			return false
+		}
+		return isActiveBetween(s, date, date)
+	})
+
+	type groupKey struct{ key, currency string }
+	totals := map[groupKey]float64{}
+	for i := range subs {
+		cost := subscriptionCost(&subs[i], date, date)
+		if cost <= 0 {
+			continue
+		}
+		var category string
+		if subs[i].ServiceID != nil {
+			category = m.services[*subs[i].ServiceID].Category
+		}
+		totals[groupKey{breakdownKey(&subs[i], groupBy, category), subs[i].Currency}] += cost
+	}
+
+	groups := make([]CostGroup, 0, len(totals))
+	for k, total := range totals {
+		groups = append(groups, CostGroup{Key: k.key, Currency: k.currency, Total: total})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Key != groups[j].Key {
+			return groups[i].Key < groups[j].Key
+		}
+		return groups[i].Currency < groups[j].Currency
+	})
+	return groups, nil
+}
+
+// Cancel завершает подписку датой c.EffectiveDate и сохраняет запись об отмене
+func (m *MemoryRepository) Cancel(_ context.Context, c *models.Cancellation, version int, change *Change) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.live(c.SubscriptionID)
+	if !ok {
+		return ErrNotFound
+	}
+	if sub.Version != version {
+		return ErrVersionConflict
+	}
+	if sub.EndDate != nil && sub.EndDate.Before(truncateDay(c.CancelledAt)) {
+		return ErrAlreadyEnded
+	}
+	if err := m.recordChange(change, models.ActionCancel, sub.ID); err != nil {
+		return err
+	}
+
+	effective := truncateDay(c.EffectiveDate)
+	sub.EndDate = &effective
+	sub.Version++
+	m.subscriptions[sub.ID] = sub
+	m.cancellations = append(m.cancellations, *c)
+	return nil
+}
+
+// Pause приостанавливает подписку на интервал p
+func (m *MemoryRepository) Pause(_ context.Context, p *models.Pause) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.live(p.SubscriptionID)
+	if !ok {
+		return ErrNotFound
+	}
+
+	pause := *p
+	pause.StartDate = truncateDay(pause.StartDate)
+	pause.EndDate = truncateDayPtr(pause.EndDate)
+
+	if sub.EndDate != nil && sub.EndDate.Before(pause.StartDate) {
+		return ErrAlreadyEnded
+	}
+	for _, existing := range sub.Pauses {
+		endsBefore := existing.EndDate != nil && existing.EndDate.Before(pause.StartDate)
+		startsAfter := pause.EndDate != nil && existing.StartDate.After(*pause.EndDate)
+		if !endsBefore && !startsAfter {
+			return ErrAlreadyPaused
+		}
+	}
+
+	sub.Pauses = append(append([]models.Pause(nil), sub.Pauses...), pause)
+	sort.Slice(sub.Pauses, func(i, j int) bool {
+		return sub.Pauses[i].StartDate.Before(sub.Pauses[j].StartDate)
+	})
+	sub.Version++
+	m.subscriptions[sub.ID] = sub
+	return nil
+}
+
+// Resume завершает незавершенную паузу: подписка снова активна с дня date
+func (m *MemoryRepository) Resume(_ context.Context, subscriptionID uuid.UUID, date time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.live(subscriptionID)
+	if !ok {
+		return ErrNotFound
+	}
+	date = truncateDay(date)
+
+	pauses := append([]models.Pause(nil), sub.Pauses...)
+	for i, p := range pauses {
+		if p.EndDate != nil && p.EndDate.Before(date) {
+			continue
+		}
+
+		lastPausedDay := date.AddDate(0, 0, -1)
+		if lastPausedDay.Before(p.StartDate) {
+			pauses = append(pauses[:i], pauses[i+1:]...)
+		} else {
+			pauses[i].EndDate = &lastPausedDay
+		}
+		sub.Pauses = pauses
+		sub.Version++
+		m.subscriptions[sub.ID] = sub
+		return nil
+	}
+
+	return ErrNotPaused
+}
+
+// SchedulePriceChange сохраняет новую цену подписки с даты pc.EffectiveFrom
+func (m *MemoryRepository) SchedulePriceChange(_ context.Context, pc *models.PriceChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.live(pc.SubscriptionID)
+	if !ok {
+		return ErrNotFound
+	}
+
+	change := *pc
+	change.EffectiveFrom = truncateDay(change.EffectiveFrom)
+
+	changes := append([]models.PriceChange(nil), sub.PriceChanges...)
+	replaced := false
+	for i := range changes {
+		if changes[i].EffectiveFrom.Equal(change.EffectiveFrom) {
+			changes[i].Price = change.Price
+			pc.ID = changes[i].ID
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		changes = append(changes, change)
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].EffectiveFrom.Before(changes[j].EffectiveFrom)
+		})
+	}
+
+	sub.PriceChanges = changes
+	sub.Version++
+	m.subscriptions[sub.ID] = sub
+	return nil
+}
+
+// ListTrialsEndingBetween возвращает действующие подписки,
+// у которых пробный период заканчивается в [from, to]
+func (m *MemoryRepository) ListTrialsEndingBetween(_ context.Context, from, to time.Time) ([]models.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := m.filter(func(s *models.Subscription) bool {
+		if s.TrialEnd == nil || s.TrialEnd.Before(from) || s.TrialEnd.After(to) {
+			return false
+		}
+		return s.EndDate == nil || !s.EndDate.Before(*s.TrialEnd)
+	})
+	sort.Slice(subs, func(i, j int) bool {
+		if !subs[i].TrialEnd.Equal(*subs[j].TrialEnd) {
+			return subs[i].TrialEnd.Before(*subs[j].TrialEnd)
+		}
+		return bytes.Compare(subs[i].ID[:], subs[j].ID[:]) < 0
+	})
+	return subs, nil
+}
+
+// SearchSubscriptions ищет подписки по похожести названия сервиса так же, как pg_trgm
+func (m *MemoryRepository) SearchSubscriptions(_ context.Context, query string, userID *uuid.UUID, limit int) ([]models.SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := []models.SearchResult{}
+	for _, sub := range m.filter(func(s *models.Subscription) bool {
+		return userID == nil || s.UserID == *userID
+	}) {
+		similarity := trigramSimilarity(sub.ServiceName, query)
+		wordSim := wordSimilarity(query, sub.ServiceName)
+		if similarity < similarityThreshold && wordSim < wordSimilarityThreshold {
+			continue
+		}
+		results = append(results, models.SearchResult{
+			Subscription: sub,
+			Relevance:    (similarity + wordSim) / 2,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := &results[i], &results[j]
+		if a.Relevance != b.Relevance {
+			return a.Relevance > b.Relevance
+		}
+		if a.ServiceName != b.ServiceName {
+			return a.ServiceName < b.ServiceName
+		}
+		return bytes.Compare(a.ID[:], b.ID[:]) < 0
+	})
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// filter возвращает копии подходящих неудаленных подписок, упорядоченные по (start_date, id).
+// Вызывающий должен держать m.mu.
+func (m *MemoryRepository) filter(match func(s *models.Subscription) bool) []models.Subscription {
+	return m.filterDeleted(false, match)
+}
+
+// filterDeleted работает как filter; includeDeleted добавляет удаленные подписки
+func (m *MemoryRepository) filterDeleted(includeDeleted bool, match func(s *models.Subscription) bool) []models.Subscription {
+	subs := []models.Subscription{}
+	for _, sub := range m.subscriptions {
+		if sub.DeletedAt != nil && !includeDeleted {
+			continue
+		}
+		if match(&sub) {
+			subs = append(subs, cloneSubscription(sub))
+		}
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		if !subs[i].StartDate.Equal(subs[j].StartDate) {
+			return subs[i].StartDate.Before(subs[j].StartDate)
+		}
+		return bytes.Compare(subs[i].ID[:], subs[j].ID[:]) < 0
+	})
+	return subs
+}
+
+// isActiveBetween повторяет условие activeBetween для подписки в памяти
+func isActiveBetween(s *models.Subscription, from, to time.Time) bool {
+	return !s.StartDate.After(to) && (s.EndDate == nil || !s.EndDate.Before(from))
+}
+
+// matches повторяет условия applyListFilter для одной подписки
+func (f ListFilter) matches(s *models.Subscription) bool {
+	if f.InTrial != nil {
+		inTrial := s.TrialEnd != nil && s.TrialEnd.After(f.Today)
+		if inTrial != *f.InTrial {
+			return false
+		}
+	}
+	if f.UserID != nil && s.UserID != *f.UserID {
+		return false
+	}
+	if f.ServiceName != "" && s.ServiceName != f.ServiceName {
+		return false
+	}
+	if f.ServiceNamePrefix != "" && !strings.HasPrefix(s.ServiceName, f.ServiceNamePrefix) {
+		return false
+	}
+	if f.MinPrice != nil && s.Price < *f.MinPrice {
+		return false
+	}
+	if f.MaxPrice != nil && s.Price > *f.MaxPrice {
+		return false
+	}
+	if f.ActiveOn != nil && !isActiveBetween(s, *f.ActiveOn, *f.ActiveOn) {
+		return false
+	}
+	if f.StartFrom != nil && s.StartDate.Before(*f.StartFrom) {
+		return false
+	}
+	if f.StartTo != nil && s.StartDate.After(*f.StartTo) {
+		return false
+	}
+	return true
+}
+
+// lessBySort сравнивает подписки так же, как listOrder в SQL.
+// Как и в Postgres, пустые даты идут последними при возрастании и первыми при убывании.
+func lessBySort(a, b *models.Subscription, fields []SortField) bool {
+	for _, field := range fields {
+		cmp := compareField(a, b, field.Field)
+		if cmp == 0 {
+			continue
+		}
+		if field.Desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) < 0
+}
+
+// compareField сравнивает значения поля сортировки; пустая дата больше любой другой
+func compareField(a, b *models.Subscription, field string) int {
+	switch field {
+	case "service_name":
+		return strings.Compare(a.ServiceName, b.ServiceName)
+	case "price":
+		return a.Price - b.Price
+	case "currency":
+		return strings.Compare(a.Currency, b.Currency)
+	case "billing_period":
+		return strings.Compare(string(a.BillingPeriod), string(b.BillingPeriod))
+	case "user_id":
+		return bytes.Compare(a.UserID[:], b.UserID[:])
+	case "start_date":
+		return a.StartDate.Compare(b.StartDate)
+	case "end_date":
+		return compareDatePtr(a.EndDate, b.EndDate)
+	case "trial_end":
+		return compareDatePtr(a.TrialEnd, b.TrialEnd)
+	}
+	return 0
+}
+
+func compareDatePtr(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+// before сообщает, идет ли курсор раньше подписки в порядке (start_date, id)
+func (c *ListCursor) before(s *models.Subscription) bool {
+	if !c.StartDate.Equal(s.StartDate) {
+		return c.StartDate.Before(s.StartDate)
+	}
+	return bytes.Compare(c.ID[:], s.ID[:]) < 0
+}
+
+// paginate применяет limit и offset так же, как LIMIT/OFFSET в SQL
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return []T{}
+		}
+		items = items[offset:]
+	}
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// normalizeSubscription отбрасывает время у дат, как это делает колонка DATE
+func normalizeSubscription(sub models.Subscription) models.Subscription {
+	sub.StartDate = truncateDay(sub.StartDate)
+	sub.EndDate = truncateDayPtr(sub.EndDate)
+	sub.TrialEnd = truncateDayPtr(sub.TrialEnd)
+	if sub.ServiceID != nil {
+		id := *sub.ServiceID
+		sub.ServiceID = &id
+	}
+	sub.Status = ""
+	sub.CurrentPrice = 0
+	return sub
+}
+
+// cloneSubscription копирует подписку вместе с историей,
+// чтобы вызывающий код не мог изменить данные хранилища
+func cloneSubscription(sub models.Subscription) models.Subscription {
+	sub.EndDate = copyTime(sub.EndDate)
+	sub.TrialEnd = copyTime(sub.TrialEnd)
+	if sub.ServiceID != nil {
+		id := *sub.ServiceID
+		sub.ServiceID = &id
+	}
+	if sub.Pauses != nil {
+		pauses := make([]models.Pause, len(sub.Pauses))
+		for i, p := range sub.Pauses {
+			p.EndDate = copyTime(p.EndDate)
+			pauses[i] = p
+		}
+		sub.Pauses = pauses
+	}
+	if sub.PriceChanges != nil {
+		sub.PriceChanges = append([]models.PriceChange(nil), sub.PriceChanges...)
+	}
+	return sub
+}
+
+func truncateDayPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	day := truncateDay(*t)
+	return &day
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+var _ Storage = (*MemoryRepository)(nil)
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// budgetAlertKey — уведомление о пороге бюджета в конкретном месяце
+type budgetAlertKey struct {
+	userID    uuid.UUID
+	month     time.Time
+	threshold int
+}
+
+// SetBudget создает бюджет пользователя или заменяет существующий
+func (m *MemoryRepository) SetBudget(_ context.Context, b *models.Budget) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.budgets[b.UserID] = cloneBudget(*b)
+	return nil
+}
+
+// GetBudget возвращает бюджет пользователя
+func (m *MemoryRepository) GetBudget(_ context.Context, userID uuid.UUID) (*models.Budget, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, ok := m.budgets[userID]
+	if !ok {
+		return nil, ErrBudgetNotFound
+	}
+	b = cloneBudget(b)
+	return &b, nil
+}
+
+// DeleteBudget удаляет бюджет пользователя вместе с историей уведомлений
+func (m *MemoryRepository) DeleteBudget(_ context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.budgets[userID]; !ok {
+		return ErrBudgetNotFound
+	}
+	delete(m.budgets, userID)
+	for key := range m.budgetAlerts {
+		if key.userID == userID {
+			delete(m.budgetAlerts, key)
+		}
+	}
+	return nil
+}
+
+// ListBudgets возвращает все бюджеты, упорядоченные по пользователю
+func (m *MemoryRepository) ListBudgets(_ context.Context) ([]models.Budget, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	budgets := make([]models.Budget, 0, len(m.budgets))
+	for _, b := range m.budgets {
+		budgets = append(budgets, cloneBudget(b))
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		return budgets[i].UserID.String() < budgets[j].UserID.String()
+	})
+	return budgets, nil
+}
+
+// BudgetAlertSent сообщает, уведомляли ли пользователя о пороге threshold в месяце month
+func (m *MemoryRepository) BudgetAlertSent(_ context.Context, userID uuid.UUID, month time.Time, threshold int) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.budgetAlerts[budgetAlertKey{userID, firstOfMonth(month), threshold}]
+	return ok, nil
+}
+
+// RecordBudgetAlert запоминает уведомление о пороге threshold в месяце month
+func (m *MemoryRepository) RecordBudgetAlert(_ context.Context, userID uuid.UUID, month time.Time, threshold int, sentAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Повторяем внешний ключ budget_alerts.user_id
+	if _, ok := m.budgets[userID]; !ok {
+		return fmt.Errorf("budget of user %s does not exist", userID)
+	}
+	key := budgetAlertKey{userID, firstOfMonth(month), threshold}
+	if _, ok := m.budgetAlerts[key]; !ok {
+		m.budgetAlerts[key] = sentAt
+	}
+	return nil
+}
+
+func cloneBudget(b models.Budget) models.Budget {
+	b.Thresholds = append([]int{}, b.Thresholds...)
+	return b
+}
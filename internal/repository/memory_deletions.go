@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// GetDeleted возвращает удаленную подписку.
+// Для существующей неудаленной подписки возвращается ErrNotDeleted.
+func (m *MemoryRepository) GetDeleted(_ context.Context, id uuid.UUID) (*models.Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sub, ok := m.subscriptions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if sub.DeletedAt == nil {
+		return nil, ErrNotDeleted
+	}
+	sub = cloneSubscription(sub)
+	return &sub, nil
+}
+
+// Restore снимает с подписки отметку об удалении и увеличивает её версию,
+// если подписка удалена и её версия равна version
+func (m *MemoryRepository) Restore(_ context.Context, id uuid.UUID, version int, change *Change) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.subscriptions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if sub.DeletedAt == nil {
+		return ErrNotDeleted
+	}
+	if sub.Version != version {
+		return ErrVersionConflict
+	}
+	if err := m.recordChange(change, models.ActionRestore, id); err != nil {
+		return err
+	}
+
+	sub.DeletedAt = nil
+	sub.Version++
+	m.subscriptions[id] = sub
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет подписки, удаленные раньше before, вместе с их историей
+func (m *MemoryRepository) PurgeDeleted(_ context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := map[uuid.UUID]bool{}
+	for id, sub := range m.subscriptions {
+		if sub.DeletedAt != nil && sub.DeletedAt.Before(before) {
+			delete(m.subscriptions, id)
+			purged[id] = true
+		}
+	}
+	if len(purged) == 0 {
+		return 0, nil
+	}
+
+	// Повторяем ON DELETE CASCADE таблиц истории
+	kept := m.cancellations[:0]
+	for _, c := range m.cancellations {
+		if !purged[c.SubscriptionID] {
+			kept = append(kept, c)
+		}
+	}
+	m.cancellations = kept
+
+	for key := range m.reminders {
+		if purged[key.subscriptionID] {
+			delete(m.reminders, key)
+		}
+	}
+	return len(purged), nil
+}
+
+// live возвращает неудаленную подписку по id.
+// Вызывающий должен держать m.mu.
+func (m *MemoryRepository) live(id uuid.UUID) (models.Subscription, bool) {
+	sub, ok := m.subscriptions[id]
+	if !ok || sub.DeletedAt != nil {
+		return models.Subscription{}, false
+	}
+	return sub, true
+}
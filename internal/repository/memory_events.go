@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// recordChange дописывает в журнал событие об изменении подписки id.
+// Вызывается под m.mu до сохранения изменения, чтобы ошибка журнала его отменяла.
+func (m *MemoryRepository) recordChange(change *Change, action models.SubscriptionAction, id uuid.UUID) error {
+	if change == nil {
+		return nil
+	}
+	e, err := change.event(action, id)
+	if err != nil {
+		return err
+	}
+	m.appendEvent(e)
+	return nil
+}
+
+// appendEvent дописывает запись в журнал под m.mu и заполняет e.ID
+func (m *MemoryRepository) appendEvent(e *models.SubscriptionEvent) {
+	e.ID = int64(len(m.events) + 1)
+	stored := *e
+	stored.Before = append([]byte(nil), e.Before...)
+	stored.After = append([]byte(nil), e.After...)
+	m.events = append(m.events, stored)
+}
+
+// ListEvents возвращает страницу журнала подписки в порядке occurred_at
+func (m *MemoryRepository) ListEvents(_ context.Context, subscriptionID uuid.UUID, limit, offset int) ([]models.SubscriptionEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := []models.SubscriptionEvent{}
+	for _, e := range m.events {
+		if e.SubscriptionID == subscriptionID {
+			events = append(events, e)
+		}
+	}
+	// Записи хранятся в порядке id, поэтому при равном времени порядок сохраняется
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+	return paginate(events, limit, offset), nil
+}
+
+// CountEvents возвращает число записей журнала подписки
+func (m *MemoryRepository) CountEvents(_ context.Context, subscriptionID uuid.UUID) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, e := range m.events {
+		if e.SubscriptionID == subscriptionID {
+			count++
+		}
+	}
+	return count, nil
+}
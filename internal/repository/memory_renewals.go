@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// reminderKey — напоминание о конкретном списании подписки
+type reminderKey struct {
+	subscriptionID uuid.UUID
+	chargeDate     time.Time
+}
+
+// ListUpcomingCharges возвращает подписки, ближайшее списание которых попадает в [from, to]
+func (m *MemoryRepository) ListUpcomingCharges(_ context.Context, from, to time.Time, userID *uuid.UUID) ([]models.UpcomingCharge, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subs := m.filter(func(s *models.Subscription) bool {
+		if userID != nil && s.UserID != *userID {
+			return false
+		}
+		return isActiveBetween(s, from, to)
+	})
+	return upcomingCharges(subs, from, to), nil
+}
+
+// ReminderSent сообщает, отправлено ли напоминание о списании chargeDate
+func (m *MemoryRepository) ReminderSent(_ context.Context, subscriptionID uuid.UUID, chargeDate time.Time) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.reminders[reminderKey{subscriptionID, truncateDay(chargeDate)}]
+	return ok, nil
+}
+
+// RecordReminder запоминает напоминание о списании chargeDate
+func (m *MemoryRepository) RecordReminder(_ context.Context, subscriptionID uuid.UUID, chargeDate, sentAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Повторяем внешний ключ renewal_reminders.subscription_id
+	if _, ok := m.subscriptions[subscriptionID]; !ok {
+		return fmt.Errorf("subscription %s does not exist", subscriptionID)
+	}
+	key := reminderKey{subscriptionID, truncateDay(chargeDate)}
+	if _, ok := m.reminders[key]; !ok {
+		m.reminders[key] = sentAt
+	}
+	return nil
+}
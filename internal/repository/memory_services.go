@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// CreateService добавляет запись в каталог
+func (m *MemoryRepository) CreateService(_ context.Context, svc *models.Service) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.services[svc.ID]; ok {
+		return fmt.Errorf("service %s already exists", svc.ID)
+	}
+	if m.serviceKeyTaken(svc) {
+		return ErrServiceConflict
+	}
+
+	m.services[svc.ID] = cloneService(*svc)
+	return nil
+}
+
+// GetService возвращает запись каталога по id
+func (m *MemoryRepository) GetService(_ context.Context, id uuid.UUID) (*models.Service, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	svc, ok := m.services[id]
+	if !ok {
+		return nil, ErrServiceNotFound
+	}
+	svc = cloneService(svc)
+	return &svc, nil
+}
+
+// ResolveService ищет запись по названию или псевдониму без учета регистра.
+// Совпадение с названием важнее совпадения с псевдонимом.
+func (m *MemoryRepository) ResolveService(_ context.Context, name string) (*models.Service, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key := strings.ToLower(strings.TrimSpace(name))
+	var byAlias *models.Service
+	for _, svc := range m.services {
+		if strings.ToLower(svc.Name) == key {
+			svc = cloneService(svc)
+			return &svc, nil
+		}
+		for _, alias := range svc.Aliases {
+			if alias == key && byAlias == nil {
+				found := cloneService(svc)
+				byAlias = &found
+			}
+		}
+	}
+	if byAlias == nil {
+		return nil, ErrServiceNotFound
+	}
+	return byAlias, nil
+}
+
+// ListServices возвращает записи каталога по алфавиту; пустая category не ограничивает выборку
+func (m *MemoryRepository) ListServices(_ context.Context, category string) ([]models.Service, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	services := []models.Service{}
+	for _, svc := range m.services {
+		if category == "" || svc.Category == category {
+			services = append(services, cloneService(svc))
+		}
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return strings.ToLower(services[i].Name) < strings.ToLower(services[j].Name)
+	})
+	return services, nil
+}
+
+// UpdateService изменяет запись каталога и название связанных подписок
+func (m *MemoryRepository) UpdateService(_ context.Context, svc *models.Service, change *Change) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.serviceKeyTaken(svc) {
+		return ErrServiceConflict
+	}
+	if _, ok := m.services[svc.ID]; !ok {
+		return ErrServiceNotFound
+	}
+
+	updated, err := m.changeLinked(svc.ID, change, func(sub *models.Subscription) {
+		sub.ServiceName = svc.Name
+	})
+	if err != nil {
+		return err
+	}
+	m.services[svc.ID] = cloneService(*svc)
+	for _, sub := range updated {
+		m.subscriptions[sub.ID] = sub
+	}
+	return nil
+}
+
+// DeleteService удаляет запись каталога; подписки сохраняют service_name и теряют service_id
+func (m *MemoryRepository) DeleteService(_ context.Context, id uuid.UUID, change *Change) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.services[id]; !ok {
+		return ErrServiceNotFound
+	}
+	updated, err := m.changeLinked(id, change, func(sub *models.Subscription) {
+		sub.ServiceID = nil
+	})
+	if err != nil {
+		return err
+	}
+	delete(m.services, id)
+
+	// Удаленные подписки теряют service_id без новой версии, как при ON DELETE SET NULL
+	for subID, sub := range m.subscriptions {
+		if sub.ServiceID != nil && *sub.ServiceID == id {
+			sub.ServiceID = nil
+			m.subscriptions[subID] = sub
+		}
+	}
+	for _, sub := range updated {
+		m.subscriptions[sub.ID] = sub
+	}
+	return nil
+}
+
+// changeLinked применяет apply к неудаленным подпискам сервиса id, увеличивает их версию
+// и записывает изменения в журнал. Возвращает новые состояния подписок, которые
+// вызывающий сохраняет сам. Вызывающий должен держать m.mu.
+func (m *MemoryRepository) changeLinked(id uuid.UUID, change *Change, apply func(sub *models.Subscription)) ([]models.Subscription, error) {
+	updated := []models.Subscription{}
+	events := []*models.SubscriptionEvent{}
+	for _, sub := range m.subscriptions {
+		if sub.DeletedAt != nil || sub.ServiceID == nil || *sub.ServiceID != id {
+			continue
+		}
+		before := cloneSubscription(sub)
+		before.RefreshDerived()
+		after := before
+		apply(&after)
+		after.Version++
+		if change != nil {
+			e, err := change.forSubscription(&before, &after).event(models.ActionUpdate, sub.ID)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, e)
+		}
+		apply(&sub)
+		sub.Version++
+		updated = append(updated, sub)
+	}
+
+	// Журнал дописывается, только когда все записи собраны
+	for _, e := range events {
+		m.appendEvent(e)
+	}
+	return updated, nil
+}
+
+// serviceKeyTaken повторяет проверку checkServiceConflict.
+// Вызывающий должен держать m.mu.
+func (m *MemoryRepository) serviceKeyTaken(svc *models.Service) bool {
+	keys := make(map[string]bool)
+	for _, key := range serviceKeys(svc) {
+		keys[key] = true
+	}
+
+	for id, other := range m.services {
+		if id == svc.ID {
+			continue
+		}
+		for _, key := range serviceKeys(&other) {
+			if keys[key] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkServiceRef повторяет внешний ключ subscriptions.service_id.
+// Вызывающий должен держать m.mu.
+func (m *MemoryRepository) checkServiceRef(sub *models.Subscription) error {
+	if sub.ServiceID == nil {
+		return nil
+	}
+	if _, ok := m.services[*sub.ServiceID]; !ok {
+		return fmt.Errorf("service %s does not exist", *sub.ServiceID)
+	}
+	return nil
+}
+
+func cloneService(svc models.Service) models.Service {
+	svc.Aliases = append([]string{}, svc.Aliases...)
+	if svc.DefaultPrice != nil {
+		price := *svc.DefaultPrice
+		svc.DefaultPrice = &price
+	}
+	return svc
+}
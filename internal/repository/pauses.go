@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+var (
+	// ErrAlreadyPaused возвращается, если новая пауза пересекается с существующей
+	ErrAlreadyPaused = errors.New("subscription is already paused")
+	// ErrNotPaused возвращается при возобновлении подписки без незавершенной паузы
+	ErrNotPaused = errors.New("subscription is not paused")
+)
+
+// Pause приостанавливает подписку на интервал p.
+// Пауза не может пересекаться с другими паузами и выходить за период подписки.
+func (r *Repository) Pause(ctx context.Context, p *models.Pause) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("Pause: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var endDate *time.Time
+	err = tx.QueryRow(ctx,
+		"SELECT end_date FROM subscriptions WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", p.SubscriptionID,
+	).Scan(&endDate)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Pause: ошибка чтения подписки: %v", err)
+		return err
+	}
+	if endDate != nil && endDate.Before(p.StartDate) {
+		return ErrAlreadyEnded
+	}
+
+	// Пересечение [start, end] с существующими паузами; NULL — бесконечность
+	overlap := squirrel.Select("1").
+		From("subscription_pauses").
+		Where(squirrel.Eq{"subscription_id": p.SubscriptionID}).
+		Where(squirrel.Or{squirrel.Eq{"end_date": nil}, squirrel.GtOrEq{"end_date": p.StartDate}}).
+		PlaceholderFormat(squirrel.Dollar)
+	if p.EndDate != nil {
+		overlap = overlap.Where(squirrel.LtOrEq{"start_date": *p.EndDate})
+	}
+
+	overlapSQL, args, err := overlap.Prefix("SELECT EXISTS (").Suffix(")").ToSql()
+	if err != nil {
+		log.Printf("Pause: ошибка формирования SQL: %v", err)
+		return err
+	}
+	var exists bool
+	if err := tx.QueryRow(ctx, overlapSQL, args...).Scan(&exists); err != nil {
+		log.Printf("Pause: ошибка проверки пересечения пауз: %v", err)
+		return err
+	}
+	if exists {
+		return ErrAlreadyPaused
+	}
+
+	insertSQL, args, err := squirrel.Insert("subscription_pauses").
+		Columns("id", "subscription_id", "start_date", "end_date").
+		Values(p.ID, p.SubscriptionID, p.StartDate, p.EndDate).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Printf("Pause: ошибка формирования SQL: %v", err)
+		return err
+	}
+	if _, err := tx.Exec(ctx, insertSQL, args...); err != nil {
+		log.Printf("Pause: ошибка записи паузы: %v", err)
+		return err
+	}
+	if err := bumpVersion(ctx, tx, p.SubscriptionID, "Pause"); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Resume завершает незавершенную паузу: подписка снова активна с дня date.
+// Пауза, которая так и не успела начаться, удаляется.
+func (r *Repository) Resume(ctx context.Context, subscriptionID uuid.UUID, date time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("Resume: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	err = tx.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM subscriptions WHERE id = $1 AND deleted_at IS NULL)", subscriptionID,
+	).Scan(&exists)
+	if err != nil {
+		log.Printf("Resume: ошибка чтения подписки: %v", err)
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	var pause models.Pause
+	err = tx.QueryRow(ctx, `
+		SELECT id, start_date FROM subscription_pauses
+		WHERE subscription_id = $1 AND (end_date IS NULL OR end_date >= $2)
+		ORDER BY start_date
+		LIMIT 1
+		FOR UPDATE`, subscriptionID, date,
+	).Scan(&pause.ID, &pause.StartDate)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotPaused
+	}
+	if err != nil {
+		log.Printf("Resume: ошибка поиска паузы: %v", err)
+		return err
+	}
+
+	lastPausedDay := date.AddDate(0, 0, -1)
+	if lastPausedDay.Before(pause.StartDate) {
+		_, err = tx.Exec(ctx, "DELETE FROM subscription_pauses WHERE id = $1", pause.ID)
+	} else {
+		_, err = tx.Exec(ctx, "UPDATE subscription_pauses SET end_date = $1 WHERE id = $2", lastPausedDay, pause.ID)
+	}
+	if err != nil {
+		log.Printf("Resume: ошибка обновления паузы: %v", err)
+		return err
+	}
+	if err := bumpVersion(ctx, tx, subscriptionID, "Resume"); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// attachPauses подгружает историю пауз для подписок одним запросом
+func (r *Repository) attachPauses(ctx context.Context, subs []models.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ids, index := subscriptionIndex(subs)
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, subscription_id, start_date, end_date FROM subscription_pauses
+		WHERE subscription_id = ANY($1::uuid[])
+		ORDER BY start_date`, ids)
+	if err != nil {
+		log.Printf("attachPauses: ошибка выполнения запроса: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.Pause
+		if err := rows.Scan(&p.ID, &p.SubscriptionID, &p.StartDate, &p.EndDate); err != nil {
+			log.Printf("attachPauses: ошибка сканирования строки: %v", err)
+			return err
+		}
+		i := index[p.SubscriptionID]
+		subs[i].Pauses = append(subs[i].Pauses, p)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/EvgenyiK/subscription-service/internal/migrate"
+	"github.com/EvgenyiK/subscription-service/migrations"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TestPostgresRepositoryConformance запускается только при заданном
+// TEST_DATABASE_URL и очищает таблицы подписок в этой базе.
+func TestPostgresRepositoryConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	runConformance(t, func(t *testing.T) Storage {
+		if _, err := pool.Exec(ctx, "TRUNCATE subscriptions, services, budgets CASCADE"); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return &Repository{db: pool}
+	})
+}
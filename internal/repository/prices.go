@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+// SchedulePriceChange сохраняет новую цену подписки с даты pc.EffectiveFrom.
+// Повторное изменение с той же даты заменяет ранее запланированную цену.
+func (r *Repository) SchedulePriceChange(ctx context.Context, pc *models.PriceChange) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("SchedulePriceChange: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	err = tx.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM subscriptions WHERE id = $1 AND deleted_at IS NULL)", pc.SubscriptionID,
+	).Scan(&exists)
+	if err != nil {
+		log.Printf("SchedulePriceChange: ошибка чтения подписки: %v", err)
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	sqlStr, args, err := squirrel.Insert("subscription_prices").
+		Columns("id", "subscription_id", "price", "effective_from").
+		Values(pc.ID, pc.SubscriptionID, pc.Price, pc.EffectiveFrom).
+		Suffix("ON CONFLICT (subscription_id, effective_from) DO UPDATE SET price = EXCLUDED.price RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Printf("SchedulePriceChange: ошибка формирования SQL: %v", err)
+		return err
+	}
+	if err := tx.QueryRow(ctx, sqlStr, args...).Scan(&pc.ID); err != nil {
+		log.Printf("SchedulePriceChange: ошибка записи цены: %v", err)
+		return err
+	}
+	if err := bumpVersion(ctx, tx, pc.SubscriptionID, "SchedulePriceChange"); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// attachPrices подгружает историю цен для подписок одним запросом
+func (r *Repository) attachPrices(ctx context.Context, subs []models.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ids, index := subscriptionIndex(subs)
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, subscription_id, price, effective_from FROM subscription_prices
+		WHERE subscription_id = ANY($1::uuid[])
+		ORDER BY effective_from`, ids)
+	if err != nil {
+		log.Printf("attachPrices: ошибка выполнения запроса: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pc models.PriceChange
+		if err := rows.Scan(&pc.ID, &pc.SubscriptionID, &pc.Price, &pc.EffectiveFrom); err != nil {
+			log.Printf("attachPrices: ошибка сканирования строки: %v", err)
+			return err
+		}
+		i := index[pc.SubscriptionID]
+		subs[i].PriceChanges = append(subs[i].PriceChanges, pc)
+	}
+
+	return rows.Err()
+}
+
+// subscriptionIndex возвращает id подписок для запроса ANY($1::uuid[])
+// и позицию каждой подписки в срезе
+func subscriptionIndex(subs []models.Subscription) ([]string, map[uuid.UUID]int) {
+	ids := make([]string, len(subs))
+	index := make(map[uuid.UUID]int, len(subs))
+	for i := range subs {
+		ids[i] = subs[i].ID.String()
+		index[subs[i].ID] = i
+	}
+	return ids, index
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+// ReminderRepository хранит отправленные напоминания о списаниях
+type ReminderRepository interface {
+	// ReminderSent сообщает, отправлено ли напоминание о списании chargeDate
+	ReminderSent(ctx context.Context, subscriptionID uuid.UUID, chargeDate time.Time) (bool, error)
+	// RecordReminder запоминает напоминание; повторная запись не ошибка
+	RecordReminder(ctx context.Context, subscriptionID uuid.UUID, chargeDate, sentAt time.Time) error
+}
+
+// ListUpcomingCharges возвращает подписки, ближайшее списание которых попадает в [from, to].
+// Дата списания зависит от start_date, периодичности, пауз и пробного периода,
+// поэтому в базе отбираются только подписки, активные в этом окне, а дата считается в Go.
+func (r *Repository) ListUpcomingCharges(ctx context.Context, from, to time.Time, userID *uuid.UUID) ([]models.UpcomingCharge, error) {
+	queryBuilder := squirrel.Select(subscriptionColumns...).
+		From("subscriptions").
+		Where(activeBetween(from, to)).
+		Where(notDeleted).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if userID != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"user_id": *userID})
+	}
+
+	subs, err := r.querySubscriptions(ctx, "ListUpcomingCharges", queryBuilder)
+	if err != nil {
+		return nil, err
+	}
+	return upcomingCharges(subs, from, to), nil
+}
+
+// ReminderSent сообщает, отправлено ли напоминание о списании chargeDate
+func (r *Repository) ReminderSent(ctx context.Context, subscriptionID uuid.UUID, chargeDate time.Time) (bool, error) {
+	var sent bool
+	err := r.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM renewal_reminders WHERE subscription_id = $1 AND charge_date = $2)",
+		subscriptionID, truncateDay(chargeDate),
+	).Scan(&sent)
+	if err != nil {
+		log.Printf("ReminderSent: ошибка выполнения запроса: %v", err)
+		return false, err
+	}
+	return sent, nil
+}
+
+// RecordReminder запоминает напоминание о списании chargeDate
+func (r *Repository) RecordReminder(ctx context.Context, subscriptionID uuid.UUID, chargeDate, sentAt time.Time) error {
+	queryBuilder := squirrel.Insert("renewal_reminders").
+		Columns("subscription_id", "charge_date", "sent_at").
+		Values(subscriptionID, truncateDay(chargeDate), sentAt).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar)
+
+	sqlStr, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Printf("RecordReminder: ошибка формирования SQL: %v", err)
+		return err
+	}
+	if _, err := r.db.Exec(ctx, sqlStr, args...); err != nil {
+		log.Printf("RecordReminder: ошибка выполнения SQL: %v", err)
+		return err
+	}
+	return nil
+}
+
+// upcomingCharges оставляет подписки со списанием в [from, to],
+// упорядоченные по дате списания
+func upcomingCharges(subs []models.Subscription, from, to time.Time) []models.UpcomingCharge {
+	charges := []models.UpcomingCharge{}
+	for i := range subs {
+		charge, ok := subs[i].ChargeBetween(from, to)
+		if !ok {
+			continue
+		}
+		charges = append(charges, models.UpcomingCharge{
+			Subscription: subs[i],
+			ChargeDate:   charge,
+			Amount:       subs[i].PriceOn(charge),
+		})
+	}
+
+	sort.SliceStable(charges, func(i, j int) bool {
+		if !charges[i].ChargeDate.Equal(charges[j].ChargeDate) {
+			return charges[i].ChargeDate.Before(charges[j].ChargeDate)
+		}
+		return bytes.Compare(charges[i].ID[:], charges[j].ID[:]) < 0
+	})
+	return charges
+}
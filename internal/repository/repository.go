@@ -2,41 +2,160 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/EvgenyiK/subscription-service/internal/config"
 	"github.com/EvgenyiK/subscription-service/internal/models"
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"log"
 	"time"
 )
 
+var (
+	// ErrNotFound возвращается, если подписка с указанным ID не найдена
+	ErrNotFound = errors.New("subscription not found")
+	// ErrVersionConflict возвращается, если подписку успели изменить после чтения
+	ErrVersionConflict = errors.New("subscription version conflict")
+)
+
+// SubscriptionRepository хранит подписки.
+// Методы изменения, принимающие *Change, записывают его в журнал атомарно с изменением.
 type SubscriptionRepository interface {
-	Create(ctx context.Context, sub *models.Subscription) error
+	Create(ctx context.Context, sub *models.Subscription, change *Change) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
-	Update(ctx context.Context, sub *models.Subscription) error
-	Delete(ctx context.Context, userID uuid.UUID) error
-	GetAllSubscriptions(ctx context.Context, limit, offset int) ([]models.Subscription, error)
+	// GetDeleted возвращает удаленную подписку, которую еще можно восстановить
+	GetDeleted(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	// Update сохраняет поля подписки версии sub.Version и увеличивает версию;
+	// если columns заданы, меняются только они
+	Update(ctx context.Context, sub *models.Subscription, change *Change, columns ...string) error
+	// Delete удаляет подписку, если её текущая версия равна version
+	Delete(ctx context.Context, id uuid.UUID, version int, change *Change) error
+	// Restore возвращает удаленную подписку, если её текущая версия равна version
+	Restore(ctx context.Context, id uuid.UUID, version int, change *Change) error
+	// PurgeDeleted окончательно удаляет подписки, удаленные раньше before
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+	GetAllSubscriptions(ctx context.Context, limit, offset int, filter ListFilter) ([]models.Subscription, error)
+	CountSubscriptions(ctx context.Context, filter ListFilter) (int, error)
+	ListSubscriptionsAfter(ctx context.Context, after *ListCursor, limit int, filter ListFilter) ([]models.Subscription, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Subscription, error)
 	GetTotalSubscriptionCost(
 		ctx context.Context,
 		date time.Time,
 		filterByUser bool,
 		userID uuid.UUID,
 		serviceName string,
-	) (float64, error)
+	) (map[string]float64, error)
+	GetCostReport(ctx context.Context, from, to time.Time, filter CostFilter) ([]models.CostReportMonth, error)
+	GetCostBreakdown(ctx context.Context, date time.Time, groupBy BreakdownGroupBy, filter CostFilter) ([]CostGroup, error)
+	// Cancel отменяет подписку, если её текущая версия равна version
+	Cancel(ctx context.Context, c *models.Cancellation, version int, change *Change) error
+	Pause(ctx context.Context, p *models.Pause) error
+	Resume(ctx context.Context, subscriptionID uuid.UUID, date time.Time) error
+	SchedulePriceChange(ctx context.Context, pc *models.PriceChange) error
+	ListTrialsEndingBetween(ctx context.Context, from, to time.Time) ([]models.Subscription, error)
+	SearchSubscriptions(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]models.SearchResult, error)
+	ListUpcomingCharges(ctx context.Context, from, to time.Time, userID *uuid.UUID) ([]models.UpcomingCharge, error)
+}
+
+// Storage объединяет подписки, каталог сервисов, бюджеты и напоминания одного хранилища
+type Storage interface {
+	SubscriptionRepository
+	ServiceCatalog
+	BudgetRepository
+	ReminderRepository
+	EventRepository
+}
+
+// CostFilter ограничивает подписки, попадающие в расчет стоимости
+type CostFilter struct {
+	UserID      *uuid.UUID
+	ServiceName string
 }
 
 type Repository struct {
 	db *pgxpool.Pool
 }
 
+// updatableColumns — колонки подписки, которые можно изменить через Update
+var updatableColumns = []string{
+	"service_name", "price", "currency", "billing_period", "user_id", "start_date", "end_date", "trial_end", "service_id",
+}
+
+// columnValues возвращает значения updatableColumns подписки
+func columnValues(sub *models.Subscription) map[string]interface{} {
+	return map[string]interface{}{
+		"service_name":   sub.ServiceName,
+		"price":          sub.Price,
+		"currency":       sub.Currency,
+		"billing_period": sub.BillingPeriod,
+		"user_id":        sub.UserID,
+		"start_date":     sub.StartDate,
+		"end_date":       sub.EndDate,
+		"trial_end":      sub.TrialEnd,
+		"service_id":     sub.ServiceID,
+	}
+}
+
+// ChangedColumns возвращает колонки, значения которых у after отличаются от before
+func ChangedColumns(before, after *models.Subscription) []string {
+	old, updated := columnValues(before), columnValues(after)
+
+	var changed []string
+	for _, column := range updatableColumns {
+		if !sameValue(old[column], updated[column]) {
+			changed = append(changed, column)
+		}
+	}
+	return changed
+}
+
+// sameValue сравнивает значения колонок; даты сравниваются как моменты времени
+func sameValue(a, b interface{}) bool {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Equal(b.(time.Time))
+	case *time.Time:
+		b := b.(*time.Time)
+		return a == b || (a != nil && b != nil && a.Equal(*b))
+	case *uuid.UUID:
+		b := b.(*uuid.UUID)
+		return a == b || (a != nil && b != nil && *a == *b)
+	default:
+		return a == b
+	}
+}
+
+// subscriptionColumns — порядок колонок, который ожидает scanSubscription
+var subscriptionColumns = []string{
+	"id", "service_name", "price", "currency", "billing_period", "user_id", "start_date", "end_date", "trial_end", "service_id", "version", "deleted_at",
+}
+
+// scanSubscription читает строку, выбранную по subscriptionColumns.
+// Колонки, выбранные после них, читаются в extra.
+func scanSubscription(row pgx.Row, sub *models.Subscription, extra ...interface{}) error {
+	dest := []interface{}{
+		&sub.ID,
+		&sub.ServiceName,
+		&sub.Price,
+		&sub.Currency,
+		&sub.BillingPeriod,
+		&sub.UserID,
+		&sub.StartDate,
+		&sub.EndDate,
+		&sub.TrialEnd,
+		&sub.ServiceID,
+		&sub.Version,
+		&sub.DeletedAt,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
 // NewRepository создает новое подключение к базе данных
 func NewRepository(cfg *config.Config) (*Repository, error) {
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
-
-	pool, err := pgxpool.Connect(context.Background(), dsn)
+	pool, err := pgxpool.Connect(context.Background(), cfg.DSN())
 	if err != nil {
 		return nil, err
 	}
@@ -44,11 +163,13 @@ func NewRepository(cfg *config.Config) (*Repository, error) {
 	return &Repository{db: pool}, nil
 }
 
-// Create добавляет новую подписку в базу данных с помощью Squirrel
-func (r *Repository) Create(ctx context.Context, sub *models.Subscription) error {
+// Create добавляет новую подписку в базу данных с помощью Squirrel.
+// Новая подписка получает версию 1.
+func (r *Repository) Create(ctx context.Context, sub *models.Subscription, change *Change) error {
+	sub.Version = 1
 	queryBuilder := squirrel.Insert("subscriptions").
-		Columns("id", "service_name", "price", "user_id", "start_date", "end_date").
-		Values(sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate).
+		Columns(subscriptionColumns...).
+		Values(sub.ID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.UserID, sub.StartDate, sub.EndDate, sub.TrialEnd, sub.ServiceID, sub.Version, sub.DeletedAt).
 		PlaceholderFormat(squirrel.Dollar)
 
 	sqlStr, args, err := queryBuilder.ToSql()
@@ -57,49 +178,85 @@ func (r *Repository) Create(ctx context.Context, sub *models.Subscription) error
 		return err
 	}
 
-	_, err = r.db.Exec(ctx, sqlStr, args...)
-	return err
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("Create: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, sqlStr, args...); err != nil {
+		return err
+	}
+	if err := recordChange(ctx, tx, change, models.ActionCreate, sub.ID, "Create"); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-// GetByID возвращает подписку по user_id
+// GetByID возвращает подписку по её собственному id
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
-	queryBuilder := squirrel.Select("id", "service_name", "price", "user_id", "start_date", "end_date").
+	return r.getByID(ctx, id, notDeleted, "GetByID")
+}
+
+// getByID возвращает подписку id, удовлетворяющую условию state, вместе с историей
+func (r *Repository) getByID(ctx context.Context, id uuid.UUID, state squirrel.Sqlizer, op string) (*models.Subscription, error) {
+	queryBuilder := squirrel.Select(subscriptionColumns...).
 		From("subscriptions").
-		Where(squirrel.Eq{"user_id": id}).PlaceholderFormat(squirrel.Dollar)
+		Where(squirrel.Eq{"id": id}).
+		Where(state).
+		PlaceholderFormat(squirrel.Dollar)
 
 	sqlStr, args, err := queryBuilder.ToSql()
 	if err != nil {
-		log.Printf("GetByID: ошибка формирования SQL: %v", err)
+		log.Printf("%s: ошибка формирования SQL: %v", op, err)
 		return nil, err
 	}
 
 	var sub models.Subscription
 
 	row := r.db.QueryRow(ctx, sqlStr, args...)
-	err = row.Scan(
-		&sub.ID,
-		&sub.ServiceName,
-		&sub.Price,
-		&sub.UserID,
-		&sub.StartDate,
-		&sub.EndDate,
-	)
+	err = scanSubscription(row, &sub)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
-		log.Printf("GetByID: ошибка при сканировании результата: %v", err)
+		log.Printf("%s: ошибка при сканировании результата: %v", op, err)
 		return nil, err
 	}
 
-	return &sub, nil
+	subs := []models.Subscription{sub}
+	if err := r.attachHistory(ctx, subs); err != nil {
+		return nil, err
+	}
+
+	return &subs[0], nil
 }
 
-// Update обновляет существующую подписку
-func (r *Repository) Update(ctx context.Context, sub *models.Subscription) error {
+// Update обновляет существующую подписку.
+// Если columns заданы, в запрос попадают только они, остальные колонки не меняются.
+// Строка обновляется, только если её версия равна sub.Version; новая версия
+// записывается в sub.Version.
+func (r *Repository) Update(ctx context.Context, sub *models.Subscription, change *Change, columns ...string) error {
+	if len(columns) == 0 {
+		columns = updatableColumns
+	}
+
+	values := columnValues(sub)
 	queryBuilder := squirrel.Update("subscriptions").
-		Set("service_name", sub.ServiceName).
-		Set("price", sub.Price).
-		Set("start_date", sub.StartDate).
-		Set("end_date", sub.EndDate).
-		Where(squirrel.Eq{"user_id": sub.UserID}).PlaceholderFormat(squirrel.Dollar)
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": sub.ID, "version": sub.Version}).
+		Where(notDeleted).
+		Suffix("RETURNING version").
+		PlaceholderFormat(squirrel.Dollar)
+	for _, column := range columns {
+		value, ok := values[column]
+		if !ok {
+			return fmt.Errorf("column %q cannot be updated", column)
+		}
+		queryBuilder = queryBuilder.Set(column, value)
+	}
 
 	sqlStr, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -107,48 +264,102 @@ func (r *Repository) Update(ctx context.Context, sub *models.Subscription) error
 		return err
 	}
 
-	cmdTag, err := r.db.Exec(ctx, sqlStr, args...)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("Update: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, sqlStr, args...).Scan(&sub.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("Update: подписка %s версии %d не найдена", sub.ID, sub.Version)
+		return r.versionMismatch(ctx, sub.ID)
+	}
 	if err != nil {
 		log.Printf("Update: ошибка выполнения SQL: %v", err)
 		return err
 	}
-	if cmdTag.RowsAffected() != 1 {
-		log.Printf("Update: строк не обновлено (RowsAffected=%d)", cmdTag.RowsAffected())
-		return fmt.Errorf("no rows affected")
+	if err := recordChange(ctx, tx, change, models.ActionUpdate, sub.ID, "Update"); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit(ctx)
 }
 
-// Delete удаляет подписку по ID
-func (r *Repository) Delete(ctx context.Context, userID uuid.UUID) error {
-	queryBuilder := squirrel.Delete("subscriptions").
-		Where(squirrel.Eq{"user_id": userID}).PlaceholderFormat(squirrel.Dollar)
+// Delete помечает подписку удаленной, если её версия равна version.
+// Строка и история подписки остаются в базе до PurgeDeleted.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID, version int, change *Change) error {
+	queryBuilder := squirrel.Update("subscriptions").
+		Set("deleted_at", squirrel.Expr("now()")).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": id, "version": version}).
+		Where(notDeleted).
+		PlaceholderFormat(squirrel.Dollar)
 
 	sqlStr, args, err := queryBuilder.ToSql()
 	if err != nil {
-		log.Printf("Update: успешно обновлена подписка для user_id=%s", userID)
+		log.Printf("Delete: ошибка формирования SQL: %v", err)
 		return err
 	}
 
-	cmdTag, err := r.db.Exec(ctx, sqlStr, args...)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("Delete: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmdTag, err := tx.Exec(ctx, sqlStr, args...)
 	if err != nil {
 		log.Printf("Delete: ошибка выполнения SQL: %v", err)
 		return err
 	}
 	if cmdTag.RowsAffected() != 1 {
 		log.Printf("Delete: строк не удалено (RowsAffected=%d)", cmdTag.RowsAffected())
-		return fmt.Errorf("no rows affected")
+		return r.versionMismatch(ctx, id)
+	}
+	if err := recordChange(ctx, tx, change, models.ActionDelete, id, "Delete"); err != nil {
+		return err
 	}
 
+	return tx.Commit(ctx)
+}
+
+// versionMismatch объясняет, почему запрос с проверкой версии не затронул строку:
+// ErrVersionConflict, если подписка существует, иначе ErrNotFound
+func (r *Repository) versionMismatch(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	err := r.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM subscriptions WHERE id = $1 AND deleted_at IS NULL)", id,
+	).Scan(&exists)
+	if err != nil {
+		log.Printf("versionMismatch: ошибка чтения подписки: %v", err)
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
+}
+
+// bumpVersion увеличивает версию подписки, у которой в транзакции tx
+// изменились связанные данные (паузы, цены), чтобы сменился её ETag
+func bumpVersion(ctx context.Context, tx pgx.Tx, id uuid.UUID, op string) error {
+	if _, err := tx.Exec(ctx, "UPDATE subscriptions SET version = version + 1 WHERE id = $1", id); err != nil {
+		log.Printf("%s: ошибка обновления версии подписки: %v", op, err)
+		return err
+	}
 	return nil
 }
 
 // Получение всех подписок
-func (r *Repository) GetAllSubscriptions(ctx context.Context, limit, offset int) ([]models.Subscription, error) {
-	queryBuilder := squirrel.Select("id", "service_name", "price", "user_id", "start_date", "end_date").
+func (r *Repository) GetAllSubscriptions(ctx context.Context, limit, offset int, filter ListFilter) ([]models.Subscription, error) {
+	queryBuilder := squirrel.Select(subscriptionColumns...).
 		From("subscriptions").
+		OrderBy(listOrder(filter.Sort)...).
 		PlaceholderFormat(squirrel.Dollar)
+	queryBuilder = applyListFilter(queryBuilder, filter)
 
 	// Добавляем лимит и смещение
 	if limit > 0 {
@@ -158,50 +369,85 @@ func (r *Repository) GetAllSubscriptions(ctx context.Context, limit, offset int)
 		queryBuilder = queryBuilder.Offset(uint64(offset))
 	}
 
-	sqlStr, args, err := queryBuilder.ToSql()
-	if err != nil {
-		log.Printf("GetSubscriptions: ошибка формирования SQL: %v", err)
-		return nil, err
+	return r.querySubscriptions(ctx, "GetSubscriptions", queryBuilder)
+}
+
+// ListSubscriptionsAfter возвращает до limit подписок, идущих после курсора after.
+// Без курсора выборка начинается с начала списка. Порядок всегда (start_date, id),
+// filter.Sort не учитывается.
+func (r *Repository) ListSubscriptionsAfter(ctx context.Context, after *ListCursor, limit int, filter ListFilter) ([]models.Subscription, error) {
+	queryBuilder := squirrel.Select(subscriptionColumns...).
+		From("subscriptions").
+		OrderBy("start_date", "id").
+		PlaceholderFormat(squirrel.Dollar)
+	queryBuilder = applyListFilter(queryBuilder, filter)
+
+	// Сравнение кортежей использует индекс idx_subscriptions_start_date_id
+	if after != nil {
+		queryBuilder = queryBuilder.Where("(start_date, id) > (?, ?)", after.StartDate, after.ID)
+	}
+	if limit > 0 {
+		queryBuilder = queryBuilder.Limit(uint64(limit))
 	}
 
-	rows, err := r.db.Query(ctx, sqlStr, args...)
+	return r.querySubscriptions(ctx, "ListSubscriptionsAfter", queryBuilder)
+}
+
+// CountSubscriptions возвращает число подписок, подходящих под фильтр
+func (r *Repository) CountSubscriptions(ctx context.Context, filter ListFilter) (int, error) {
+	queryBuilder := squirrel.Select("COUNT(*)").
+		From("subscriptions").
+		PlaceholderFormat(squirrel.Dollar)
+	queryBuilder = applyListFilter(queryBuilder, filter)
+
+	query, args, err := queryBuilder.ToSql()
 	if err != nil {
-		log.Printf("GetSubscriptions: ошибка выполнения запроса: %v", err)
-		return nil, err
+		log.Printf("CountSubscriptions: ошибка формирования SQL: %v", err)
+		return 0, err
 	}
-	defer rows.Close()
 
-	var subs []models.Subscription
-	for rows.Next() {
-		var s models.Subscription
-		err := rows.Scan(&s.ID, &s.ServiceName, &s.Price, &s.UserID, &s.StartDate, &s.EndDate)
-		if err != nil {
-			log.Printf("GetSubscriptions: ошибка сканирования строки: %v", err)
-			return nil, err
-		}
-		subs = append(subs, s)
+	var count int
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		log.Printf("CountSubscriptions: ошибка выполнения SQL: %v", err)
+		return 0, err
 	}
+	return count, nil
+}
 
-	return subs, nil
+// ListByUser возвращает подписки одного пользователя
+func (r *Repository) ListByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Subscription, error) {
+	queryBuilder := squirrel.Select(subscriptionColumns...).
+		From("subscriptions").
+		Where(squirrel.Eq{"user_id": userID}).
+		Where(notDeleted).
+		OrderBy("start_date", "id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if limit > 0 {
+		queryBuilder = queryBuilder.Limit(uint64(limit))
+	}
+	if offset > 0 {
+		queryBuilder = queryBuilder.Offset(uint64(offset))
+	}
+
+	return r.querySubscriptions(ctx, "ListByUser", queryBuilder)
 }
 
-// Подсчет стоимости подписки по указанной дате в запросе
+// Подсчет стоимости подписок по указанной дате в запросе.
+// Суммы возвращаются в минорных единицах отдельно по каждой валюте.
 func (r *Repository) GetTotalSubscriptionCost(
 	ctx context.Context,
 	date time.Time,
 	filterByUser bool,
 	userID uuid.UUID,
 	serviceName string,
-) (float64, error) {
+) (map[string]float64, error) {
 
-	queryBuilder := squirrel.Select("price", "start_date", "end_date").
+	queryBuilder := squirrel.Select(subscriptionColumns...).
 		From("subscriptions").
-		Where(
-			squirrel.And{
-				squirrel.LtOrEq{"start_date": date},
-				squirrel.GtOrEq{"end_date": date},
-			},
-		).PlaceholderFormat(squirrel.Dollar)
+		Where(activeBetween(date, date)).
+		Where(notDeleted).
+		PlaceholderFormat(squirrel.Dollar)
 
 	if filterByUser {
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"user_id": userID})
@@ -211,54 +457,117 @@ func (r *Repository) GetTotalSubscriptionCost(
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"service_name": serviceName})
 	}
 
+	subs, err := r.querySubscriptions(ctx, "GetTotalSubscriptionCost", queryBuilder)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := map[string]float64{}
+	for i := range subs {
+		// Стоимость за один день date с учетом периодичности списания и пауз
+		totals[subs[i].Currency] += subscriptionCost(&subs[i], date, date)
+	}
+
+	return totals, nil
+}
+
+// GetCostReport считает помесячную стоимость подписок за период [from, to].
+// Все подписки, пересекающиеся с периодом, выбираются одним запросом,
+// стоимость элементов отчета возвращается в валюте подписки.
+func (r *Repository) GetCostReport(ctx context.Context, from, to time.Time, filter CostFilter) ([]models.CostReportMonth, error) {
+	queryBuilder := squirrel.Select(subscriptionColumns...).
+		From("subscriptions").
+		Where(activeBetween(from, to)).
+		Where(notDeleted).
+		OrderBy("start_date", "id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if filter.UserID != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"user_id": *filter.UserID})
+	}
+	if filter.ServiceName != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"service_name": filter.ServiceName})
+	}
+
+	subs, err := r.querySubscriptions(ctx, "GetCostReport", queryBuilder)
+	if err != nil {
+		return nil, err
+	}
+
+	return monthlyBreakdown(subs, from, to), nil
+}
+
+// ListTrialsEndingBetween возвращает действующие подписки,
+// у которых пробный период заканчивается в [from, to]
+func (r *Repository) ListTrialsEndingBetween(ctx context.Context, from, to time.Time) ([]models.Subscription, error) {
+	queryBuilder := squirrel.Select(subscriptionColumns...).
+		From("subscriptions").
+		Where(squirrel.And{
+			squirrel.GtOrEq{"trial_end": from},
+			squirrel.LtOrEq{"trial_end": to},
+			squirrel.Or{squirrel.Eq{"end_date": nil}, squirrel.Expr("end_date >= trial_end")},
+		}).
+		Where(notDeleted).
+		OrderBy("trial_end", "id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	return r.querySubscriptions(ctx, "ListTrialsEndingBetween", queryBuilder)
+}
+
+// querySubscriptions выполняет выборку по subscriptionColumns
+// и подгружает к найденным подпискам историю пауз и цен
+func (r *Repository) querySubscriptions(ctx context.Context, op string, queryBuilder squirrel.SelectBuilder) ([]models.Subscription, error) {
 	sqlStr, args, err := queryBuilder.ToSql()
 	if err != nil {
-		log.Printf("GetTotalSubscriptionCost : ошибка формирования SQL :%v", err)
-		return 0, err
+		log.Printf("%s: ошибка формирования SQL: %v", op, err)
+		return nil, err
 	}
 
 	rows, err := r.db.Query(ctx, sqlStr, args...)
 	if err != nil {
-		log.Printf("GetTotalSubscriptionCost : ошибка выполнения запроса :%v", err)
-		return 0, err
+		log.Printf("%s: ошибка выполнения запроса: %v", op, err)
+		return nil, err
 	}
 	defer rows.Close()
 
-	var total float64 = 0
+	subs := []models.Subscription{}
 	for rows.Next() {
-		var price float64
-		var startDate, endDate time.Time
-		if err := rows.Scan(&price, &startDate, &endDate); err != nil {
-			log.Printf("GetTotalSubscriptionCost: ошибка сканирования строки: %v", err)
-			return 0, err
-		}
-
-		// Определяем пересечение периода с датой
-		start := startDate
-		end := endDate
-
-		if start.Before(date) {
-			start = date
-		}
-		if end.After(date) {
-			end = date
+		var s models.Subscription
+		if err := scanSubscription(rows, &s); err != nil {
+			log.Printf("%s: ошибка сканирования строки: %v", op, err)
+			return nil, err
 		}
+		subs = append(subs, s)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("%s: ошибка чтения результата: %v", op, err)
+		return nil, err
+	}
 
-		daysActive := end.Sub(start).Hours()/24 + 1 // +1 чтобы включительно считать день
-
-		if daysActive > 0 {
-			total += price * (daysActive / float64(daysInMonth(date)))
-		}
+	if err := r.attachHistory(ctx, subs); err != nil {
+		return nil, err
 	}
+	return subs, nil
+}
 
-	return total, nil
+// attachHistory подгружает к подпискам историю пауз и цен
+func (r *Repository) attachHistory(ctx context.Context, subs []models.Subscription) error {
+	if err := r.attachPauses(ctx, subs); err != nil {
+		return err
+	}
+	return r.attachPrices(ctx, subs)
 }
 
-// helper функции:
-func daysInMonth(t time.Time) int {
-	firstOfMonth := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
-	firstOfNextMonth := firstOfMonth.AddDate(0, 1, 0)
-	return int(firstOfNextMonth.Sub(firstOfMonth).Hours() / 24)
+// activeBetween отбирает подписки, активные хотя бы один день в [from, to].
+// Пустой end_date означает подписку до отмены.
+func activeBetween(from, to time.Time) squirrel.Sqlizer {
+	return squirrel.And{
+		squirrel.LtOrEq{"start_date": to},
+		squirrel.Or{
+			squirrel.Eq{"end_date": nil},
+			squirrel.GtOrEq{"end_date": from},
+		},
+	}
 }
 
-var _ SubscriptionRepository = (*Repository)(nil)
+var _ Storage = (*Repository)(nil)
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+// SearchSubscriptions ищет подписки, название сервиса которых похоже на query.
+// Сравнение идет по триграммам (pg_trgm) без учета регистра: подходят названия,
+// похожие на запрос целиком или содержащие похожее на него слово.
+// Результаты упорядочены по убыванию релевантности — среднего similarity и
+// word_similarity, поэтому полное совпадение названия выше совпадения одного слова.
+func (r *Repository) SearchSubscriptions(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]models.SearchResult, error) {
+	// Операторы % и <% используют индекс idx_subscriptions_service_name_trgm
+	queryBuilder := squirrel.Select(subscriptionColumns...).
+		Column("(similarity(lower(service_name), lower(?)) + word_similarity(lower(?), lower(service_name))) / 2 AS relevance", query, query).
+		From("subscriptions").
+		Where(notDeleted).
+		Where("(lower(service_name) % lower(?) OR lower(?) <% lower(service_name))", query, query).
+		OrderBy("relevance DESC", "service_name", "id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	if userID != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"user_id": *userID})
+	}
+	if limit > 0 {
+		queryBuilder = queryBuilder.Limit(uint64(limit))
+	}
+
+	sqlStr, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Printf("SearchSubscriptions: ошибка формирования SQL: %v", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, sqlStr, args...)
+	if err != nil {
+		log.Printf("SearchSubscriptions: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.Subscription
+	var relevance []float64
+	for rows.Next() {
+		var s models.Subscription
+		var rel float64
+		if err := scanSubscription(rows, &s, &rel); err != nil {
+			log.Printf("SearchSubscriptions: ошибка сканирования строки: %v", err)
+			return nil, err
+		}
+		subs = append(subs, s)
+		relevance = append(relevance, rel)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("SearchSubscriptions: ошибка чтения результата: %v", err)
+		return nil, err
+	}
+
+	if err := r.attachHistory(ctx, subs); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.SearchResult, len(subs))
+	for i := range subs {
+		results[i] = models.SearchResult{Subscription: subs[i], Relevance: relevance[i]}
+	}
+	return results, nil
+}
@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/EvgenyiK/subscription-service/internal/models"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+var (
+	// ErrServiceNotFound возвращается, если записи каталога с указанным ID нет
+	ErrServiceNotFound = errors.New("service not found")
+	// ErrServiceConflict возвращается, если название или псевдоним уже заняты другой записью
+	ErrServiceConflict = errors.New("service name or alias is already taken")
+)
+
+// ServiceCatalog — каталог сервисов, на записи которого ссылаются подписки
+type ServiceCatalog interface {
+	CreateService(ctx context.Context, svc *models.Service) error
+	GetService(ctx context.Context, id uuid.UUID) (*models.Service, error)
+	ListServices(ctx context.Context, category string) ([]models.Service, error)
+	// UpdateService и DeleteService меняют и связанные неудаленные подписки;
+	// change задает автора и запрос для записи журнала о каждой из них
+	UpdateService(ctx context.Context, svc *models.Service, change *Change) error
+	DeleteService(ctx context.Context, id uuid.UUID, change *Change) error
+	// ResolveService ищет запись по названию или псевдониму без учета регистра
+	ResolveService(ctx context.Context, name string) (*models.Service, error)
+}
+
+// serviceColumns — порядок колонок, который ожидает scanService
+var serviceColumns = []string{
+	"id", "name", "aliases", "category", "website", "default_price", "currency", "billing_period",
+}
+
+func scanService(row pgx.Row, svc *models.Service) error {
+	return row.Scan(
+		&svc.ID,
+		&svc.Name,
+		&svc.Aliases,
+		&svc.Category,
+		&svc.Website,
+		&svc.DefaultPrice,
+		&svc.Currency,
+		&svc.BillingPeriod,
+	)
+}
+
+// serviceKeys возвращает все написания, по которым находится запись каталога
+func serviceKeys(svc *models.Service) []string {
+	return append([]string{strings.ToLower(svc.Name)}, svc.Aliases...)
+}
+
+// serviceAliases возвращает псевдонимы для записи в aliases: nil pgx передал бы как NULL
+func serviceAliases(svc *models.Service) []string {
+	if svc.Aliases == nil {
+		return []string{}
+	}
+	return svc.Aliases
+}
+
+// CreateService добавляет запись в каталог
+func (r *Repository) CreateService(ctx context.Context, svc *models.Service) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("CreateService: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkServiceConflict(ctx, tx, svc, "CreateService"); err != nil {
+		return err
+	}
+
+	queryBuilder := squirrel.Insert("services").
+		Columns(serviceColumns...).
+		Values(svc.ID, svc.Name, serviceAliases(svc), svc.Category, svc.Website, svc.DefaultPrice, svc.Currency, svc.BillingPeriod).
+		PlaceholderFormat(squirrel.Dollar)
+
+	sqlStr, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Printf("CreateService: ошибка формирования SQL: %v", err)
+		return err
+	}
+	if _, err := tx.Exec(ctx, sqlStr, args...); err != nil {
+		log.Printf("CreateService: ошибка выполнения SQL: %v", err)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetService возвращает запись каталога по id
+func (r *Repository) GetService(ctx context.Context, id uuid.UUID) (*models.Service, error) {
+	return r.getService(ctx, "GetService", squirrel.Select(serviceColumns...).
+		From("services").
+		Where(squirrel.Eq{"id": id}))
+}
+
+// ResolveService ищет запись по названию или псевдониму без учета регистра.
+// Совпадение с названием важнее совпадения с псевдонимом.
+func (r *Repository) ResolveService(ctx context.Context, name string) (*models.Service, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	return r.getService(ctx, "ResolveService", squirrel.Select(serviceColumns...).
+		From("services").
+		Where("(lower(name) = ? OR ? = ANY(aliases))", key, key).
+		OrderByClause("lower(name) = ? DESC", key).
+		Limit(1))
+}
+
+func (r *Repository) getService(ctx context.Context, op string, queryBuilder squirrel.SelectBuilder) (*models.Service, error) {
+	sqlStr, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		log.Printf("%s: ошибка формирования SQL: %v", op, err)
+		return nil, err
+	}
+
+	var svc models.Service
+	err = scanService(r.db.QueryRow(ctx, sqlStr, args...), &svc)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrServiceNotFound
+	}
+	if err != nil {
+		log.Printf("%s: ошибка при сканировании результата: %v", op, err)
+		return nil, err
+	}
+	return &svc, nil
+}
+
+// ListServices возвращает записи каталога по алфавиту; пустая category не ограничивает выборку
+func (r *Repository) ListServices(ctx context.Context, category string) ([]models.Service, error) {
+	queryBuilder := squirrel.Select(serviceColumns...).
+		From("services").
+		OrderBy("lower(name)").
+		PlaceholderFormat(squirrel.Dollar)
+	if category != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"category": category})
+	}
+
+	sqlStr, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Printf("ListServices: ошибка формирования SQL: %v", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, sqlStr, args...)
+	if err != nil {
+		log.Printf("ListServices: ошибка выполнения запроса: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	services := []models.Service{}
+	for rows.Next() {
+		var svc models.Service
+		if err := scanService(rows, &svc); err != nil {
+			log.Printf("ListServices: ошибка сканирования строки: %v", err)
+			return nil, err
+		}
+		services = append(services, svc)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("ListServices: ошибка чтения результата: %v", err)
+		return nil, err
+	}
+	return services, nil
+}
+
+// UpdateService изменяет запись каталога. Связанные подписки получают новое название,
+// чтобы service_name оставался каноническим.
+func (r *Repository) UpdateService(ctx context.Context, svc *models.Service, change *Change) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("UpdateService: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkServiceConflict(ctx, tx, svc, "UpdateService"); err != nil {
+		return err
+	}
+
+	queryBuilder := squirrel.Update("services").
+		Set("name", svc.Name).
+		Set("aliases", serviceAliases(svc)).
+		Set("category", svc.Category).
+		Set("website", svc.Website).
+		Set("default_price", svc.DefaultPrice).
+		Set("currency", svc.Currency).
+		Set("billing_period", svc.BillingPeriod).
+		Where(squirrel.Eq{"id": svc.ID}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	sqlStr, args, err := queryBuilder.ToSql()
+	if err != nil {
+		log.Printf("UpdateService: ошибка формирования SQL: %v", err)
+		return err
+	}
+	cmdTag, err := tx.Exec(ctx, sqlStr, args...)
+	if err != nil {
+		log.Printf("UpdateService: ошибка выполнения SQL: %v", err)
+		return err
+	}
+	if cmdTag.RowsAffected() != 1 {
+		return ErrServiceNotFound
+	}
+
+	// Версия меняется у всех подписок сервиса, как и при любом изменении подписки
+	subs, err := r.linkedSubscriptions(ctx, tx, svc.ID, "UpdateService")
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx,
+		"UPDATE subscriptions SET service_name = $1, version = version + 1 WHERE service_id = $2 AND deleted_at IS NULL",
+		svc.Name, svc.ID,
+	); err != nil {
+		log.Printf("UpdateService: ошибка обновления подписок: %v", err)
+		return err
+	}
+	err = recordLinkedChanges(ctx, tx, change, subs, func(sub *models.Subscription) {
+		sub.ServiceName = svc.Name
+	}, "UpdateService")
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteService удаляет запись каталога; подписки сохраняют service_name и теряют service_id
+func (r *Repository) DeleteService(ctx context.Context, id uuid.UUID, change *Change) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("DeleteService: ошибка начала транзакции: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// service_id обнуляется внешним ключом, версию неудаленных подписок увеличиваем сами
+	subs, err := r.linkedSubscriptions(ctx, tx, id, "DeleteService")
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx,
+		"UPDATE subscriptions SET version = version + 1 WHERE service_id = $1 AND deleted_at IS NULL", id,
+	); err != nil {
+		log.Printf("DeleteService: ошибка обновления подписок: %v", err)
+		return err
+	}
+	err = recordLinkedChanges(ctx, tx, change, subs, func(sub *models.Subscription) {
+		sub.ServiceID = nil
+	}, "DeleteService")
+	if err != nil {
+		return err
+	}
+
+	cmdTag, err := tx.Exec(ctx, "DELETE FROM services WHERE id = $1", id)
+	if err != nil {
+		log.Printf("DeleteService: ошибка выполнения SQL: %v", err)
+		return err
+	}
+	if cmdTag.RowsAffected() != 1 {
+		return ErrServiceNotFound
+	}
+
+	return tx.Commit(ctx)
+}
+
+// linkedSubscriptions читает неудаленные подписки сервиса id вместе с историей
+// и блокирует их строки до конца транзакции tx
+func (r *Repository) linkedSubscriptions(ctx context.Context, tx pgx.Tx, id uuid.UUID, op string) ([]models.Subscription, error) {
+	sqlStr, args, err := squirrel.Select(subscriptionColumns...).
+		From("subscriptions").
+		Where(squirrel.Eq{"service_id": id}).
+		Where(notDeleted).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		log.Printf("%s: ошибка формирования SQL: %v", op, err)
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, sqlStr, args...)
+	if err != nil {
+		log.Printf("%s: ошибка чтения подписок сервиса: %v", op, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []models.Subscription{}
+	for rows.Next() {
+		var sub models.Subscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			log.Printf("%s: ошибка сканирования строки: %v", op, err)
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("%s: ошибка чтения результата: %v", op, err)
+		return nil, err
+	}
+
+	if err := r.attachHistory(ctx, subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// recordLinkedChanges записывает в журнал изменение каждой подписки subs:
+// apply переносит в снимок после изменения то, что изменение каталога поменяло в подписке
+func recordLinkedChanges(ctx context.Context, tx pgx.Tx, change *Change, subs []models.Subscription, apply func(sub *models.Subscription), op string) error {
+	for i := range subs {
+		before := subs[i]
+		before.RefreshDerived()
+		after := before
+		apply(&after)
+		after.Version++
+		if err := recordChange(ctx, tx, change.forSubscription(&before, &after), models.ActionUpdate, before.ID, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkServiceConflict проверяет, что название и псевдонимы svc не заняты другими записями.
+// Блокировка таблицы до конца транзакции не дает двум запросам занять одно название.
+func checkServiceConflict(ctx context.Context, tx pgx.Tx, svc *models.Service, op string) error {
+	if _, err := tx.Exec(ctx, "LOCK TABLE services IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		log.Printf("%s: ошибка блокировки каталога: %v", op, err)
+		return err
+	}
+
+	var taken bool
+	err := tx.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM services WHERE id <> $1 AND (lower(name) = ANY($2) OR aliases && $2))",
+		svc.ID, serviceKeys(svc),
+	).Scan(&taken)
+	if err != nil {
+		log.Printf("%s: ошибка проверки названия: %v", op, err)
+		return err
+	}
+	if taken {
+		return ErrServiceConflict
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Пороги операторов % и <% по умолчанию в pg_trgm
+const (
+	similarityThreshold     = 0.3
+	wordSimilarityThreshold = 0.6
+)
+
+// Функции ниже повторяют similarity и word_similarity из pg_trgm,
+// чтобы MemoryRepository находил то же, что и Postgres.
+
+// trigrams возвращает триграммы строки в порядке появления.
+// Как в pg_trgm, строка делится на слова из букв и цифр, каждое слово
+// дополняется двумя пробелами в начале и одним в конце.
+func trigrams(s string) []string {
+	var result []string
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			result = append(result, string(padded[i:i+3]))
+		}
+	}
+	return result
+}
+
+func trigramSet(trgs []string) map[string]bool {
+	set := make(map[string]bool, len(trgs))
+	for _, t := range trgs {
+		set[t] = true
+	}
+	return set
+}
+
+// trigramSimilarity — доля общих триграмм двух строк
+func trigramSimilarity(a, b string) float64 {
+	setA, setB := trigramSet(trigrams(a)), trigramSet(trigrams(b))
+	if len(setA) == 0 || len(setB) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range setA {
+		if setB[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(setA)+len(setB)-shared)
+}
+
+// wordSimilarity — наибольшая похожесть query на непрерывный участок триграмм text
+func wordSimilarity(query, text string) float64 {
+	setQ := trigramSet(trigrams(query))
+	textTrgs := trigrams(text)
+	if len(setQ) == 0 || len(textTrgs) == 0 {
+		return 0
+	}
+
+	best := 0.0
+	for start := range textTrgs {
+		extent := make(map[string]bool)
+		shared := 0
+		for _, t := range textTrgs[start:] {
+			if !extent[t] {
+				extent[t] = true
+				if setQ[t] {
+					shared++
+				}
+			}
+			if sim := float64(shared) / float64(len(setQ)+len(extent)-shared); sim > best {
+				best = sim
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,35 @@
+package repository
+
+import "testing"
+
+// Пара "word" / "two words" — пример из документации pg_trgm
+func TestTrigramSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"word", "two words", 4.0 / 11},
+		{"Netflix", "NETFLIX", 1},
+		{"netflix", "netfix", 0.5},
+		{"netflix", "spotify", 0},
+		{"", "netflix", 0},
+	}
+	for _, tt := range tests {
+		assertClose(t, trigramSimilarity(tt.a, tt.b), tt.want)
+	}
+}
+
+func TestWordSimilarity(t *testing.T) {
+	tests := []struct {
+		query, text string
+		want        float64
+	}{
+		{"word", "two words", 0.8},
+		{"netflix", "Netflix Premium", 1},
+		{"premium", "Netflix Premium", 1},
+		{"netflix", "spotify", 0},
+	}
+	for _, tt := range tests {
+		assertClose(t, wordSimilarity(tt.query, tt.text), tt.want)
+	}
+}
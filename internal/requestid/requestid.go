@@ -0,0 +1,51 @@
+// Package requestid присваивает каждому HTTP-запросу идентификатор,
+// по которому запрос можно найти в логах и журнале изменений.
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Header — заголовок, в котором ID запроса принимается от клиента и возвращается ему
+const Header = "X-Request-ID"
+
+// maxLength ограничивает длину ID, переданного клиентом
+const maxLength = 128
+
+type contextKey struct{}
+
+// Middleware берет ID запроса из заголовка X-Request-ID или создает новый,
+// кладет его в контекст запроса и возвращает клиенту в том же заголовке
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
+
+// FromContext возвращает ID запроса или пустую строку, если Middleware не вызывался
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// valid принимает непустые ID из печатных ASCII-символов разумной длины
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
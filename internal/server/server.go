@@ -2,6 +2,7 @@ package server
 
 import (
 	"github.com/EvgenyiK/subscription-service/internal/handlers"
+	"github.com/EvgenyiK/subscription-service/internal/requestid"
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	"github.com/gorilla/mux"
@@ -9,6 +10,7 @@ import (
 
 func NewRouter(h *handlers.Handler) *mux.Router {
 	r := mux.NewRouter()
+	r.Use(requestid.Middleware)
 
 	// Группировка маршрутов по пути "/subscriptions"
 	subsRouter := r.PathPrefix("/subscriptions").Subrouter()
@@ -16,12 +18,45 @@ func NewRouter(h *handlers.Handler) *mux.Router {
 	// Маршруты для просмотра и подсчета
 	subsRouter.HandleFunc("/view/list", h.ListSubscriptions).Methods("GET")
 	subsRouter.HandleFunc("/view/total/{date}", h.GetTotalCost).Methods("GET")
+	subsRouter.HandleFunc("/view/report", h.GetCostReport).Methods("GET")
+	subsRouter.HandleFunc("/view/breakdown", h.GetCostBreakdown).Methods("GET")
+	subsRouter.HandleFunc("/upcoming", h.ListUpcomingCharges).Methods("GET")
+
+	// Поиск по названию сервиса
+	subsRouter.HandleFunc("/search", h.SearchSubscriptions).Methods("GET")
 
 	// CRUD операции для подписок
 	subsRouter.HandleFunc("", h.CreateSubscription).Methods("POST")
 	subsRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}", h.GetSubscription).Methods("GET")
 	subsRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}", h.UpdateSubscription).Methods("PUT")
+	subsRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}", h.PatchSubscription).Methods("PATCH")
 	subsRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}", h.DeleteSubscription).Methods("DELETE")
+	subsRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}/restore", h.RestoreSubscription).Methods("POST")
+	subsRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}/history", h.GetSubscriptionHistory).Methods("GET")
+	subsRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}/cancel", h.CancelSubscription).Methods("POST")
+	subsRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}/pause", h.PauseSubscription).Methods("POST")
+	subsRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}/resume", h.ResumeSubscription).Methods("POST")
+	subsRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}/prices", h.SchedulePriceChange).Methods("POST")
+
+	// Подписки конкретного пользователя
+	usersRouter := r.PathPrefix("/users/{user_id:[0-9a-fA-F-]{36}}").Subrouter()
+	usersRouter.HandleFunc("/subscriptions", h.ListUserSubscriptions).Methods("GET")
+	usersRouter.HandleFunc("/subscriptions", h.CreateUserSubscription).Methods("POST")
+	usersRouter.HandleFunc("/subscriptions/{id:[0-9a-fA-F-]{36}}", h.GetUserSubscription).Methods("GET")
+
+	// Бюджет пользователя
+	usersRouter.HandleFunc("/budget", h.GetBudget).Methods("GET")
+	usersRouter.HandleFunc("/budget", h.SetBudget).Methods("PUT")
+	usersRouter.HandleFunc("/budget", h.DeleteBudget).Methods("DELETE")
+	usersRouter.HandleFunc("/budget/status", h.GetBudgetStatus).Methods("GET")
+
+	// Каталог сервисов
+	servicesRouter := r.PathPrefix("/services").Subrouter()
+	servicesRouter.HandleFunc("", h.ListServices).Methods("GET")
+	servicesRouter.HandleFunc("", h.CreateService).Methods("POST")
+	servicesRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}", h.GetService).Methods("GET")
+	servicesRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}", h.UpdateService).Methods("PUT")
+	servicesRouter.HandleFunc("/{id:[0-9a-fA-F-]{36}}", h.DeleteService).Methods("DELETE")
 
 	subsRouter.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
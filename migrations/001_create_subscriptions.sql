@@ -7,5 +7,8 @@ CREATE TABLE IF NOT EXISTS subscriptions (
     end_date DATE
 );
 
-CREATE INDEX idx_user_id ON subscriptions(user_id);
-CREATE INDEX idx_service_name ON subscriptions(service_name);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_user_id ON subscriptions(user_id);
+CREATE INDEX IF NOT EXISTS idx_service_name ON subscriptions(service_name);
+
+-- +migrate Down
+DROP TABLE IF EXISTS subscriptions;
@@ -0,0 +1,11 @@
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS billing_period VARCHAR(16) NOT NULL DEFAULT 'monthly';
+
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_billing_period_check;
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_billing_period_check
+    CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly'));
+
+-- +migrate Down
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_billing_period_check;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_period;
@@ -0,0 +1,26 @@
+-- Цены переводятся из целых рублей в минорные единицы валюты (копейки, центы).
+-- Пересчет выполняется только один раз, вместе с добавлением колонки currency.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'subscriptions' AND column_name = 'currency'
+    ) THEN
+        ALTER TABLE subscriptions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB';
+        ALTER TABLE subscriptions ALTER COLUMN price TYPE BIGINT;
+        UPDATE subscriptions SET price = price * 100;
+    END IF;
+END $$;
+
+-- +migrate Down
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'subscriptions' AND column_name = 'currency'
+    ) THEN
+        UPDATE subscriptions SET price = price / 100;
+        ALTER TABLE subscriptions ALTER COLUMN price TYPE INTEGER;
+        ALTER TABLE subscriptions DROP COLUMN currency;
+    END IF;
+END $$;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS subscription_cancellations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_date DATE NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    cancelled_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_cancellations_subscription_id
+    ON subscription_cancellations(subscription_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS subscription_cancellations;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (end_date IS NULL OR end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_pauses_subscription_id
+    ON subscription_pauses(subscription_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS subscription_pauses;
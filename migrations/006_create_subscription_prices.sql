@@ -0,0 +1,13 @@
+-- История изменения цен: цена действует с effective_from до следующего изменения.
+-- До первого изменения действует subscriptions.price.
+CREATE TABLE IF NOT EXISTS subscription_prices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    price BIGINT NOT NULL CHECK (price > 0),
+    effective_from DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (subscription_id, effective_from)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS subscription_prices;
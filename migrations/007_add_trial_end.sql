@@ -0,0 +1,9 @@
+-- trial_end — первый оплачиваемый день; дни до него не входят в стоимость
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS trial_end DATE;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_trial_end
+    ON subscriptions(trial_end) WHERE trial_end IS NOT NULL;
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_subscriptions_trial_end;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_end;
@@ -0,0 +1,6 @@
+-- Индекс для постраничной выборки по ключу (start_date, id)
+CREATE INDEX IF NOT EXISTS idx_subscriptions_start_date_id
+    ON subscriptions(start_date, id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_subscriptions_start_date_id;
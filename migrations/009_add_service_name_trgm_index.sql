@@ -0,0 +1,9 @@
+-- Нечеткий поиск по названию сервиса без учета регистра
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_name_trgm
+    ON subscriptions USING GIN (lower(service_name) gin_trgm_ops);
+
+-- +migrate Down
+-- Расширение pg_trgm не удаляется: его могут использовать другие объекты базы
+DROP INDEX IF EXISTS idx_subscriptions_service_name_trgm;
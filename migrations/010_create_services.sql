@@ -0,0 +1,27 @@
+-- Каталог сервисов: каноническое название, псевдонимы и цена по умолчанию
+CREATE TABLE IF NOT EXISTS services (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}', -- в нижнем регистре
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    website VARCHAR(255) NOT NULL DEFAULT '',
+    default_price BIGINT CHECK (default_price >= 0),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    billing_period VARCHAR(16) NOT NULL DEFAULT 'monthly'
+        CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_services_name ON services(lower(name));
+CREATE INDEX IF NOT EXISTS idx_services_aliases ON services USING GIN (aliases);
+CREATE INDEX IF NOT EXISTS idx_services_category ON services(category);
+
+-- service_name подписки остается для отображения и для подписок вне каталога
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS service_id UUID REFERENCES services(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_id ON subscriptions(service_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_subscriptions_service_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS service_id;
+DROP TABLE IF EXISTS services;
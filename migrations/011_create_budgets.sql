@@ -0,0 +1,21 @@
+-- Месячный лимит расходов пользователя на подписки
+CREATE TABLE IF NOT EXISTS budgets (
+    user_id UUID PRIMARY KEY,
+    monthly_limit BIGINT NOT NULL CHECK (monthly_limit > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    thresholds INTEGER[] NOT NULL DEFAULT '{80,100}', -- проценты лимита
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Отправленные уведомления: не больше одного на порог в месяц
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    user_id UUID NOT NULL REFERENCES budgets(user_id) ON DELETE CASCADE,
+    month DATE NOT NULL, -- первое число месяца
+    threshold INTEGER NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, month, threshold)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS budget_alerts;
+DROP TABLE IF EXISTS budgets;
@@ -0,0 +1,10 @@
+-- Отправленные напоминания о списаниях: одно на каждое списание подписки
+CREATE TABLE IF NOT EXISTS renewal_reminders (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    charge_date DATE NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, charge_date)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS renewal_reminders;
@@ -0,0 +1,5 @@
+-- Версия строки подписки для оптимистичной блокировки; растет при каждом изменении
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- +migrate Down
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS version;
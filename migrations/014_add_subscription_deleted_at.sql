@@ -0,0 +1,9 @@
+-- Мягкое удаление: удаленная подписка хранится до очистки по сроку хранения
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Очистка ищет подписки, удаленные раньше границы срока хранения
+CREATE INDEX IF NOT EXISTS idx_subscriptions_deleted_at ON subscriptions (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_subscriptions_deleted_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS deleted_at;
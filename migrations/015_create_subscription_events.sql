@@ -0,0 +1,36 @@
+-- Журнал изменений подписок для аудита. Внешнего ключа нет:
+-- записи переживают окончательное удаление подписки.
+CREATE TABLE IF NOT EXISTS subscription_events (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete', 'cancel', 'restore')),
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_events_subscription
+    ON subscription_events (subscription_id, occurred_at, id);
+
+-- Журнал только дополняется: изменение и удаление записей запрещены
+CREATE OR REPLACE FUNCTION subscription_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'subscription_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS subscription_events_no_modify ON subscription_events;
+CREATE TRIGGER subscription_events_no_modify
+    BEFORE UPDATE OR DELETE ON subscription_events
+    FOR EACH ROW EXECUTE PROCEDURE subscription_events_append_only();
+
+DROP TRIGGER IF EXISTS subscription_events_no_truncate ON subscription_events;
+CREATE TRIGGER subscription_events_no_truncate
+    BEFORE TRUNCATE ON subscription_events
+    FOR EACH STATEMENT EXECUTE PROCEDURE subscription_events_append_only();
+
+-- +migrate Down
+DROP TABLE IF EXISTS subscription_events;
+DROP FUNCTION IF EXISTS subscription_events_append_only();
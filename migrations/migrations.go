@@ -0,0 +1,11 @@
+// Package migrations содержит SQL-миграции схемы, встроенные в бинарник.
+//
+// Файл миграции называется NNN_описание.sql, где NNN — номер версии.
+// Часть файла до строки "-- +migrate Down" применяется при migrate up,
+// часть после неё — при откате.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS